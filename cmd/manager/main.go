@@ -1,21 +1,29 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"math/rand"
+	"os"
 	"runtime"
 	"strconv"
 	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
-	_ "github.com/operator-framework/operator-sdk/pkg/metrics"
+	sdkmetrics "github.com/operator-framework/operator-sdk/pkg/metrics"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
 	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/backup"
 	"github.com/tungstenfabric/tf-operator/pkg/k8s"
+	"github.com/tungstenfabric/tf-operator/pkg/metrics"
+	"github.com/tungstenfabric/tf-operator/pkg/webhooks"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -31,6 +39,14 @@ import (
 
 var log = logf.Log.WithName("cmd")
 
+var (
+	metricsHost          = pflag.String("metrics-host", "0.0.0.0", "Address the metrics server listens on")
+	metricsPort          = pflag.Int32("metrics-port", 8383, "Port of the metrics server, 0 disables metrics")
+	createServiceMonitor = pflag.Bool("create-service-monitor", false, "Create metrics Service and Prometheus ServiceMonitor for the operator")
+	webhookPort          = pflag.Int("webhook-port", 9443, "Port of the admission webhook server, 0 disables webhooks")
+	webhookCertDir       = pflag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory for the serving certificate of the webhook server")
+)
+
 func printVersion() {
 	log.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	log.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
@@ -48,14 +64,21 @@ func runOperator(sigHandler <-chan struct{}) (err error) {
 		return err
 	}
 
+	metricsBindAddress := "0"
+	if *metricsPort != 0 {
+		metricsBindAddress = fmt.Sprintf("%s:%d", *metricsHost, *metricsPort)
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components.
 	var mgr manager.Manager
 	if mgr, err = manager.New(cfg, manager.Options{
 		Namespace:               namespace,
-		MetricsBindAddress:      "0",
+		MetricsBindAddress:      metricsBindAddress,
 		LeaderElection:          true,
 		LeaderElectionID:        "tf-manager-lock",
 		LeaderElectionNamespace: namespace,
+		Port:                    *webhookPort,
+		CertDir:                 *webhookCertDir,
 	}); err != nil {
 		log.Error(err, "Failed create Manager instance")
 		return err
@@ -63,6 +86,9 @@ func runOperator(sigHandler <-chan struct{}) (err error) {
 
 	log.Info("Registering Components.")
 
+	// Events of common helpers and ZIU are recorded on behalf of the operator
+	v1alpha1.SetEventRecorder(mgr.GetEventRecorderFor("tf-operator"))
+
 	// Setup Scheme for all resources.
 	if err = apis.AddToScheme(mgr.GetScheme()); err != nil {
 		log.Error(err, "")
@@ -81,18 +107,21 @@ func runOperator(sigHandler <-chan struct{}) (err error) {
 	}
 	log.Info("IsOpenshift=" + strconv.FormatBool(k8s.IsOpenshift()))
 
-	// Check is ZIU Required?
-	if f, err := v1alpha1.IsZiuRequired(clnt); err != nil {
+	// Check is ZIU in progress or Required?
+	if resumed, err := v1alpha1.ResumeZiu(clnt); err != nil {
+		log.Error(err, "try to resume ziu")
+		return err
+	} else if resumed {
+		// Operator has been restarted in the middle of ZIU - continue from the current stage
+		log.Info("Resume ZIU process")
+	} else if f, err := v1alpha1.IsZiuRequired(clnt); err != nil {
 		log.Error(err, "try to check if ziu required")
 		return err
 	} else {
 		if f {
 			// We start ZIU process
 			log.Info("Start ZIU process")
-			if err = v1alpha1.InitZiu(clnt); err == nil {
-				err = manager_controller.EnableZiu2011(namespace, clnt, mgr.GetScheme(), log)
-			}
-
+			err = manager_controller.StartZiu(namespace, clnt, mgr.GetScheme(), log)
 		} else {
 			// We not needed ZIU
 			log.Info("ZIU not needed")
@@ -115,11 +144,58 @@ func runOperator(sigHandler <-chan struct{}) (err error) {
 		return err
 	}
 
+	if *webhookPort != 0 {
+		if err = webhooks.Setup(mgr, clnt, namespace, *webhookPort, *webhookCertDir); err != nil {
+			log.Error(err, "Failed to setup admission webhooks")
+			return err
+		}
+	}
+
+	if *metricsPort != 0 {
+		if err = metrics.Register(mgr.GetClient(), mgr.GetScheme(), namespace); err != nil {
+			log.Error(err, "Failed to register metrics")
+			return err
+		}
+		if *createServiceMonitor {
+			serveMetrics(cfg, namespace)
+		}
+	}
+
 	log.Info("Starting")
 	return mgr.Start(sigHandler)
 }
 
+// serveMetrics creates Service and ServiceMonitor for Prometheus to scrape metrics,
+// failures are not fatal as Prometheus operator may be not installed
+func serveMetrics(cfg *rest.Config, namespace string) {
+	servicePorts := []corev1.ServicePort{{
+		Name:       sdkmetrics.OperatorPortName,
+		Protocol:   corev1.ProtocolTCP,
+		Port:       *metricsPort,
+		TargetPort: intstr.FromInt(int(*metricsPort)),
+	}}
+	service, err := sdkmetrics.CreateMetricsService(context.TODO(), cfg, servicePorts)
+	if err != nil || service == nil {
+		log.Info("Could not create metrics Service", "error", err)
+		return
+	}
+	if _, err = sdkmetrics.CreateServiceMonitors(cfg, namespace, []*corev1.Service{service}); err != nil {
+		log.Info("Could not create ServiceMonitor object", "error", err)
+	}
+}
+
 func main() {
+	// backup and restore jobs run the operator binary with the command of the job
+	if len(os.Args) > 1 && backup.IsJobCommand(os.Args[1]) {
+		logf.SetLogger(zap.Logger())
+		if err := backup.RunJob(os.Args[1], os.Args[2:]); err != nil {
+			log.Error(err, "Job failed")
+			backup.WriteTerminationMessage(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Add the zap logger flag set to the CLI. The flag set must
 	// be added before calling pflag.Parse().
 	pflag.CommandLine.AddFlagSet(zap.FlagSet())
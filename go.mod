@@ -12,6 +12,7 @@ require (
 	github.com/go-openapi/spec v0.19.3
 	github.com/google/go-cmp v0.5.2
 	github.com/operator-framework/operator-sdk v0.18.0
+	github.com/prometheus/client_golang v1.5.1
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.6.1
 	gopkg.in/ini.v1 v1.51.0
@@ -162,11 +162,8 @@ func (c *Analytics) InstanceConfiguration(podList []corev1.Pod, client client.Cl
 	configApiIPListCommaSeparated := configtemplates.JoinListWithSeparator(configNodesInformation.APIServerIPList, ",")
 	analyticsNodes := strings.Join(nodes, ",")
 
-	var collectorServerList, analyticsServerSpaceSeparatedList string
-	collectorServerList = strings.Join(nodes, ":"+strconv.Itoa(*analyticsConfig.CollectorPort)+" ")
-	collectorServerList = collectorServerList + ":" + strconv.Itoa(*analyticsConfig.CollectorPort)
-	analyticsServerSpaceSeparatedList = strings.Join(nodes, ":"+strconv.Itoa(*analyticsConfig.AnalyticsPort)+" ")
-	analyticsServerSpaceSeparatedList = analyticsServerSpaceSeparatedList + ":" + strconv.Itoa(*analyticsConfig.AnalyticsPort)
+	collectorServerList := configtemplates.JoinListWithSeparator(configtemplates.EndpointList(nodes, *analyticsConfig.CollectorPort), " ")
+	analyticsServerSpaceSeparatedList := configtemplates.JoinListWithSeparator(configtemplates.EndpointList(nodes, *analyticsConfig.AnalyticsPort), " ")
 	apiServerEndpointList := configtemplates.EndpointList(configNodesInformation.APIServerIPList, configNodesInformation.APIServerPort)
 	apiServerEndpointListSpaceSeparated := configtemplates.JoinListWithSeparator(apiServerEndpointList, " ")
 	apiServerIPListCommaSeparated := configtemplates.JoinListWithSeparator(configNodesInformation.APIServerIPList, ",")
@@ -190,11 +187,7 @@ func (c *Analytics) InstanceConfiguration(podList []corev1.Pod, client client.Cl
 	if err != nil {
 		return
 	}
-	kafkaServerSpaceSeparatedList := ""
-	if len(kafkaServerList) > 0 {
-		kafkaPortSuffix := ":" + strconv.Itoa(KafkaPort)
-		kafkaServerSpaceSeparatedList = strings.Join(kafkaServerList, kafkaPortSuffix+" ") + kafkaPortSuffix
-	}
+	kafkaServerSpaceSeparatedList := configtemplates.JoinListWithSeparator(configtemplates.EndpointList(kafkaServerList, KafkaPort), " ")
 
 	logLevel := ConvertLogLevel(c.Spec.CommonConfiguration.LogLevel)
 
@@ -384,6 +377,7 @@ func (c *Analytics) InstanceConfiguration(podList []corev1.Pod, client client.Cl
 		AnalyticsNodes: analyticsNodes}
 	data["analytics-provisioner.env"] = ProvisionerEnvData(&clusterNodes, "",
 		c.Spec.CommonConfiguration.AuthParameters)
+	err = ApplyConfigOverrides("analytics", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -446,12 +440,18 @@ func (c *Analytics) SetInstanceActive(client client.Client, activeStatus *bool,
 	*activeStatus = sts.Status.ReadyReplicas >= *sts.Spec.Replicas/2+1
 	*degradedStatus = sts.Status.ReadyReplicas < *sts.Spec.Replicas
 
+	c.Status.SetStatefulSetConditions(c.Generation, sts)
 	if err := client.Status().Update(context.TODO(), c); err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Analytics) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // PodIPListAndIPMapFromInstance gets a list with POD IPs and a map of POD names and IPs.
 func (c *Analytics) PodIPListAndIPMapFromInstance(request reconcile.Request, reconcileClient client.Client) ([]corev1.Pod, map[string]NodeInfo, error) {
 	return PodIPListAndIPMapFromInstance("analytics", request, reconcileClient, "")
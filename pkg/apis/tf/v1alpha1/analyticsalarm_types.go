@@ -238,8 +238,7 @@ func (c *AnalyticsAlarm) InstanceConfiguration(podList []corev1.Pod, client clie
 	nodes := pods2nodes(podList)
 	sort.SliceStable(podList, func(i, j int) bool { return podList[i].Status.PodIP < podList[j].Status.PodIP })
 
-	kafkaPortSuffix := ":" + strconv.Itoa(KafkaPort)
-	kafkaServerSpaceSeparatedList := strings.Join(nodes, kafkaPortSuffix+" ") + kafkaPortSuffix
+	kafkaServerSpaceSeparatedList := configtemplates.JoinListWithSeparator(configtemplates.EndpointList(nodes, KafkaPort), " ")
 
 	analyticsAlarmNodes := strings.Join(nodes, ",")
 
@@ -252,6 +251,7 @@ func (c *AnalyticsAlarm) InstanceConfiguration(podList []corev1.Pod, client clie
 	redisEndpointListSpaceSpearated := configtemplates.JoinListWithSeparator(redisEndpointList, " ")
 
 	logLevel := ConvertLogLevel(c.Spec.CommonConfiguration.LogLevel)
+	alarmConfig := c.ConfigurationParameters()
 
 	for _, pod := range podList {
 		hostname := pod.Annotations["hostname"]
@@ -284,23 +284,26 @@ func (c *AnalyticsAlarm) InstanceConfiguration(podList []corev1.Pod, client clie
 			RedisPort                      int
 			CAFilePath                     string
 		}{
-			PodIP:                    podIP,
-			Hostname:                 hostname,
-			ListenAddress:            podIP,
-			InstrospectListenAddress: instrospectListenAddress,
-			CollectorServers:         collectorEndpointListSpaceSeparated,
-			ZookeeperServers:         zookeeperEndpointListSpaceSeparated,
-			ConfigServers:            configApiIPEndpointListSpaceSeparated,
-			ConfigDbServerList:       configDbEndpointListSpaceSeparated,
-			KafkaServers:             kafkaServerSpaceSeparatedList,
-			CassandraSslCaCertfile:   SignerCAFilepath,
-			RabbitmqServerList:       rabbitmqSSLEndpointListSpaceSeparated,
-			RabbitmqVhost:            rabbitmqSecretVhost,
-			RabbitmqUser:             rabbitmqSecretUser,
-			RabbitmqPassword:         rabbitmqSecretPassword,
-			RedisServerList:          redisEndpointListSpaceSpearated,
-			RedisPort:                redisNodesInformation.ServerPort,
-			CAFilePath:               SignerCAFilepath,
+			PodIP:                          podIP,
+			Hostname:                       hostname,
+			ListenAddress:                  podIP,
+			InstrospectListenAddress:       instrospectListenAddress,
+			AlarmgenRedisAggregateDbOffset: strconv.Itoa(*alarmConfig.AlarmgenRedisAggregateDbOffset),
+			AlarmgenPartitions:             strconv.Itoa(*alarmConfig.AlarmgenPartitions),
+			AlarmgenIntrospectListenPort:   strconv.Itoa(*alarmConfig.AlarmgenIntrospectListenPort),
+			CollectorServers:               collectorEndpointListSpaceSeparated,
+			ZookeeperServers:               zookeeperEndpointListSpaceSeparated,
+			ConfigServers:                  configApiIPEndpointListSpaceSeparated,
+			ConfigDbServerList:             configDbEndpointListSpaceSeparated,
+			KafkaServers:                   kafkaServerSpaceSeparatedList,
+			CassandraSslCaCertfile:         SignerCAFilepath,
+			RabbitmqServerList:             rabbitmqSSLEndpointListSpaceSeparated,
+			RabbitmqVhost:                  rabbitmqSecretVhost,
+			RabbitmqUser:                   rabbitmqSecretUser,
+			RabbitmqPassword:               rabbitmqSecretPassword,
+			RedisServerList:                redisEndpointListSpaceSpearated,
+			RedisPort:                      redisNodesInformation.ServerPort,
+			CAFilePath:                     SignerCAFilepath,
 			// TODO: move to params
 			LogLevel: logLevel,
 		})
@@ -409,6 +412,7 @@ func (c *AnalyticsAlarm) InstanceConfiguration(podList []corev1.Pod, client clie
 	data["analyticsalarm-provisioner.env"] = ProvisionerEnvData(&clusterNodes,
 		"", c.Spec.CommonConfiguration.AuthParameters)
 
+	err = ApplyConfigOverrides("analyticsalarm", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -435,6 +439,11 @@ func (c *AnalyticsAlarm) SetInstanceActive(client client.Client, activeStatus *b
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *AnalyticsAlarm) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // CommonStartupScript prepare common run service script
 //  command - is a final command to run
 //  configs - config files to be waited for and to be linked from configmap mount
@@ -462,3 +471,34 @@ func (c *AnalyticsAlarm) ManageNodeStatus(podNameIPMap map[string]NodeInfo,
 	updated = true
 	return
 }
+
+// ConfigurationParameters creates AnalyticsAlarmConfiguration with the defaults for unset parameters
+func (c *AnalyticsAlarm) ConfigurationParameters() AnalyticsAlarmConfiguration {
+	alarmConfiguration := AnalyticsAlarmConfiguration{}
+
+	var redisAggregateDbOffset int
+	if c.Spec.ServiceConfiguration.AlarmgenRedisAggregateDbOffset != nil {
+		redisAggregateDbOffset = *c.Spec.ServiceConfiguration.AlarmgenRedisAggregateDbOffset
+	} else {
+		redisAggregateDbOffset = AlarmgenRedisAggregateDbOffset
+	}
+	alarmConfiguration.AlarmgenRedisAggregateDbOffset = &redisAggregateDbOffset
+
+	var partitions int
+	if c.Spec.ServiceConfiguration.AlarmgenPartitions != nil {
+		partitions = *c.Spec.ServiceConfiguration.AlarmgenPartitions
+	} else {
+		partitions = AlarmgenPartitions
+	}
+	alarmConfiguration.AlarmgenPartitions = &partitions
+
+	var introspectPort int
+	if c.Spec.ServiceConfiguration.AlarmgenIntrospectListenPort != nil {
+		introspectPort = *c.Spec.ServiceConfiguration.AlarmgenIntrospectListenPort
+	} else {
+		introspectPort = AlarmgenIntrospectPort
+	}
+	alarmConfiguration.AlarmgenIntrospectListenPort = &introspectPort
+
+	return alarmConfiguration
+}
@@ -183,6 +183,7 @@ func (c *AnalyticsSnmp) InstanceConfiguration(podList []corev1.Pod, client clien
 
 	nodes := pods2nodes(podList)
 	analyticsSnmpNodes := strings.Join(nodes, ",")
+	snmpConfig := c.ConfigurationParameters()
 
 	for _, pod := range podList {
 		hostname := pod.Annotations["hostname"]
@@ -213,21 +214,24 @@ func (c *AnalyticsSnmp) InstanceConfiguration(podList []corev1.Pod, client clien
 			CAFilePath                        string
 			RedisPort                         int
 		}{
-			PodIP:                    podIP,
-			Hostname:                 hostname,
-			ListenAddress:            podIP,
-			InstrospectListenAddress: instrospectListenAddress,
-			CollectorServers:         collectorEndpointListSpaceSeparated,
-			ZookeeperServers:         zookeeperEndpointListCommaSeparated,
-			ConfigServers:            configApiIPEndpointListSpaceSeparated,
-			ConfigDbServerList:       configDbEndpointListSpaceSeparated,
-			CassandraSslCaCertfile:   SignerCAFilepath,
-			RabbitmqServerList:       rabbitmqSSLEndpointListSpaceSeparated,
-			RabbitmqVhost:            rabbitmqSecretVhost,
-			RabbitmqUser:             rabbitmqSecretUser,
-			RabbitmqPassword:         rabbitmqSecretPassword,
-			CAFilePath:               SignerCAFilepath,
-			RedisPort:                redisNodesInformation.ServerPort,
+			PodIP:                             podIP,
+			Hostname:                          hostname,
+			ListenAddress:                     podIP,
+			InstrospectListenAddress:          instrospectListenAddress,
+			SnmpCollectorScanFrequency:        strconv.Itoa(*snmpConfig.SnmpCollectorScanFrequency),
+			SnmpCollectorFastScanFrequency:    strconv.Itoa(*snmpConfig.SnmpCollectorFastScanFrequency),
+			SnmpCollectorIntrospectListenPort: strconv.Itoa(*snmpConfig.SnmpCollectorIntrospectListenPort),
+			CollectorServers:                  collectorEndpointListSpaceSeparated,
+			ZookeeperServers:                  zookeeperEndpointListCommaSeparated,
+			ConfigServers:                     configApiIPEndpointListSpaceSeparated,
+			ConfigDbServerList:                configDbEndpointListSpaceSeparated,
+			CassandraSslCaCertfile:            SignerCAFilepath,
+			RabbitmqServerList:                rabbitmqSSLEndpointListSpaceSeparated,
+			RabbitmqVhost:                     rabbitmqSecretVhost,
+			RabbitmqUser:                      rabbitmqSecretUser,
+			RabbitmqPassword:                  rabbitmqSecretPassword,
+			CAFilePath:                        SignerCAFilepath,
+			RedisPort:                         redisNodesInformation.ServerPort,
 			// TODO: move to params
 			LogLevel: logLevel,
 		})
@@ -259,21 +263,23 @@ func (c *AnalyticsSnmp) InstanceConfiguration(podList []corev1.Pod, client clien
 			RabbitmqPassword                 string
 			CAFilePath                       string
 		}{
-			PodIP:                    podIP,
-			Hostname:                 hostname,
-			ListenAddress:            podIP,
-			InstrospectListenAddress: instrospectListenAddress,
-			CollectorServers:         collectorEndpointListSpaceSeparated,
-			ZookeeperServers:         zookeeperEndpointListCommaSeparated,
-			AnalyticsServers:         configApiIPEndpointListSpaceSeparated,
-			ConfigServers:            configApiIPEndpointListSpaceSeparated,
-			ConfigDbServerList:       configDbEndpointListSpaceSeparated,
-			CassandraSslCaCertfile:   SignerCAFilepath,
-			RabbitmqServerList:       rabbitmqSSLEndpointListSpaceSeparated,
-			RabbitmqVhost:            rabbitmqSecretVhost,
-			RabbitmqUser:             rabbitmqSecretUser,
-			RabbitmqPassword:         rabbitmqSecretPassword,
-			CAFilePath:               SignerCAFilepath,
+			PodIP:                            podIP,
+			Hostname:                         hostname,
+			ListenAddress:                    podIP,
+			InstrospectListenAddress:         instrospectListenAddress,
+			SnmpTopologyScanFrequency:        strconv.Itoa(*snmpConfig.TopologyScanFrequency),
+			SnmpTopologyIntrospectListenPort: strconv.Itoa(*snmpConfig.TopologyIntrospectListenPort),
+			CollectorServers:                 collectorEndpointListSpaceSeparated,
+			ZookeeperServers:                 zookeeperEndpointListCommaSeparated,
+			AnalyticsServers:                 configApiIPEndpointListSpaceSeparated,
+			ConfigServers:                    configApiIPEndpointListSpaceSeparated,
+			ConfigDbServerList:               configDbEndpointListSpaceSeparated,
+			CassandraSslCaCertfile:           SignerCAFilepath,
+			RabbitmqServerList:               rabbitmqSSLEndpointListSpaceSeparated,
+			RabbitmqVhost:                    rabbitmqSecretVhost,
+			RabbitmqUser:                     rabbitmqSecretUser,
+			RabbitmqPassword:                 rabbitmqSecretPassword,
+			CAFilePath:                       SignerCAFilepath,
 			// TODO: move to params
 			LogLevel: logLevel,
 		})
@@ -343,6 +349,7 @@ func (c *AnalyticsSnmp) InstanceConfiguration(podList []corev1.Pod, client clien
 	data["analyticssnmp-provisioner.env"] = ProvisionerEnvData(&clusterNodes,
 		"", c.Spec.CommonConfiguration.AuthParameters)
 
+	err = ApplyConfigOverrides("analyticssnmp", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -356,6 +363,11 @@ func (c *AnalyticsSnmp) SetInstanceActive(client client.Client, activeStatus *bo
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *AnalyticsSnmp) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // CommonStartupScript prepare common run service script
 //  command - is a final command to run
 //  configs - config files to be waited for and to be linked from configmap mount
@@ -383,3 +395,50 @@ func (c *AnalyticsSnmp) ManageNodeStatus(podNameIPMap map[string]NodeInfo,
 	updated = true
 	return
 }
+
+// ConfigurationParameters creates AnalyticsSnmpConfiguration with the defaults for unset parameters
+func (c *AnalyticsSnmp) ConfigurationParameters() AnalyticsSnmpConfiguration {
+	snmpConfiguration := AnalyticsSnmpConfiguration{}
+
+	var scanFrequency int
+	if c.Spec.ServiceConfiguration.SnmpCollectorScanFrequency != nil {
+		scanFrequency = *c.Spec.ServiceConfiguration.SnmpCollectorScanFrequency
+	} else {
+		scanFrequency = SnmpcollectorScanFrequency
+	}
+	snmpConfiguration.SnmpCollectorScanFrequency = &scanFrequency
+
+	var fastScanFrequency int
+	if c.Spec.ServiceConfiguration.SnmpCollectorFastScanFrequency != nil {
+		fastScanFrequency = *c.Spec.ServiceConfiguration.SnmpCollectorFastScanFrequency
+	} else {
+		fastScanFrequency = SnmpcollectorFastScanFrequency
+	}
+	snmpConfiguration.SnmpCollectorFastScanFrequency = &fastScanFrequency
+
+	var collectorIntrospectPort int
+	if c.Spec.ServiceConfiguration.SnmpCollectorIntrospectListenPort != nil {
+		collectorIntrospectPort = *c.Spec.ServiceConfiguration.SnmpCollectorIntrospectListenPort
+	} else {
+		collectorIntrospectPort = SnmpcollectorIntrospectPort
+	}
+	snmpConfiguration.SnmpCollectorIntrospectListenPort = &collectorIntrospectPort
+
+	var topologyScanFrequency int
+	if c.Spec.ServiceConfiguration.TopologyScanFrequency != nil {
+		topologyScanFrequency = *c.Spec.ServiceConfiguration.TopologyScanFrequency
+	} else {
+		topologyScanFrequency = TopologyScanFrequency
+	}
+	snmpConfiguration.TopologyScanFrequency = &topologyScanFrequency
+
+	var topologyIntrospectPort int
+	if c.Spec.ServiceConfiguration.TopologyIntrospectListenPort != nil {
+		topologyIntrospectPort = *c.Spec.ServiceConfiguration.TopologyIntrospectListenPort
+	} else {
+		topologyIntrospectPort = TopologyIntrospectPort
+	}
+	snmpConfiguration.TopologyIntrospectListenPort = &topologyIntrospectPort
+
+	return snmpConfiguration
+}
@@ -1,11 +1,9 @@
 package v1alpha1
 
 import (
-	"context"
 	"fmt"
+	"time"
 
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -31,6 +29,9 @@ type AuthParameters struct {
 	AuthMode               AuthenticationMode     `json:"authMode,omitempty"`
 	KeystoneAuthParameters KeystoneAuthParameters `json:"keystoneAuthParameters,omitempty"`
 	KeystoneSecretName     *string                `json:"keystoneSecretName,omitempty"`
+	// KeystoneCredentialsSource is the source of the admin password in the password key,
+	// it takes precedence over KeystoneSecretName
+	KeystoneCredentialsSource *CredentialsSource `json:"keystoneCredentialsSource,omitempty"`
 }
 
 // KeystoneAuthParameters keystone parameters
@@ -49,6 +50,23 @@ type KeystoneAuthParameters struct {
 	Insecure          *bool   `json:"insecure,omitempty"`
 }
 
+// credentialsSource returns the source of the admin password if any,
+// KeystoneSecretName is the legacy way to set the Secret source
+func (ap *AuthParameters) credentialsSource() *CredentialsSource {
+	if ap.KeystoneCredentialsSource != nil {
+		return ap.KeystoneCredentialsSource
+	}
+	if ap.KeystoneSecretName != nil && *ap.KeystoneSecretName != "" {
+		return &CredentialsSource{Secret: &SecretCredentialsSource{Name: *ap.KeystoneSecretName}}
+	}
+	return nil
+}
+
+// RefreshInterval returns the interval to read the admin password from its source again, 0 if not needed
+func (ap *AuthParameters) RefreshInterval() time.Duration {
+	return ap.KeystoneCredentialsSource.RefreshInterval()
+}
+
 // Prepare makes default empty AuthParameters
 func (ap *AuthParameters) Prepare(namespace string, client client.Client) error {
 	if ap == nil {
@@ -65,13 +83,12 @@ func (ap *AuthParameters) Prepare(namespace string, client client.Client) error
 		c.AdminTenant = KeystoneAuthAdminTenant
 	}
 	if c.AdminPassword == nil {
-		if ap.KeystoneSecretName != nil && *ap.KeystoneSecretName != "" {
-			adminPasswordSecret := &corev1.Secret{}
-			nsName := types.NamespacedName{Name: *ap.KeystoneSecretName, Namespace: namespace}
-			if err := client.Get(context.TODO(), nsName, adminPasswordSecret); err != nil {
+		if source := ap.credentialsSource(); source != nil {
+			data, err := source.Credentials(namespace, client, "password")
+			if err != nil {
 				return err
 			}
-			pwdStr := string(adminPasswordSecret.Data["password"])
+			pwdStr := string(data["password"])
 			c.AdminPassword = &pwdStr
 		} else {
 			pwdStr := KeystoneAuthAdminPassword
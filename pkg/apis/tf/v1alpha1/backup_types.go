@@ -0,0 +1,180 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupConfiguration defines scheduled backups of Cassandra and Zookeeper data
+// into S3 compatible object store.
+// +k8s:openapi-gen=true
+type BackupConfiguration struct {
+	// IntervalMinutes is the period of backups
+	// +kubebuilder:validation:Minimum=1
+	IntervalMinutes int `json:"intervalMinutes"`
+	// Retention is the number of backups kept per service, 7 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Retention *int `json:"retention,omitempty"`
+	// Suspend stops new backups
+	// +optional
+	Suspend *bool    `json:"suspend,omitempty"`
+	S3      BackupS3 `json:"s3"`
+}
+
+// BackupS3 defines S3 compatible object store for backups.
+// +k8s:openapi-gen=true
+type BackupS3 struct {
+	// Endpoint is URL of the store, e.g. http://minio.minio:9000
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Prefix of object keys
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// CredentialsSecret is the name of the secret with accessKey and secretKey
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// BackupStatus is the status of backups of a service.
+// +k8s:openapi-gen=true
+type BackupStatus struct {
+	// Service is kind and name of the service, e.g. cassandra/configdb1
+	Service            string       `json:"service"`
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// LastBackup is the key prefix of the last successful backup
+	LastBackup     string       `json:"lastBackup,omitempty"`
+	LastFailedTime *metav1.Time `json:"lastFailedTime,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	// Job is the name of the running backup job
+	Job string `json:"job,omitempty"`
+}
+
+const defaultBackupRetention = 7
+
+// GetRetention returns number of backups to keep
+func (b *BackupConfiguration) GetRetention() int {
+	if b.Retention != nil && *b.Retention > 0 {
+		return *b.Retention
+	}
+	return defaultBackupRetention
+}
+
+// IsSuspended returns true if new backups are not started
+func (b *BackupConfiguration) IsSuspended() bool {
+	return b.Suspend != nil && *b.Suspend
+}
+
+// Backup takes snapshot of all keyspaces on the pod and writes tar.gz archive of the snapshot into w
+func (c *Cassandra) Backup(pod *corev1.Pod, tag string, w io.Writer) error {
+	if _, err := c.nodetool(pod, "snapshot -t "+tag); err != nil {
+		return err
+	}
+	// snapshot hardlinks are not needed after archiving
+	defer func() {
+		if _, err := c.nodetool(pod, "clearsnapshot -t "+tag); err != nil {
+			cassandraLog.Error(err, "Failed to clear snapshot", "pod", pod.Name, "tag", tag)
+		}
+	}()
+	command := fmt.Sprintf("set -o pipefail; cd /var/lib/cassandra/data && find . -type d -path '*/snapshots/%s' | tar czf - -T -", tag)
+	stderr, err := StreamFromContainer(pod, CassandraInstanceType, []string{"/usr/bin/bash", "-c", command}, nil, w)
+	if err != nil {
+		return fmt.Errorf("Failed to archive snapshot %s: %v (stderr=%s)", tag, err, stderr)
+	}
+	return nil
+}
+
+// Backup writes tar.gz archive of the latest snapshot and transaction logs of the pod into w
+func (c *Zookeeper) Backup(pod *corev1.Pod, w io.Writer) error {
+	// zookeeper writes snapshot on start and periodically, txn logs contain the rest
+	command := "set -o pipefail; cd /var/lib/zookeeper && tar czf - $(ls -t version-2/snapshot.* | head -n 1) version-2/log.* version-2/acceptedEpoch version-2/currentEpoch"
+	stderr, err := StreamFromContainer(pod, "zookeeper", []string{"bash", "-c", command}, nil, w)
+	if err != nil {
+		return fmt.Errorf("Failed to archive zookeeper data: %v (stderr=%s)", err, stderr)
+	}
+	return nil
+}
+
+// RestoreConfiguration defines restore of Cassandra and Zookeeper data from a backup.
+// Services other than the data stores are not reconciled till the restore is completed.
+// +k8s:openapi-gen=true
+type RestoreConfiguration struct {
+	// Snapshot is the time folder of the backup, e.g. 20210101T000000Z
+	Snapshot string `json:"snapshot"`
+	// S3 is the store of the backup, the store of scheduled backups is used by default
+	// +optional
+	S3 *BackupS3 `json:"s3,omitempty"`
+}
+
+// RestorePhase is the phase of the restore
+type RestorePhase string
+
+const (
+	RestoreInProgress RestorePhase = "InProgress"
+	RestoreCompleted  RestorePhase = "Completed"
+)
+
+// RestoreStatus is the status of the restore.
+// +k8s:openapi-gen=true
+type RestoreStatus struct {
+	Snapshot       string                 `json:"snapshot"`
+	Phase          RestorePhase           `json:"phase,omitempty"`
+	Services       []RestoreServiceStatus `json:"services,omitempty"`
+	Message        string                 `json:"message,omitempty"`
+	StartTime      *metav1.Time           `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time           `json:"completionTime,omitempty"`
+}
+
+// RestoreServiceStatus is the status of the restore of a service.
+// +k8s:openapi-gen=true
+type RestoreServiceStatus struct {
+	// Service is kind and name of the service, e.g. cassandra/configdb1
+	Service string `json:"service"`
+	// Job is the name of the running restore job which stages the backup into pods
+	Job string `json:"job,omitempty"`
+	// StagedTime is the time when the backup was put to pods, pods created before are restarted
+	StagedTime *metav1.Time `json:"stagedTime,omitempty"`
+	Restored   bool         `json:"restored,omitempty"`
+}
+
+// restoreAllowedKinds are reconciled while the restore is in progress
+var restoreAllowedKinds = map[string]bool{
+	"Cassandra": true,
+	"Zookeeper": true,
+	"Rabbitmq":  true,
+	"Redis":     true,
+}
+
+// IsRestoreInProgress returns true if the restore of the snapshot from the spec is not completed yet
+func (m *Manager) IsRestoreInProgress() bool {
+	if m.Spec.Restore == nil {
+		return false
+	}
+	s := m.Status.Restore
+	return s == nil || s.Snapshot != m.Spec.Restore.Snapshot || s.Phase != RestoreCompleted
+}
+
+// stageRestore extracts the backup archive read from r into restore folder of the data dir,
+// the startup script of the service moves it into place on the next start
+func stageRestore(pod *corev1.Pod, container, dataDir string, r io.Reader) error {
+	command := fmt.Sprintf("set -e; rm -rf %[1]s/restore; mkdir -p %[1]s/restore; tar xzf - -C %[1]s/restore; touch %[1]s/restore/.ready", dataDir)
+	_, stderr, err := ExecToContainer(pod, container, []string{"bash", "-c", command}, r)
+	if err != nil {
+		return fmt.Errorf("Failed to stage restore on pod %s: %v (stderr=%s)", pod.Name, err, stderr)
+	}
+	return nil
+}
+
+// StageRestore puts the backup archive of the pod to be restored on the next start of the pod
+func (c *Cassandra) StageRestore(pod *corev1.Pod, r io.Reader) error {
+	return stageRestore(pod, CassandraInstanceType, "/var/lib/cassandra", r)
+}
+
+// StageRestore puts the backup archive to be restored on the next start of the pod
+func (c *Zookeeper) StageRestore(pod *corev1.Pod, r io.Reader) error {
+	return stageRestore(pod, "zookeeper", "/var/lib/zookeeper", r)
+}
@@ -20,6 +20,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -57,14 +58,18 @@ type Container struct {
 	Name    string   `json:"name,omitempty"`
 	Image   string   `json:"image,omitempty"`
 	Command []string `json:"command,omitempty"`
+	// Resources are requests and limits of the container, set ones replace defaults per resource
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // ServiceStatus provides information on the current status of the service.
 // +k8s:openapi-gen=true
 type ServiceStatus struct {
-	Name    *string `json:"name,omitempty"`
-	Active  *bool   `json:"active,omitempty"`
-	Created *bool   `json:"created,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Active   *bool   `json:"active,omitempty"`
+	Created  *bool   `json:"created,omitempty"`
+	Degraded *bool   `json:"degraded,omitempty"`
 }
 
 // PodConfiguration is the common services struct.
@@ -90,6 +95,40 @@ type PodConfiguration struct {
 	// OS family
 	// +optional
 	Distribution *string `json:"distribution,omitempty"`
+	// ConfigOverrides are values merged into rendered INI files of the service:
+	// file name (e.g. control, contrail-vrouter-agent.conf) -> section -> key -> value
+	// +optional
+	ConfigOverrides map[string]ConfigFileOverrides `json:"configOverrides,omitempty"`
+	// MaxUnavailable is the maximum number of pods restarted at once to apply update
+	// of statefulset with OnDelete update strategy, 1 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxUnavailable *int `json:"maxUnavailable,omitempty"`
+	// Replicas is the number of pods of the service,
+	// the number of nodes matching the nodeSelector by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// ScaleDownOnNodeRemoval reduces replicas following the number of nodes
+	// when nodes are removed, true by default
+	// +optional
+	ScaleDownOnNodeRemoval *bool `json:"scaleDownOnNodeRemoval,omitempty"`
+	// Affinity replaces the default anti-affinity which places one pod of the service per node
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// TopologySpreadConstraints describe how pods of the service are spread across topology domains,
+	// e.g. failure zones
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// IntendedReplicas returns the configured number of replicas or the number of nodes matching the nodeSelector
+func (c *PodConfiguration) IntendedReplicas(clnt client.Client) (int32, error) {
+	if c.Replicas != nil {
+		return *c.Replicas, nil
+	}
+	nodes, err := GetNodes(c.NodeSelector, clnt)
+	return int32(len(nodes)), err
 }
 
 type ClusterNodes struct {
@@ -120,6 +159,13 @@ var ZiuKindsAll = append(ZiuKindsNoVrouterCNI, "Kubemanager")
 // Establishes ZIU staging
 var ZiuKinds []string
 
+// Names of hooks of the default ZIU plan
+const (
+	ZiuHookPrepare2011               = "Prepare2011"
+	ZiuHookResetStatusNodes2011      = "ResetStatusNodes2011"
+	ZiuHookResetRedisStatusNodes2011 = "ResetRedisStatusNodes2011"
+)
+
 var ZiuRestartTime, _ = time.ParseDuration("20s")
 
 // IntrospectionListenAddress returns listen address for instrospection
@@ -130,14 +176,13 @@ func (cc *PodConfiguration) IntrospectionListenAddress(addr string) string {
 	return addr
 }
 
+// CmpConfigMaps returns true if configmaps have the same files,
+// files kept in the companion secret are compared by checksum annotation
 func CmpConfigMaps(first, second *corev1.ConfigMap) bool {
-	if first.Data == nil {
-		first.Data = map[string]string{}
-	}
-	if second.Data == nil {
-		second.Data = map[string]string{}
+	if first.Annotations[ConfigSecretChecksumAnnotation] != second.Annotations[ConfigSecretChecksumAnnotation] {
+		return false
 	}
-	return reflect.DeepEqual(first.Data, second.Data)
+	return reflect.DeepEqual(plainConfigData(first), plainConfigData(second))
 }
 
 func (ss *ServiceStatus) ready() bool {
@@ -378,6 +423,12 @@ func PodsCertSubjects(domain string, podList []corev1.Pod, podAltIPs PodAlternat
 		if podAltIPs.ServiceIP != "" {
 			alternativeIPs = append(alternativeIPs, podAltIPs.ServiceIP)
 		}
+		// other addresses of dual-stack pod
+		for _, ip := range PodIPs(&pod) {
+			if ip != pod.Status.PodIP {
+				alternativeIPs = append(alternativeIPs, ip)
+			}
+		}
 		if podAltIPs.Retriever != nil {
 			if altIPs := podAltIPs.Retriever(pod); len(altIPs) > 0 {
 				for _, v := range altIPs {
@@ -404,13 +455,6 @@ func PodsCertSubjects(domain string, podList []corev1.Pod, podAltIPs PodAlternat
 	return pods
 }
 
-// GetConfigMap creates a config map based on the instance type.
-func GetConfigMap(name, ns string, client client.Client) (*corev1.ConfigMap, error) {
-	configMap := &corev1.ConfigMap{}
-	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, configMap)
-	return configMap, err
-}
-
 func prepConfigData(instanceType string, data map[string]string, configMap *corev1.ConfigMap) {
 	if configMap.Data == nil {
 		configMap.Data = make(map[string]string)
@@ -435,7 +479,7 @@ func CreateConfigMap(
 	configMap, err := GetConfigMap(configMapName, request.Namespace, client)
 	if err == nil {
 		prepConfigData(instanceType, data, configMap)
-		return configMap, client.Update(context.TODO(), configMap)
+		return configMap, saveConfigMap(configMap, false, client)
 	}
 	if !k8serrors.IsNotFound(err) {
 		return nil, err
@@ -448,7 +492,7 @@ func CreateConfigMap(
 	if err = controllerutil.SetControllerReference(object, configMap, scheme); err != nil {
 		return nil, err
 	}
-	if err = client.Create(context.TODO(), configMap); err != nil && !k8serrors.IsAlreadyExists(err) {
+	if err = saveConfigMap(configMap, true, client); err != nil && !k8serrors.IsAlreadyExists(err) {
 		return nil, err
 	}
 	return configMap, nil
@@ -502,7 +546,7 @@ func PrepareSTS(sts *appsv1.StatefulSet,
 	object v1.Object,
 	usePralallePodManagementPolicy bool) error {
 
-	SetSTSCommonConfiguration(sts, commonConfiguration)
+	SetSTSCommonConfiguration(sts, commonConfiguration, instanceType, request.Name)
 	if usePralallePodManagementPolicy {
 		sts.Spec.PodManagementPolicy = appsv1.PodManagementPolicyType("Parallel")
 	} else {
@@ -550,17 +594,39 @@ func SetDeploymentCommonConfiguration(deployment *appsv1.Deployment,
 }
 
 // SetSTSCommonConfiguration takes common configuration parameters
-// and applies it to the pod.
+// and applies it to the pod. Pods of the instance are spread one per node
+// unless affinity is configured.
 func SetSTSCommonConfiguration(sts *appsv1.StatefulSet,
-	commonConfiguration *PodConfiguration) {
-	var replicas = int32(1)
-	sts.Spec.Replicas = &replicas
+	commonConfiguration *PodConfiguration, instanceType, name string) {
+	// nil replicas are set by the number of nodes on create and update of the statefulset
+	sts.Spec.Replicas = commonConfiguration.Replicas
 	if len(commonConfiguration.Tolerations) > 0 {
 		sts.Spec.Template.Spec.Tolerations = commonConfiguration.Tolerations
 	}
 	if len(commonConfiguration.NodeSelector) > 0 {
 		sts.Spec.Template.Spec.NodeSelector = commonConfiguration.NodeSelector
 	}
+	if commonConfiguration.Affinity != nil {
+		sts.Spec.Template.Spec.Affinity = commonConfiguration.Affinity
+	} else if sts.Spec.Template.Spec.Affinity == nil {
+		sts.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      instanceType,
+							Operator: "In",
+							Values:   []string{name},
+						}},
+					},
+					TopologyKey: "kubernetes.io/hostname",
+				}},
+			},
+		}
+	}
+	if len(commonConfiguration.TopologySpreadConstraints) > 0 {
+		sts.Spec.Template.Spec.TopologySpreadConstraints = commonConfiguration.TopologySpreadConstraints
+	}
 
 	if len(commonConfiguration.ImagePullSecrets) > 0 {
 		imagePullSecretList := []corev1.LocalObjectReference{}
@@ -579,13 +645,7 @@ func AddVolumesToPodSpec(spec *corev1.PodSpec, volumeConfigMapMap map[string]str
 	for configMapName, volumeName := range volumeConfigMapMap {
 		volume := corev1.Volume{
 			Name: volumeName,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: configMapName,
-					},
-				},
-			},
+			VolumeSource: configVolumeSource(configMapName),
 		}
 		volumeList = append(volumeList, volume)
 	}
@@ -719,12 +779,14 @@ func CreateServiceSTS(instance v1.Object,
 		return
 	}
 	var replicas int32
-	if replicas, err = GetReplicas(cl, sts.Spec.Template.Spec.NodeSelector); err == nil {
+	if replicas, err = GetSTSReplicas(cl, sts.Spec.Replicas, sts.Spec.Template.Spec.NodeSelector); err == nil {
 		sts.Name = stsName
 		sts.Namespace = stsNamespace
 		sts.Spec.Replicas = &replicas
 		if err = cl.Create(context.TODO(), sts); err == nil {
 			created = true
+			RecordEvent(instance, corev1.EventTypeNormal, EventReasonStatefulSetCreated,
+				"StatefulSet %s is created with %d replicas", stsName, replicas)
 		}
 	}
 	return
@@ -771,6 +833,15 @@ func _containersChanged(first []corev1.Container,
 					)
 					break
 				}
+				if !equality.Semantic.DeepEqual(container1.Resources, container2.Resources) {
+					changed = true
+					logger.Info("Resources changed",
+						"Container", container1.Name,
+						"Current Resources", container1.Resources,
+						"Intended Resources", container2.Resources,
+					)
+					break
+				}
 				sort.SliceStable(
 					container1.Env,
 					func(i, j int) bool { return container1.Env[i].Name < container1.Env[j].Name })
@@ -797,7 +868,7 @@ func _containersChanged(first []corev1.Container,
 
 }
 
-// TODO: Make it more intellectual. Now it's checks only images and envs.
+// TODO: Make it more intellectual. Now it's checks only images, resources and envs.
 func containersChanged(first *corev1.PodTemplateSpec,
 	second *corev1.PodTemplateSpec,
 ) (changed bool) {
@@ -822,14 +893,18 @@ func containersChanged(first *corev1.PodTemplateSpec,
 
 // UpdateSafeSTS query existing statefulset and add to it allowed fields.
 // Allowed fileds are template, replicas and updateStrategy (k8s restrinction).
-// Template will be updated just in case when some container images or container env changed (or use force).
+// Template will be updated just in case when some container images, resources or env changed (or use force).
+// Replicas are only raised here and not while scale-down is in progress, they are reduced by ScaleDownSTS,
+// nil replicas are the number of nodes.
 // Nil values to leave fields unchanged.
 func UpdateSTS(stsName string,
 	instanceType string,
 	namespace string,
 	template *corev1.PodTemplateSpec,
+	intendedReplicas *int32,
 	strategy *appsv1.StatefulSetUpdateStrategy,
 	force bool,
+	scalingDown bool,
 	cl client.Client,
 ) (updated bool, err error) {
 
@@ -849,7 +924,7 @@ func UpdateSTS(stsName string,
 
 	changed := false
 	if force || containersChanged(&sts.Spec.Template, template) {
-		logger.Info("Some of container images or env changed, or force mode", "force", force)
+		logger.Info("Some of container images, resources or env changed, or force mode", "force", force)
 		changed = true
 		sts.Spec.Template = *template
 	}
@@ -860,14 +935,22 @@ func UpdateSTS(stsName string,
 		changed = true
 	}
 
-	replicas, err := GetReplicas(cl, template.Spec.NodeSelector)
+	if !changed && (!equality.Semantic.DeepEqual(sts.Spec.Template.Spec.Affinity, template.Spec.Affinity) ||
+		!equality.Semantic.DeepEqual(sts.Spec.Template.Spec.TopologySpreadConstraints, template.Spec.TopologySpreadConstraints)) {
+		sts.Spec.Template.Spec.Affinity = template.Spec.Affinity
+		sts.Spec.Template.Spec.TopologySpreadConstraints = template.Spec.TopologySpreadConstraints
+		logger.Info("affinity or topologySpreadConstraints changed")
+		changed = true
+	}
+
+	replicas, err := GetSTSReplicas(cl, intendedReplicas, template.Spec.NodeSelector)
 	if err != nil {
 		return
 	}
 
-	if replicas != *sts.Spec.Replicas {
-		if replicas < *sts.Spec.Replicas {
-			logger.Info("To reduce replicas delete STS manually", "Current", *sts.Spec.Replicas, "Intended", replicas)
+	if replicas > *sts.Spec.Replicas {
+		if scalingDown {
+			logger.Info("Replicas are raised when scale-down is completed", "Current", *sts.Spec.Replicas, "Intended", replicas)
 		} else {
 			logger.Info("Replicas changed", "Current", *sts.Spec.Replicas, "Intended", replicas)
 			changed = true
@@ -890,17 +973,7 @@ func UpdateSTS(stsName string,
 		return
 	}
 
-	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
-		logger.Info("Update OnDelete strategy")
-		opts := &client.DeleteAllOfOptions{}
-		opts.Namespace = namespace
-		opts.LabelSelector = labelSelector(stsName, instanceType)
-		pod := &corev1.Pod{}
-		if err = cl.DeleteAllOf(context.TODO(), pod, opts); err != nil {
-			return
-		}
-	}
-
+	// pods of OnDelete strategy are restarted one by one by RestartOnDeletePods
 	logger.Info("Update done")
 	updated = true
 	return
@@ -926,7 +999,20 @@ func UpdateServiceSTS(instance v1.Object,
 	stsName := instance.GetName()
 	stsNamespace := instance.GetNamespace()
 	stsTemplate := sts.Spec.Template
-	updated, err = UpdateSTS(stsName, instanceType, stsNamespace, &stsTemplate, &sts.Spec.UpdateStrategy, force, clnt)
+	scalingDown := false
+	if so, ok := instance.(StatusObject); ok {
+		scalingDown = so.GetCommonStatus().ScaleDown != nil
+	}
+	updated, err = UpdateSTS(stsName, instanceType, stsNamespace, &stsTemplate, sts.Spec.Replicas, &sts.Spec.UpdateStrategy, force, scalingDown, clnt)
+	if err == nil && updated {
+		name := stsName + "-" + instanceType + "-statefulset"
+		if force {
+			RecordEvent(instance, corev1.EventTypeNormal, EventReasonConfigChanged,
+				"Configuration is changed, pods of StatefulSet %s are restarted", name)
+		} else {
+			RecordEvent(instance, corev1.EventTypeNormal, EventReasonStatefulSetUpdated, "StatefulSet %s is updated", name)
+		}
+	}
 	return
 }
 
@@ -944,6 +1030,9 @@ func SetInstanceActive(client client.Client, activeStatus *bool, degradedStatus
 
 	*activeStatus = active
 	*degradedStatus = degraded
+	if o, ok := object.(StatusObject); ok {
+		o.GetCommonStatus().SetStatefulSetConditions(o.GetGeneration(), sts)
+	}
 	if err := client.Status().Update(context.TODO(), object); err != nil {
 		return err
 	}
@@ -1029,7 +1118,8 @@ func GetDataAddresses(pod *corev1.Pod, instanceType string, cidr string) (string
 	if c, ok := instanceToContainerMap[instanceType]; ok {
 		container = c
 	}
-	command := "ip address | awk '/inet /{print $2}' | cut -d '/' -f1"
+	// loopback and link-local addresses are never in data network
+	command := "ip address show scope global | awk '/inet6? /{print $2}' | cut -d '/' -f1"
 	stdout, _, err := ExecToContainer(pod, container, []string{"/usr/bin/bash", "-c", command}, nil)
 	if err != nil {
 		return stdout, fmt.Errorf("failed to get IP adresses for POD %s (err=%+v)", pod.Name, err)
@@ -1044,7 +1134,11 @@ func GetDataAddresses(pod *corev1.Pod, instanceType string, cidr string) (string
 		return stdout, fmt.Errorf("dataSubnet CIDR is invalid %s (err=%+v)", cidr, err)
 	}
 	for _, ip := range ip_addresses {
-		if network.Contains(net.ParseIP(ip)) {
+		addr := net.ParseIP(ip)
+		if addr == nil || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+			continue
+		}
+		if network.Contains(addr) {
 			return ip, nil
 		}
 	}
@@ -1184,6 +1278,9 @@ func PodIPListAndIPMapFromInstance(instanceType string,
 		if pod.Status.PodIP == "" || (pod.Status.Phase != "Running" && pod.Status.Phase != "Pending") {
 			continue
 		}
+		if err := CheckPrimaryPodIP(pod.Status.PodIP); err != nil {
+			return nil, nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+		}
 		podIP := pod.Status.PodIP
 		hostname := pod.Annotations["hostname"]
 		if datanetwork != "" {
@@ -1199,13 +1296,37 @@ func PodIPListAndIPMapFromInstance(instanceType string,
 			podIP = ip
 			hostname = removeLastDot(names[0])
 		}
-		podNameIPMap[pod.Name] = NodeInfo{IP: podIP, Hostname: hostname}
+		podNameIPMap[pod.Name] = NodeInfo{IP: podIP, Hostname: hostname, IPs: PodIPs(pod)}
 		podList = append(podList, *pod)
 	}
 	sort.SliceStable(podList, func(i, j int) bool { return podList[i].Name < podList[j].Name })
 	return podList, podNameIPMap, nil
 }
 
+// CheckPrimaryPodIP returns error if the primary address of the pod is IPv6.
+// Config files in configmaps and certificates in secrets are keyed by the primary address of the pod,
+// colons are not allowed in keys, so IPv6-only and IPv6-primary dual-stack clusters are not supported.
+func CheckPrimaryPodIP(podIP string) error {
+	if ip := net.ParseIP(podIP); ip != nil && ip.To4() == nil {
+		return fmt.Errorf("primary address %s is IPv6, IPv6-only and IPv6-primary dual-stack clusters are not supported", podIP)
+	}
+	return nil
+}
+
+// PodIPs returns all addresses of the pod, the primary address goes first
+func PodIPs(pod *corev1.Pod) []string {
+	ips := []string{}
+	if pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	for _, ip := range pod.Status.PodIPs {
+		if ip.IP != "" && ip.IP != pod.Status.PodIP {
+			ips = append(ips, ip.IP)
+		}
+	}
+	return ips
+}
+
 func pod2node(pod corev1.Pod) string {
 	if k8s.IsOpenshift() {
 		return pod.Status.PodIP
@@ -1650,6 +1771,18 @@ func ExecToContainer(pod *corev1.Pod, container string, command []string, stdin
 	return stdout, stderr, err
 }
 
+// StreamFromContainer uninterractively exec to the container and writes output of the command into stdout.
+// Returns errors of the command (STDERR).
+func StreamFromContainer(pod *corev1.Pod, container string, command []string, stdin io.Reader, stdout io.Writer) (string, error) {
+	return k8s.StreamFromPodThroughAPI(command,
+		container,
+		pod.ObjectMeta.Name,
+		pod.ObjectMeta.Namespace,
+		stdin,
+		stdout,
+	)
+}
+
 // ContainerFileSha gets sha of file from a container
 func ContainerFileSha(pod *corev1.Pod, container string, path string) (string, error) {
 	command := []string{"bash", "-c", fmt.Sprintf("[ ! -e %s ] || /usr/bin/sha1sum %s", path, path)}
@@ -2068,17 +2201,25 @@ func updateMap(values map[string]string, data *map[string]string) {
 }
 
 func UpdateConfigMap(instance v1.Object, instanceType string, data map[string]string, client client.Client) error {
-	namespacedName := types.NamespacedName{
-		Name:      instance.GetName() + "-" + instanceType + "-configmap",
-		Namespace: instance.GetNamespace(),
-	}
-	config := corev1.ConfigMap{}
-	if err := client.Get(context.TODO(), namespacedName, &config); err != nil {
+	config, err := GetConfigMap(instance.GetName()+"-"+instanceType+"-configmap", instance.GetNamespace(), client)
+	if err != nil {
 		return err
 	}
+	if config.Data == nil {
+		config.Data = make(map[string]string)
+	}
 	updateMap(data, &config.Data)
 
-	return client.Update(context.TODO(), &config)
+	return saveConfigMap(config, false, client)
+}
+
+// GetSTSReplicas returns the intended replicas of the statefulset if they are set
+// or the number of nodes matching the nodeSelector of the statefulset
+func GetSTSReplicas(clnt client.Client, replicas *int32, labels client.MatchingLabels) (int32, error) {
+	if replicas != nil {
+		return *replicas, nil
+	}
+	return GetReplicas(clnt, labels)
 }
 
 func GetReplicas(clnt client.Client, labels client.MatchingLabels) (nodesNumber int32, err error) {
@@ -2113,27 +2254,90 @@ func SetZiuStage(stage int, clnt client.Client) error {
 	}
 }
 
+// GetZiuPlan returns ZIU plan from the spec or the default plan made of hooks
+// of the ZIU versions table matching the version ZIU is started from
+func (m *Manager) GetZiuPlan(versions []ZiuVersionHooks) *ZiuPlan {
+	if m.Spec.ZiuPlan != nil && len(m.Spec.ZiuPlan.Stages) > 0 {
+		return m.Spec.ZiuPlan
+	}
+	kinds := ZiuKindsNoVrouterCNI
+	if len(m.Spec.Services.KubemanagerInputs()) > 0 {
+		kinds = ZiuKindsAll
+	}
+	hooks := MatchZiuVersion(versions, m.Status.ZiuFromVersion)
+	if hooks == nil {
+		hooks = &ZiuVersionHooks{}
+	}
+	plan := &ZiuPlan{PreHooks: hooks.PreHooks}
+	for _, kind := range kinds {
+		stage := ZiuPlanStage{Kind: kind}
+		stage.PreHooks = append(stage.PreHooks, hooks.StagePreHooks...)
+		stage.PreHooks = append(stage.PreHooks, hooks.KindPreHooks[kind]...)
+		plan.Stages = append(plan.Stages, stage)
+	}
+	return plan
+}
+
+// ZiuPlan returns ZIU plan of the manager with the ZIU versions table from its namespace
+func (m *Manager) ZiuPlan(clnt client.Client) (*ZiuPlan, error) {
+	versions, err := GetZiuVersions(m.Namespace, clnt)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetZiuPlan(versions), nil
+}
+
+// InitZiu sets ZIU stages from the plan and starts ZIU from the first stage
 func InitZiu(clnt client.Client) (err error) {
 	var manager *Manager
 	if manager, err = GetManagerObject(clnt); err != nil {
 		return
 	}
-	if manager.Spec.Services.Kubemanager != nil {
-		ZiuKinds = ZiuKindsAll
-	} else {
-		ZiuKinds = ZiuKindsNoVrouterCNI
+	if manager.Status.ZiuFromVersion, err = ziuDeployedVersion(manager, clnt); err != nil {
+		return
+	}
+	var plan *ZiuPlan
+	if plan, err = manager.ZiuPlan(clnt); err != nil {
+		return
+	}
+	var kinds []string
+	var stages []ZiuStageStatus
+	for _, stage := range plan.Stages {
+		kinds = append(kinds, stage.Kind)
+		stages = append(stages, ZiuStageStatus{Kind: stage.Kind, Phase: ZiuStagePending})
 	}
-	err = SetZiuStage(0, clnt)
+	ZiuKinds = kinds
+	manager.Status.ZiuStages = stages
+	manager.Status.ZiuState = 0
+	err = clnt.Status().Update(context.Background(), manager)
 	return
 }
 
+// ResumeZiu restores ZIU stages from the manager status after operator restart.
+// Returns true if ZIU is in progress.
+func ResumeZiu(clnt client.Client) (bool, error) {
+	manager, err := GetManagerObject(clnt)
+	if err != nil {
+		return false, err
+	}
+	if manager.Status.ZiuState < 0 || len(manager.Status.ZiuStages) == 0 {
+		return false, nil
+	}
+	var kinds []string
+	for _, stage := range manager.Status.ZiuStages {
+		kinds = append(kinds, stage.Kind)
+	}
+	ZiuKinds = kinds
+	return true, nil
+}
+
 func ziuCheckContainerImage(m *Manager) (stsName string, image string) {
 	stsName = ""
 	image = ""
 	var cc []*Container = nil
-	if m.Spec.Services.Kubemanager != nil {
-		stsName = m.Spec.Services.Kubemanager.Metadata.Name + "-kubemanager"
-		cc = m.Spec.Services.Kubemanager.Spec.ServiceConfiguration.Containers
+	if kubemanagers := m.Spec.Services.KubemanagerInputs(); len(kubemanagers) > 0 {
+		stsName = kubemanagers[0].Metadata.Name + "-kubemanager"
+		cc = kubemanagers[0].Spec.ServiceConfiguration.Containers
 	} else if m.Spec.Services.Webui != nil {
 		stsName = m.Spec.Services.Webui.Metadata.Name + "-webui"
 		cc = m.Spec.Services.Webui.Spec.ServiceConfiguration.Containers
@@ -2175,42 +2379,80 @@ func ziuCheckContainerImage(m *Manager) (stsName string, image string) {
 	return
 }
 
+// ZiuTargetImage returns image from the manifest used to detect ZIU
+func (m *Manager) ZiuTargetImage() string {
+	_, image := ziuCheckContainerImage(m)
+	return image
+}
+
+// imageTag returns the tag of the image
+func imageTag(image string) string {
+	ss := strings.Split(image, ":")
+	return ss[len(ss)-1]
+}
+
+// ziuDeployedVersion returns the image tag of the deployed STS ZIU is detected by,
+// empty if there is no such STS
+func ziuDeployedVersion(manager *Manager, clnt client.Client) (string, error) {
+	stsName, _ := ziuCheckContainerImage(manager)
+	if stsName == "" {
+		return "", nil
+	}
+	sts := &appsv1.StatefulSet{}
+	nsName := types.NamespacedName{Name: stsName, Namespace: manager.GetNamespace()}
+	if err := clnt.Get(context.Background(), nsName, sts); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	// Get first container tag from sts
+	return imageTag(sts.Spec.Template.Spec.Containers[0].Image), nil
+}
+
 // IsZiuRequired
 // Return true if manifests image tag (get kubemanager or webui depending on CNI)
-// is different from deployed STS
+// is different from deployed STS and the deployed version is upgraded by ZIU
+// according to the ZIU versions table
 func IsZiuRequired(clnt client.Client) (bool, error) {
 	manager, err := GetManagerObject(clnt)
 	if err != nil {
 		return false, err
 	}
-	stsName, image := ziuCheckContainerImage(manager)
-	if stsName == "" || image == "" {
+	_, image := ziuCheckContainerImage(manager)
+	if image == "" {
 		return false, nil
 	}
-	var manifestTag string
-	ss := strings.Split(image, ":")
-	manifestTag = ss[len(ss)-1]
-	sts := &appsv1.StatefulSet{}
-	nsName := types.NamespacedName{Name: stsName, Namespace: manager.GetNamespace()}
-	if err = clnt.Get(context.Background(), nsName, sts); err != nil {
-		if k8serrors.IsNotFound(err) {
-			// Looks like setup installed the first time
-			return false, nil
-		}
+	if manager.IsZiuRolledBack() {
+		// ZIU to the spec has been rolled back, the spec is to be changed to retry
+		return false, nil
+	}
+	deployedTag, err := ziuDeployedVersion(manager, clnt)
+	if err != nil || deployedTag == "" {
+		// Looks like setup installed the first time
 		return false, err
 	}
-	// Get first container tag from sts
-	ss = strings.Split(sts.Spec.Template.Spec.Containers[0].Image, ":")
-	deployedTag := ss[len(ss)-1]
-	return deployedTag != manifestTag, nil
+	if deployedTag == imageTag(image) {
+		return false, nil
+	}
+	versions, err := GetZiuVersions(manager.GetNamespace(), clnt)
+	if err != nil {
+		return false, err
+	}
+	return MatchZiuVersion(versions, deployedTag) != nil, nil
 }
 
 // Function check reconsiler request against current ZIU stage and allow reconcile for controllers
 func CanReconcile(resourceKind string, clnt client.Client) (bool, error) {
-	ziuStage, err := GetZiuStage(clnt)
+	mngr, err := GetManagerObject(clnt)
 	if err != nil {
 		return false, err
 	}
+	// Only data stores are reconciled till they are restored from backup
+	if mngr.IsRestoreInProgress() {
+		return restoreAllowedKinds[resourceKind], nil
+	}
+	ziuStage := mngr.Status.ZiuState
 	if ziuStage == -1 {
 		if ziuStage == -1 {
 			f, err := IsZiuRequired(clnt)
@@ -2222,6 +2464,11 @@ func CanReconcile(resourceKind string, clnt client.Client) (bool, error) {
 	if resourceKind == "Vrouter" {
 		return false, nil
 	}
+	if len(ZiuKinds) == 0 {
+		if _, err := ResumeZiu(clnt); err != nil {
+			return false, err
+		}
+	}
 	// Calculate current reconcile stage
 	resourceStage := -1
 	for index, kind := range ZiuKinds {
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -9,8 +10,13 @@ import (
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tungstenfabric/tf-operator/pkg/certificates"
 )
 
 func getCassandras(names []string) []*CassandraInput {
@@ -305,3 +311,83 @@ func TestContainersImageChanged(t *testing.T) {
 	}
 	require.Equal(t, true, containersChanged(&currentSts.Spec.Template, &targetSts.Spec.Template))
 }
+
+func TestContainersResourcesChanged(t *testing.T) {
+	current := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name:  "container1",
+		Image: "image1",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+	}}}}
+	target := current.DeepCopy()
+	target.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("1024Mi")
+	require.Equal(t, false, containersChanged(current, target), "same quantity in other units")
+	target.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("2Gi")
+	require.Equal(t, true, containersChanged(current, target))
+}
+
+func TestPodIPsDualStack(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "control1-control-statefulset-0"},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+		Status: corev1.PodStatus{
+			PodIP:  "10.0.0.1",
+			PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+		},
+	}
+	assert.Equal(t, []string{"10.0.0.1", "fd00::1"}, PodIPs(&pod))
+
+	subjects := PodsCertSubjects("example.com", []corev1.Pod{pod}, PodAlternativeIPs{}, false, "config")
+	require.Len(t, subjects, 1)
+	assert.Equal(t, certificates.NewSubject(pod.Name, "example.com", "node1", "10.0.0.1",
+		[]string{"fd00::1"}, []string{"node1", ""}, false), subjects[0])
+}
+
+func TestPodIPListRejectsIPv6Primary(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	pod := restartTestPod(0, "rev1", true)
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.PodIP = "10.0.0.1"
+	pod.Status.PodIPs = []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}}
+	cl := fake.NewFakeClientWithScheme(scheme, pod)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "config1", Namespace: "tf"}}
+
+	pods, nodes, err := PodIPListAndIPMapFromInstance("config", request, cl, "")
+	require.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, []string{"10.0.0.1", "fd00::1"}, nodes[pod.Name].IPs)
+
+	pod.Status.PodIP = "fd00::1"
+	pod.Status.PodIPs = []corev1.PodIP{{IP: "fd00::1"}}
+	require.NoError(t, cl.Update(context.TODO(), pod))
+	_, _, err = PodIPListAndIPMapFromInstance("config", request, cl, "")
+	assert.Error(t, err, "configmap keys can't be made of IPv6 address")
+}
+
+func TestNodeInfoDeepCopy(t *testing.T) {
+	status := CommonStatus{Nodes: map[string]NodeInfo{
+		"pod1": {IP: "10.0.0.1", IPs: []string{"10.0.0.1", "fd00::1"}},
+	}}
+	copied := &CommonStatus{}
+	status.DeepCopyInto(copied)
+	copied.Nodes["pod1"].IPs[1] = "fd00::2"
+	assert.Equal(t, "fd00::1", status.Nodes["pod1"].IPs[1], "copy must not share addresses")
+}
+
+func TestSetSTSCommonConfigurationAffinity(t *testing.T) {
+	sts := &appsv1.StatefulSet{}
+	SetSTSCommonConfiguration(sts, &PodConfiguration{}, "config", "config1")
+	require.NotNil(t, sts.Spec.Template.Spec.Affinity, "pods are spread one per node by default")
+	terms := sts.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	assert.Equal(t, "kubernetes.io/hostname", terms[0].TopologyKey)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{Key: "config", Operator: "In", Values: []string{"config1"}}},
+		terms[0].LabelSelector.MatchExpressions)
+
+	affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	SetSTSCommonConfiguration(sts, &PodConfiguration{Affinity: affinity}, "config", "config1")
+	assert.Equal(t, affinity, sts.Spec.Template.Spec.Affinity, "configured affinity replaces the default")
+}
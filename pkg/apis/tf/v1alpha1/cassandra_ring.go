@@ -0,0 +1,384 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CassandraRingNode is a node of the Cassandra ring as reported by nodetool status.
+// +k8s:openapi-gen=true
+type CassandraRingNode struct {
+	Address string `json:"address"`
+	// State is the status and the state of the node, e.g. UN, DN, UJ, UL
+	State  string `json:"state"`
+	HostID string `json:"hostID,omitempty"`
+	Load   string `json:"load,omitempty"`
+}
+
+// CassandraRingOperation is the nodetool command changing the ring which runs in background in the pod.
+// +k8s:openapi-gen=true
+type CassandraRingOperation struct {
+	Pod string `json:"pod"`
+	// Command is the nodetool command, e.g. removenode <host id> or decommission
+	Command   string       `json:"command"`
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// key prefix of configmap data with address of the dead node to be replaced by the pod,
+// the data is empty if the pod starts as a new node or is a member of the ring.
+// The prefix is followed by the address of the pod in the form of replaceAddressKey.
+const cassandraReplaceAddressKey = "replace-address."
+
+// replaceAddressKey returns the key of the replace decision of the pod,
+// colons of IPv6 address are not allowed in configmap keys and are replaced by dashes
+// as by ${POD_IP//:/-} in the startup script
+func replaceAddressKey(ip string) string {
+	return cassandraReplaceAddressKey + strings.Replace(ip, ":", "-", -1)
+}
+
+// replaceAddressIP returns the address of the pod by the key of its replace decision
+func replaceAddressIP(key string) string {
+	return strings.Replace(strings.TrimPrefix(key, cassandraReplaceAddressKey), "-", ":", -1)
+}
+
+// files in the cassandra container with output and exit code of the ring operation
+const cassandraRingOperationFile = "/tmp/ring-operation"
+
+// cassandraExec runs commands in containers, it is replaced in unit tests
+var cassandraExec = ExecToContainer
+
+// parseCassandraRing parses nodes from output of nodetool status, e.g.
+// UN  10.0.0.1  1.2 MiB  256  ?  3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01  rack1
+func parseCassandraRing(status string) []CassandraRingNode {
+	var ring []CassandraRingNode
+	for _, line := range strings.Split(status, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 7 || len(f[0]) != 2 || !strings.ContainsAny(f[0][:1], "UD") || !strings.ContainsAny(f[0][1:], "NLJM") {
+			continue
+		}
+		ring = append(ring, CassandraRingNode{
+			Address: f[1],
+			State:   f[0],
+			HostID:  f[len(f)-2],
+			Load:    strings.Join(f[2:len(f)-4], " "),
+		})
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].Address < ring[j].Address })
+	return ring
+}
+
+// nodetoolCommand returns shell command of nodetool with the args
+func (c *Cassandra) nodetoolCommand(args string) string {
+	config := c.ConfigurationParameters()
+	jmxremoteParams := ""
+	if *config.ReaperEnabled {
+		jmxremoteParams = " -u cassandra -pw cassandra --ssl"
+	}
+	return "nodetool -p " + strconv.Itoa(*config.JmxLocalPort) + jmxremoteParams + " -Dcom.sun.jndi.rmiURLParsing=legacy " + args
+}
+
+// nodetool runs nodetool command in the cassandra container of the pod
+func (c *Cassandra) nodetool(pod *corev1.Pod, args string) (string, error) {
+	stdout, stderr, err := cassandraExec(pod, CassandraInstanceType, []string{"/usr/bin/bash", "-c", c.nodetoolCommand(args)}, nil)
+	if err != nil {
+		return stdout, fmt.Errorf("nodetool %s failed: %v (stderr=%s)", args, err, stderr)
+	}
+	return stdout, nil
+}
+
+// startRingOperation starts the nodetool command in background in the pod,
+// the command streams data and may take hours, its exit code is checked by checkRingOperation
+func (c *Cassandra) startRingOperation(pod *corev1.Pod, args string) error {
+	f := cassandraRingOperationFile
+	command := fmt.Sprintf("rm -f %[1]s.rc %[1]s.log ; nohup bash -c '%[2]s >%[1]s.log 2>&1 ; echo $? >%[1]s.rc' >/dev/null 2>&1 &",
+		f, c.nodetoolCommand(args))
+	if _, stderr, err := cassandraExec(pod, CassandraInstanceType, []string{"/usr/bin/bash", "-c", command}, nil); err != nil {
+		return fmt.Errorf("Failed to start nodetool %s: %v (stderr=%s)", args, err, stderr)
+	}
+	now := metav1.Now()
+	c.Status.RingOperation = &CassandraRingOperation{Pod: pod.Name, Command: args, StartTime: &now}
+	return nil
+}
+
+// checkRingOperation checks the ring operation of the status and clears it when it is finished.
+// Returns true if the operation is running and error if it has failed.
+func (c *Cassandra) checkRingOperation(podList []corev1.Pod) (bool, error) {
+	op := c.Status.RingOperation
+	if op == nil {
+		return false, nil
+	}
+	ll := cassandraLog.WithName("checkRingOperation").WithName(c.Name)
+	var pod *corev1.Pod
+	for idx := range podList {
+		if podList[idx].Name == op.Pod {
+			pod = &podList[idx]
+		}
+	}
+	if pod == nil {
+		ll.Info("Pod of the ring operation is gone", "pod", op.Pod, "command", op.Command)
+		c.Status.RingOperation = nil
+		return false, nil
+	}
+	f := cassandraRingOperationFile
+	command := fmt.Sprintf("if [ -f %[1]s.rc ] ; then cat %[1]s.rc ; tail -n 5 %[1]s.log ; elif [ -f %[1]s.log ] ; then echo running ; else echo lost ; fi", f)
+	stdout, stderr, err := cassandraExec(pod, CassandraInstanceType, []string{"/usr/bin/bash", "-c", command}, nil)
+	if err != nil {
+		return true, fmt.Errorf("Failed to check nodetool %s: %v (stderr=%s)", op.Command, err, stderr)
+	}
+	lines := strings.SplitN(strings.TrimSpace(stdout), "\n", 2)
+	switch lines[0] {
+	case "running":
+		return true, nil
+	case "lost":
+		// container is restarted, the ring state shows if the operation is to be repeated
+		ll.Info("Ring operation is lost", "pod", op.Pod, "command", op.Command)
+		c.Status.RingOperation = nil
+		return false, nil
+	}
+	c.Status.RingOperation = nil
+	if lines[0] != "0" {
+		output := ""
+		if len(lines) > 1 {
+			output = lines[1]
+		}
+		return false, fmt.Errorf("nodetool %s in pod %s failed with exit code %s: %s", op.Command, op.Pod, lines[0], output)
+	}
+	ll.Info("Ring operation finished", "pod", op.Pod, "command", op.Command)
+	return false, nil
+}
+
+// ReconcileRing reads the ring membership into the status and manages nodes of the ring:
+//   - a new pod replaces a dead node which address doesn't belong to any k8s node,
+//     cassandra doesn't start till the decision is in the configmap, the address
+//     is passed to -Dcassandra.replace_address_first_boot
+//   - dead nodes without replacement are removed by nodetool removenode
+//     when all replicas are running
+//   - pods leaving the ring by scale-down are decommissioned, the dead ones are removed
+//
+// One operation at a time is started in background and tracked in the status, the rest on next reconciles.
+// Returns true if the status is changed.
+func (c *Cassandra) ReconcileRing(podList []corev1.Pod, replicas int32, clnt client.Client) (bool, error) {
+	ll := cassandraLog.WithName("ReconcileRing").WithName(c.Name)
+
+	var queryPod *corev1.Pod
+	liveIPs := make(map[string]*corev1.Pod)
+	for idx := range podList {
+		pod := &podList[idx]
+		liveIPs[pod.Status.PodIP] = pod
+		if _, ok := c.Status.Nodes[pod.Name]; ok && queryPod == nil {
+			queryPod = pod
+		}
+	}
+	if queryPod == nil {
+		// ring is not formed yet, all pods start as new nodes
+		return false, c.InitReplaceAddresses(podList, clnt)
+	}
+
+	out, err := c.nodetool(queryPod, "status")
+	if err != nil {
+		return false, err
+	}
+	ring := parseCassandraRing(out)
+	changed := !reflect.DeepEqual(c.Status.Ring, ring)
+	c.Status.Ring = ring
+
+	wasRunning := c.Status.RingOperation != nil
+	running, err := c.checkRingOperation(podList)
+	changed = changed || (wasRunning && c.Status.RingOperation == nil)
+	if err != nil {
+		return changed, err
+	}
+
+	nodes, err := GetNodes(c.Spec.CommonConfiguration.NodeSelector, clnt)
+	if err != nil {
+		return changed, err
+	}
+	nodeIPs := make(map[string]bool)
+	for _, n := range nodes {
+		for _, a := range n.Status.Addresses {
+			nodeIPs[a.Address] = true
+		}
+	}
+	replaces, err := c.replaceAddresses(clnt)
+	if err != nil {
+		return changed, err
+	}
+	for ip := range replaces {
+		if liveIPs[ip] == nil && !nodeIPs[ip] {
+			delete(replaces, ip)
+		}
+	}
+
+	leaving := make(map[string]bool)
+	if c.Status.ScaleDown != nil {
+		for _, m := range c.Status.ScaleDown.Members {
+			leaving[m.Pod] = true
+		}
+	}
+
+	replaced := make(map[string]bool)
+	for _, address := range replaces {
+		replaced[address] = true
+	}
+	inRing := make(map[string]string)
+	var dead []CassandraRingNode
+	busy := running
+	for _, n := range ring {
+		inRing[n.Address] = n.State
+		if n.State[0] == 'D' && liveIPs[n.Address] == nil && !nodeIPs[n.Address] && !replaced[n.Address] {
+			dead = append(dead, n)
+		}
+		if n.State[1] != 'N' {
+			// some node is joining, leaving or moving
+			busy = true
+		}
+	}
+	var joining, undecided []*corev1.Pod
+	for _, pod := range liveIPs {
+		ip := pod.Status.PodIP
+		_, decided := replaces[ip]
+		switch {
+		case inRing[ip] != "" || leaving[pod.Name]:
+			if !decided {
+				replaces[ip] = ""
+			}
+		case decided:
+			joining = append(joining, pod)
+		default:
+			undecided = append(undecided, pod)
+		}
+	}
+	if !busy {
+		// new pods wait till topology changes are finished
+		sort.Slice(undecided, func(i, j int) bool { return undecided[i].Name < undecided[j].Name })
+		for i, pod := range undecided {
+			replaces[pod.Status.PodIP] = ""
+			if i < len(dead) {
+				ll.Info("Pod replaces dead node", "pod", pod.Name, "ip", pod.Status.PodIP, "address", dead[i].Address)
+				replaces[pod.Status.PodIP] = dead[i].Address
+			}
+			joining = append(joining, pod)
+		}
+		if len(undecided) < len(dead) {
+			dead = dead[len(undecided):]
+		} else {
+			dead = nil
+		}
+	}
+	if err := c.updateReplaceAddresses(replaces, clnt); err != nil {
+		return changed, err
+	}
+	if busy || len(joining) > 0 || len(undecided) > 0 {
+		return changed, nil
+	}
+
+	if len(dead) > 0 && len(podList) >= int(replicas) {
+		ll.Info("Remove dead node", "address", dead[0].Address, "hostID", dead[0].HostID)
+		return true, c.startRingOperation(queryPod, "removenode "+dead[0].HostID)
+	}
+
+	for idx := range podList {
+		pod := &podList[idx]
+		if !leaving[pod.Name] || inRing[pod.Status.PodIP] != "UN" {
+			continue
+		}
+		ll.Info("Decommission node", "pod", pod.Name, "ip", pod.Status.PodIP)
+		return true, c.startRingOperation(pod, "decommission")
+	}
+	return changed, nil
+}
+
+// InitReplaceAddresses lets pods without the decision about replacement start as new nodes
+// or members of the ring, it is used when the ring is not reconciled.
+func (c *Cassandra) InitReplaceAddresses(podList []corev1.Pod, clnt client.Client) error {
+	replaces, err := c.replaceAddresses(clnt)
+	if err != nil {
+		return err
+	}
+	for _, pod := range podList {
+		if _, ok := replaces[pod.Status.PodIP]; !ok && pod.Status.PodIP != "" {
+			replaces[pod.Status.PodIP] = ""
+		}
+	}
+	return c.updateReplaceAddresses(replaces, clnt)
+}
+
+// RemoveMember checks that the node of the leaving pod left the ring, the node is decommissioned
+// or removed by ReconcileRing. The removal is not finished while the ring operation is running
+// in the pod or the address of the pod, the last known one for a pod on a departed node, is in the ring.
+func (c *Cassandra) RemoveMember(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+	if op := c.Status.RingOperation; op != nil && op.Pod == pod.Name {
+		return false, fmt.Sprintf("nodetool %s is running", op.Command), nil
+	}
+	if len(c.Status.Ring) == 0 {
+		return false, "Ring is not known", nil
+	}
+	address := pod.Status.PodIP
+	if node, ok := c.Status.Nodes[pod.Name]; ok && address == "" {
+		address = node.IP
+	}
+	for _, n := range c.Status.Ring {
+		if address != "" && n.Address == address {
+			return false, fmt.Sprintf("Node %s is %s", n.Address, n.State), nil
+		}
+	}
+	return true, "", nil
+}
+
+func (c *Cassandra) replaceAddressesConfigMap(clnt client.Client) (*corev1.ConfigMap, error) {
+	return GetConfigMap(c.Name+"-"+CassandraInstanceType+"-configmap", c.Namespace, clnt)
+}
+
+// replaceAddresses returns decisions about replacement of pods from the configmap,
+// the address of the dead node or empty string by ip of the pod
+func (c *Cassandra) replaceAddresses(clnt client.Client) (map[string]string, error) {
+	cm, err := c.replaceAddressesConfigMap(clnt)
+	if err != nil {
+		return nil, err
+	}
+	replaces := make(map[string]string)
+	for key, address := range cm.Data {
+		if strings.HasPrefix(key, cassandraReplaceAddressKey) {
+			replaces[replaceAddressIP(key)] = address
+		}
+	}
+	return replaces, nil
+}
+
+// updateReplaceAddresses sets decisions about replacement of pods in the configmap,
+// decisions not in replaces are deleted
+func (c *Cassandra) updateReplaceAddresses(replaces map[string]string, clnt client.Client) error {
+	cm, err := c.replaceAddressesConfigMap(clnt)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for key := range cm.Data {
+		if strings.HasPrefix(key, cassandraReplaceAddressKey) {
+			if _, ok := replaces[replaceAddressIP(key)]; !ok {
+				delete(cm.Data, key)
+				changed = true
+			}
+		}
+	}
+	for ip, address := range replaces {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		if current, ok := cm.Data[replaceAddressKey(ip)]; !ok || current != address {
+			cm.Data[replaceAddressKey(ip)] = address
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return saveConfigMap(cm, false, clnt)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -21,6 +22,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -62,6 +64,7 @@ type CassandraConfiguration struct {
 	ReaperAppPort       *int                      `json:"reaperAppPort,omitempty"`
 	ReaperAdmPort       *int                      `json:"reaperAdmPort,omitempty"`
 	CassandraParameters CassandraConfigParameters `json:"cassandraParameters,omitempty"`
+	Storage             *Storage                  `json:"storage,omitempty"`
 }
 
 // CassandraStatus defines the status of the cassandra object.
@@ -69,6 +72,11 @@ type CassandraConfiguration struct {
 type CassandraStatus struct {
 	CommonStatus `json:",inline"`
 	Ports        CassandraStatusPorts `json:"ports,omitempty"`
+	Storage      *StorageStatus       `json:"storage,omitempty"`
+	// Ring is the ring membership as seen by Cassandra
+	Ring []CassandraRingNode `json:"ring,omitempty"`
+	// RingOperation is the running nodetool command changing the ring
+	RingOperation *CassandraRingOperation `json:"ringOperation,omitempty"`
 }
 
 // CassandraStatusPorts defines the status of the ports of the cassandra object.
@@ -116,10 +124,7 @@ func (c *Cassandra) InstanceConfiguration(request reconcile.Request,
 	client client.Client) error {
 
 	instanceConfigMapName := request.Name + "-" + CassandraInstanceType + "-configmap"
-	configMapInstanceDynamicConfig := &corev1.ConfigMap{}
-	err := client.Get(context.TODO(),
-		types.NamespacedName{Name: instanceConfigMapName, Namespace: request.Namespace},
-		configMapInstanceDynamicConfig)
+	configMapInstanceDynamicConfig, err := GetConfigMap(instanceConfigMapName, request.Namespace, client)
 	if err != nil {
 		return err
 	}
@@ -363,7 +368,11 @@ func (c *Cassandra) InstanceConfiguration(request reconcile.Request,
 	configMapInstanceDynamicConfig.Data["cassandra-provisioner.env"] = ProvisionerEnvData(&clusterNodes,
 		"", c.Spec.CommonConfiguration.AuthParameters)
 
-	return client.Update(context.TODO(), configMapInstanceDynamicConfig)
+	if err = ApplyConfigOverrides(CassandraInstanceType, c.Spec.CommonConfiguration.ConfigOverrides,
+		configMapInstanceDynamicConfig.Data); err != nil {
+		return err
+	}
+	return saveConfigMap(configMapInstanceDynamicConfig, false, client)
 }
 
 // CreateConfigMap creates a configmap for cassandra service.
@@ -407,6 +416,7 @@ func (c *Cassandra) CreateConfigMap(configMapName string,
 			"jmxremote.password.${POD_IP}":      "",
 			"jmxremote.access.${POD_IP}":        "",
 			"nodetool-ssl.properties.${POD_IP}": "",
+			"replace-address.${POD_IP//:/-}":    "",
 		})
 
 	return CreateConfigMap(configMapName,
@@ -567,6 +577,41 @@ func (c *Cassandra) ConfigurationParameters() *CassandraConfiguration {
 	return cassandraConfiguration
 }
 
+// ParseJVMSize parses the JVM memory size (-Xmx/-Xms format, e.g. 1024m, 4G) into quantity
+func ParseJVMSize(size string) (resource.Quantity, error) {
+	suffixes := map[byte]string{'k': "Ki", 'K': "Ki", 'm': "Mi", 'M': "Mi", 'g': "Gi", 'G': "Gi", 't': "Ti", 'T': "Ti"}
+	value := size
+	suffix := ""
+	if len(size) > 0 {
+		if s, ok := suffixes[size[len(size)-1]]; ok {
+			value, suffix = size[:len(size)-1], s
+		}
+	}
+	if n, err := strconv.ParseUint(value, 10, 64); err != nil || n == 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid JVM memory size %q", size)
+	}
+	return resource.ParseQuantity(value + suffix)
+}
+
+// HeapAwareResources returns default resources of the cassandra container for MaxHeapSize:
+// memory request and limit are twice the heap to leave the same room for off-heap structures
+// (memtables, bloom filters, direct buffers, metaspace and thread stacks).
+// Resources set explicitly for the container take precedence. Nil is returned if MaxHeapSize is not set.
+func (c *CassandraConfiguration) HeapAwareResources() (*corev1.ResourceRequirements, error) {
+	if c.MaxHeapSize == "" {
+		return nil, nil
+	}
+	heap, err := ParseJVMSize(c.MaxHeapSize)
+	if err != nil {
+		return nil, err
+	}
+	memory := *resource.NewQuantity(2*heap.Value(), resource.BinarySI)
+	return &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: memory},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: memory.DeepCopy()},
+	}, nil
+}
+
 // UpdateStatus manages the status of the Cassandra nodes.
 func (c *Cassandra) UpdateStatus(cassandraConfig *CassandraConfiguration, nodes map[string]NodeInfo, sts *appsv1.StatefulSet) bool {
 	log := cassandraLog.WithName("UpdateStatus")
@@ -625,9 +670,23 @@ func (c *Cassandra) UpdateStatus(cassandraConfig *CassandraConfiguration, nodes
 		changed = true
 	}
 
+	if sts != nil {
+		generation, conditions := c.Status.ObservedGeneration, append([]metav1.Condition(nil), c.Status.Conditions...)
+		c.Status.SetStatefulSetConditions(c.Generation, sts)
+		if generation != c.Status.ObservedGeneration || !reflect.DeepEqual(conditions, c.Status.Conditions) {
+			log.Info("Conditions", "generation", c.Status.ObservedGeneration)
+			changed = true
+		}
+	}
+
 	return changed || (c.Status.ConfigChanged != nil && *c.Status.ConfigChanged)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Cassandra) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // CommonStartupScript prepare common run service script
 //  command - is a final command to run
 //  configs - config files to be waited for and to be linked from configmap mount
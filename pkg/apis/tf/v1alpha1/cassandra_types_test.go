@@ -2,7 +2,10 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -13,8 +16,10 @@ import (
 	"gopkg.in/ini.v1"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -170,12 +175,12 @@ func TestCassandraConfigMapsWithDefaultValues(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, InitCA(cl, scheme, &cassandra, "cassandra"))
+	require.NoError(t, InitCA(cl, scheme, &cassandra, "cassandra", certificates.DefaultLifetimes()))
 
 	require.NoError(t, cassandra.InstanceConfiguration(cassandraRequest, cassandraPodList, cassandraNodeInfo, cassandraSeedList, cl))
 
-	var cassandraConfigMap = &corev1.ConfigMap{}
-	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "configdb1-cassandra-configmap", Namespace: "test-ns"}, cassandraConfigMap), "Error while gathering cassandra config map")
+	cassandraConfigMap, err := GetConfigMap("configdb1-cassandra-configmap", "test-ns", cl)
+	require.NoError(t, err, "Error while gathering cassandra config map")
 
 	var cassandraConfig CassandraParamsStruct
 	err = yaml.Unmarshal([]byte(cassandraConfigMap.Data["cassandra.1.1.1.1.yaml"]), &cassandraConfig)
@@ -294,12 +299,12 @@ func TestCassandraConfigMapsWithCustomValues(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, InitCA(cl, scheme, &cassandra, "cassandra"))
+	require.NoError(t, InitCA(cl, scheme, &cassandra, "cassandra", certificates.DefaultLifetimes()))
 
 	require.NoError(t, cassandra.InstanceConfiguration(cassandraRequest, cassandraPodList, cassandraNodeInfo, cassandraSeedList, cl))
 
-	var cassandraConfigMap = &corev1.ConfigMap{}
-	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "configdb1-cassandra-configmap", Namespace: "test-ns"}, cassandraConfigMap), "Error while gathering cassandra config map")
+	cassandraConfigMap, err := GetConfigMap("configdb1-cassandra-configmap", "test-ns", cl)
+	require.NoError(t, err, "Error while gathering cassandra config map")
 
 	var cassandraConfig CassandraParamsStruct
 	err = yaml.Unmarshal([]byte(cassandraConfigMap.Data["cassandra.1.1.1.1.yaml"]), &cassandraConfig)
@@ -388,3 +393,282 @@ func TestCassandraConfigMapsWithCustomValues(t *testing.T) {
 	assert.Equal(t, "pod1-host,pod2-host", cassandraNodemanagerEnvConfig.Section("").Key("export ANALYTICSDB_NODES").String())
 	assert.Equal(t, "pod1-host,pod2-host", cassandraNodemanagerEnvConfig.Section("").Key("export CONFIGDB_NODES").String())
 }
+
+func TestCassandraHeapAwareResources(t *testing.T) {
+	for size, expected := range map[string]string{"1024m": "1Gi", "4G": "4Gi", "524288k": "512Mi", "1073741824": "1Gi"} {
+		q, err := ParseJVMSize(size)
+		require.NoError(t, err, size)
+		assert.Equal(t, 0, q.Cmp(resource.MustParse(expected)), size)
+	}
+	for _, size := range []string{"", "g", "1.5g", "-1g", "0m", "1Gi"} {
+		_, err := ParseJVMSize(size)
+		assert.Error(t, err, size)
+	}
+
+	c := &CassandraConfiguration{}
+	resources, err := c.HeapAwareResources()
+	require.NoError(t, err)
+	assert.Nil(t, resources, "no defaults without heap size")
+
+	c.MaxHeapSize = "2g"
+	resources, err = c.HeapAwareResources()
+	require.NoError(t, err)
+	assert.Equal(t, "4Gi", resources.Requests.Memory().String())
+	assert.Equal(t, "4Gi", resources.Limits.Memory().String())
+}
+
+func TestCassandraParseRing(t *testing.T) {
+	status := `Datacenter: datacenter1
+=======================
+Status=Up/Down
+|/ State=Normal/Leaving/Joining/Moving
+--  Address   Load       Tokens       Owns (effective)  Host ID                               Rack
+UN  10.0.0.2  1.21 MiB   256          66.7%             5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02  rack1
+DN  10.0.0.1  998.5 KiB  256          66.7%             3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01  rack1
+UJ  10.0.0.3  ?          256          ?                 8e0cb0a6-2a94-4d2b-9d1d-0a3c1b2d4e03  rack1
+`
+	ring := parseCassandraRing(status)
+	assert.Equal(t, []CassandraRingNode{
+		{Address: "10.0.0.1", State: "DN", HostID: "3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01", Load: "998.5 KiB"},
+		{Address: "10.0.0.2", State: "UN", HostID: "5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02", Load: "1.21 MiB"},
+		{Address: "10.0.0.3", State: "UJ", HostID: "8e0cb0a6-2a94-4d2b-9d1d-0a3c1b2d4e03", Load: "?"},
+	}, ring)
+}
+
+func TestCassandraReplaceAddresses(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cassandra1-cassandra-configmap", Namespace: "test-ns"},
+		Data: map[string]string{
+			"cassandra.1.1.1.1.yaml":  "",
+			"replace-address.3.3.3.3": "10.0.0.1",
+		},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, cm)
+	cassandra := Cassandra{ObjectMeta: metav1.ObjectMeta{Name: "cassandra1", Namespace: "test-ns"}}
+
+	require.NoError(t, cassandra.updateReplaceAddresses(map[string]string{"4.4.4.4": "10.0.0.2", "1.1.1.1": "", "fd00::5": "fd00::2"}, cl))
+	updated := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, updated))
+	assert.Equal(t, map[string]string{
+		"cassandra.1.1.1.1.yaml":  "",
+		"replace-address.1.1.1.1": "",
+		"replace-address.4.4.4.4": "10.0.0.2",
+		"replace-address.fd00--5": "fd00::2",
+	}, updated.Data)
+	for key := range updated.Data {
+		assert.Empty(t, validation.IsConfigMapKey(key), key)
+	}
+
+	replaces, err := cassandra.replaceAddresses(cl)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"1.1.1.1": "", "4.4.4.4": "10.0.0.2", "fd00::5": "fd00::2"}, replaces)
+}
+
+// fakeCassandraRing answers nodetool and ring operation commands in unit tests
+type fakeCassandraRing struct {
+	status   string
+	opStatus string
+	started  []string
+}
+
+func (f *fakeCassandraRing) exec(pod *corev1.Pod, container string, command []string, stdin io.Reader) (string, string, error) {
+	script := command[len(command)-1]
+	switch {
+	case strings.Contains(script, "nohup"):
+		f.started = append(f.started, pod.Name+": "+script)
+		return "", "", nil
+	case strings.Contains(script, cassandraRingOperationFile+".rc ] ; then"):
+		return f.opStatus, "", nil
+	case strings.HasSuffix(script, " status"):
+		return f.status, "", nil
+	}
+	return "", "", fmt.Errorf("unexpected command %s", script)
+}
+
+func newRingPod(id, ip string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cassandra1-cassandra-statefulset-" + id, Namespace: "test-ns"},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func newRingCassandra() *Cassandra {
+	return &Cassandra{
+		ObjectMeta: metav1.ObjectMeta{Name: "cassandra1", Namespace: "test-ns"},
+		Status: CassandraStatus{CommonStatus: CommonStatus{Nodes: map[string]NodeInfo{
+			"cassandra1-cassandra-statefulset-0": {IP: "10.0.0.2"},
+		}}},
+	}
+}
+
+func TestCassandraReconcileRingReplacesDeadNode(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cassandra1-cassandra-configmap", Namespace: "test-ns"}}
+	cl := fake.NewFakeClientWithScheme(scheme, cm)
+
+	ring := &fakeCassandraRing{status: `UN  10.0.0.2  1.21 MiB   256  66.7%  5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02  rack1
+DN  10.0.0.1  998.5 KiB  256  66.7%  3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01  rack1
+`}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		cassandraExec = exec
+	}(cassandraExec)
+	cassandraExec = ring.exec
+
+	cassandra := newRingCassandra()
+	pods := []corev1.Pod{newRingPod("0", "10.0.0.2"), newRingPod("1", "10.0.0.3"), newRingPod("2", "10.0.0.4")}
+	changed, err := cassandra.ReconcileRing(pods, 3, cl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, ring.started, "nothing is removed while pods join")
+
+	replaces, err := cassandra.replaceAddresses(cl)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"10.0.0.2": "",
+		"10.0.0.3": "10.0.0.1",
+		"10.0.0.4": "",
+	}, replaces, "new pods wait for the decision before cassandra starts")
+
+	// the decision is not changed on next reconciles, the dead node is not removed while it is replaced
+	_, err = cassandra.ReconcileRing(pods, 3, cl)
+	require.NoError(t, err)
+	replaces, err = cassandra.replaceAddresses(cl)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", replaces["10.0.0.3"])
+	assert.Empty(t, ring.started)
+}
+
+func TestCassandraReconcileRingTracksRemoveNode(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cassandra1-cassandra-configmap", Namespace: "test-ns"},
+		Data:       map[string]string{"replace-address.10.0.0.2": "", "replace-address.10.0.0.3": ""},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, cm)
+
+	ring := &fakeCassandraRing{status: `UN  10.0.0.2  1.21 MiB   256  66.7%  5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02  rack1
+UN  10.0.0.3  1.18 MiB   256  66.7%  8e0cb0a6-2a94-4d2b-9d1d-0a3c1b2d4e03  rack1
+DN  10.0.0.1  998.5 KiB  256  66.7%  3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01  rack1
+`}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		cassandraExec = exec
+	}(cassandraExec)
+	cassandraExec = ring.exec
+
+	cassandra := newRingCassandra()
+	pods := []corev1.Pod{newRingPod("0", "10.0.0.2"), newRingPod("1", "10.0.0.3")}
+	changed, err := cassandra.ReconcileRing(pods, 2, cl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, ring.started, 1)
+	assert.Contains(t, ring.started[0], "removenode 3cd1aa2c-bd9b-4a39-9a2c-0b7e2f4f0c01 >/tmp/ring-operation.log 2>&1 ; echo $? >/tmp/ring-operation.rc")
+	require.NotNil(t, cassandra.Status.RingOperation)
+	assert.Equal(t, "cassandra1-cassandra-statefulset-0", cassandra.Status.RingOperation.Pod)
+
+	// the removal is running, nothing else is started
+	ring.opStatus = "running\n"
+	_, err = cassandra.ReconcileRing(pods, 2, cl)
+	require.NoError(t, err)
+	assert.Len(t, ring.started, 1)
+	assert.NotNil(t, cassandra.Status.RingOperation)
+
+	// the removal has failed, the error is reported and the removal is retried later
+	ring.opStatus = "1\nerror: Host ID not found\n"
+	changed, err = cassandra.ReconcileRing(pods, 2, cl)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 1: error: Host ID not found")
+	assert.True(t, changed)
+	assert.Nil(t, cassandra.Status.RingOperation)
+
+	_, err = cassandra.ReconcileRing(pods, 2, cl)
+	require.NoError(t, err)
+	assert.Len(t, ring.started, 2)
+}
+
+func TestCassandraReconcileRingDecommissions(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cassandra1-cassandra-configmap", Namespace: "test-ns"}}
+	cl := fake.NewFakeClientWithScheme(scheme, cm)
+
+	ring := &fakeCassandraRing{status: `UN  10.0.0.2  1.21 MiB   256  66.7%  5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02  rack1
+UN  10.0.0.3  1.18 MiB   256  66.7%  8e0cb0a6-2a94-4d2b-9d1d-0a3c1b2d4e03  rack1
+`}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		cassandraExec = exec
+	}(cassandraExec)
+	cassandraExec = ring.exec
+
+	cassandra := newRingCassandra()
+	pods := []corev1.Pod{newRingPod("0", "10.0.0.2"), newRingPod("1", "10.0.0.3")}
+	_, err = cassandra.ReconcileRing(pods, 1, cl)
+	require.NoError(t, err)
+	assert.Empty(t, ring.started, "nodes are decommissioned only by scale-down")
+
+	cassandra.Status.ScaleDown = &ScaleDownStatus{Replicas: 1, Phase: ScaleDownRemovingMembers,
+		Members: []ScaleDownMember{{Pod: "cassandra1-cassandra-statefulset-1"}}}
+	_, err = cassandra.ReconcileRing(pods, 1, cl)
+	require.NoError(t, err)
+	require.Len(t, ring.started, 1)
+	assert.True(t, strings.HasPrefix(ring.started[0], "cassandra1-cassandra-statefulset-1: "))
+	assert.Contains(t, ring.started[0], " decommission >")
+
+	// the node has left the ring
+	ring.status = `UN  10.0.0.2  1.21 MiB   256  100%  5b2a54d5-4b8e-4a4f-8f0c-c4ca1d7e2c02  rack1
+`
+	ring.opStatus = "0\n"
+	changed, err := cassandra.ReconcileRing(pods, 1, cl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Nil(t, cassandra.Status.RingOperation)
+	assert.Len(t, ring.started, 1)
+}
+
+func TestCassandraRemoveMember(t *testing.T) {
+	cassandra := newRingCassandra()
+	cassandra.Status.Nodes["cassandra1-cassandra-statefulset-1"] = NodeInfo{IP: "10.0.0.3"}
+	pod := newRingPod("1", "10.0.0.3")
+
+	removed, message, err := cassandra.RemoveMember(&pod, nil)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, "Ring is not known", message)
+
+	cassandra.Status.Ring = []CassandraRingNode{{Address: "10.0.0.2", State: "UN"}, {Address: "10.0.0.3", State: "UL"}}
+	cassandra.Status.RingOperation = &CassandraRingOperation{Pod: pod.Name, Command: "decommission"}
+	removed, message, err = cassandra.RemoveMember(&pod, nil)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, "nodetool decommission is running", message)
+
+	// the operation is finished but the ring is not refreshed yet
+	cassandra.Status.RingOperation = nil
+	removed, message, err = cassandra.RemoveMember(&pod, nil)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, "Node 10.0.0.3 is UL", message)
+
+	// the pod on the departed node has no address, the last known one is checked
+	departed := newRingPod("1", "")
+	cassandra.Status.Ring[1].State = "DN"
+	removed, _, err = cassandra.RemoveMember(&departed, nil)
+	require.NoError(t, err)
+	assert.False(t, removed, "dead node is still in the ring")
+
+	cassandra.Status.Ring = cassandra.Status.Ring[:1]
+	removed, _, err = cassandra.RemoveMember(&pod, nil)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, _, err = cassandra.RemoveMember(&departed, nil)
+	require.NoError(t, err)
+	assert.True(t, removed)
+}
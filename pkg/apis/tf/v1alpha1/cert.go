@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/tungstenfabric/tf-operator/pkg/certificates"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -23,17 +26,22 @@ const (
 
 var signer certificates.CertificateSigner = nil
 
-func InitCA(cl client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string) (err error) {
+// InitCA initializes the signer of certificates, lifetimes of certificates are stored
+// in the CA configmap to be used by owners of certificates
+func InitCA(cl client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string, lifetimes certificates.Lifetimes) (err error) {
 	// This might be called from reconsiles.. need sync
 	_Lock.Lock()
 	defer _Lock.Unlock()
 	err = nil
 	if certificates.ClientSignerName != certificates.ExternalSigner {
 		if certificates.ClientSignerName == certificates.SelfSigner {
-			signer, err = certificates.InitSelfCA(cl, scheme, owner, ownerType)
+			signer, err = certificates.InitSelfCA(cl, scheme, owner, ownerType, lifetimes)
 		} else {
 			signer, err = certificates.InitK8SCA(cl, scheme, owner)
 		}
+		if err == nil {
+			err = certificates.UpdateLifetimes(owner.GetNamespace(), lifetimes, cl)
+		}
 		if err == nil {
 			err = touchCertSecretsOnCAUpdate(owner.GetNamespace(), cl)
 		}
@@ -98,11 +106,120 @@ func EnsureCertificatesExistEx(instance metav1.Object, pods []corev1.Pod, instan
 	if err != nil {
 		return err
 	}
-	return crt.EnsureExistsAndIsSigned(false)
+	if err = crt.EnsureExistsAndIsSigned(false); err != nil {
+		return err
+	}
+	if issued := crt.Issued(); issued > 0 {
+		certType := "server"
+		if clientAuth {
+			certType = "client"
+		}
+		RecordEvent(instance, corev1.EventTypeNormal, EventReasonCertificatesIssued,
+			"%d %s certificates are issued for pods of %s", issued, certType, instanceType)
+	}
+	return nil
 }
 
-// EnsureCertificatesExist ensures pod server cert is issued
+// EnsureCertificatesExist ensures pod server cert is issued,
+// CertificatesReady condition of the instance is set by the result
 func EnsureCertificatesExist(instance metav1.Object, pods []corev1.Pod, instanceType string, cl client.Client, scheme *runtime.Scheme) error {
+	err := ensureCertificatesExist(instance, pods, instanceType, cl, scheme)
+	if o, ok := instance.(StatusObject); ok {
+		status := o.GetCommonStatus()
+		switch {
+		case err != nil:
+			status.SetCondition(ConditionCertificatesReady, false, "CertificatesNotSigned", err.Error())
+			// the error is returned to the reconcile, so the condition is stored here
+			if updateErr := cl.Status().Update(context.TODO(), o); updateErr != nil {
+				return fmt.Errorf("%v, failed to update status: %w", err, updateErr)
+			}
+		case certificates.ClientSignerName == certificates.ExternalSigner:
+			status.SetCondition(ConditionCertificatesReady, true, "ExternalSigner", "Certificates are signed by the external signer")
+		default:
+			status.SetCondition(ConditionCertificatesReady, true, "CertificatesSigned", "Certificates of pods are signed")
+			setCertificatesExpiry(status, instance, cl)
+		}
+	}
+	return err
+}
+
+// setCertificatesExpiry stores the earliest expiry and renewal time of certificates of the instance in its status
+func setCertificatesExpiry(status *CommonStatus, instance metav1.Object, cl client.Client) {
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Name: instance.GetName() + "-secret-certificates", Namespace: instance.GetNamespace()}
+	if err := cl.Get(context.TODO(), name, secret); err != nil {
+		return
+	}
+	notAfter, renewAt := certificates.GetLifetimes(instance.GetNamespace(), cl).CertificatesExpiry(secret)
+	if notAfter.IsZero() {
+		return
+	}
+	status.CertificatesNotAfter = &metav1.Time{Time: notAfter}
+	status.CertificatesRenewAt = &metav1.Time{Time: renewAt}
+}
+
+// CertificatesRenewal touches secrets with certificates to be renewed to trigger reconciles of their owners.
+// Returns the time till the nearest renewal of certificates or of the self-signed CA generated by the operator,
+// 0 if there is nothing to renew.
+func CertificatesRenewal(ns string, cl client.Client) (time.Duration, error) {
+	if certificates.ClientSignerName == certificates.ExternalSigner {
+		return 0, nil
+	}
+	lifetimes := certificates.GetLifetimes(ns, cl)
+	var next time.Time
+	nearest := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	if certificates.ClientSignerName == certificates.SelfSigner {
+		caSecret, err := certificates.GetCaCertSecret(cl, ns)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return 0, err
+		}
+		if err == nil && metav1.GetControllerOf(caSecret) != nil {
+			if certs, err := certutil.ParseCertsPEM(caSecret.Data[certificates.CAFilename]); err == nil && len(certs) > 0 {
+				nearest(lifetimes.RenewalTime(certs[0]))
+			}
+		}
+	}
+	secretsList := &corev1.SecretList{}
+	if err := cl.List(context.TODO(), secretsList, &client.ListOptions{Namespace: ns}); err != nil {
+		return 0, err
+	}
+	now := certificates.RenewalClock()
+	for _, s := range secretsList.Items {
+		if !strings.HasSuffix(s.Name, "secret-certificates") {
+			continue
+		}
+		_, renewAt := lifetimes.CertificatesExpiry(&s)
+		if renewAt.IsZero() {
+			continue
+		}
+		if renewAt.After(now) {
+			nearest(renewAt)
+			continue
+		}
+		// the owner re-issues certificates on the reconcile
+		value := renewAt.UTC().Format(time.RFC3339)
+		if s.Annotations["renew-at"] == value {
+			continue
+		}
+		if s.Annotations == nil {
+			s.Annotations = map[string]string{}
+		}
+		s.Annotations["renew-at"] = value
+		if err := cl.Update(context.TODO(), &s); err != nil {
+			return 0, err
+		}
+	}
+	if next.IsZero() {
+		return 0, nil
+	}
+	return next.Sub(now), nil
+}
+
+func ensureCertificatesExist(instance metav1.Object, pods []corev1.Pod, instanceType string, cl client.Client, scheme *runtime.Scheme) error {
 	if certificates.ClientSignerName == certificates.ExternalSigner {
 		return nil
 	}
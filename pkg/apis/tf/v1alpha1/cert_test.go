@@ -269,7 +269,7 @@ func prepareSelfCA(t *testing.T) (*corev1.Secret, client.Client, *runtime.Scheme
 	caSecret, err := getSelfCASecret(caCertValidityPeriod10Years)
 	require.NoError(t, err, "Failed to create secret with self CA")
 	cl := fake.NewFakeClientWithScheme(scheme, caSecret)
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	validateCAConfigMap(t, cl, caSecret.Data[caFileName])
 	return caSecret, cl, scheme
 }
@@ -297,7 +297,7 @@ func TestSelfSignedCAInitEmptySecret(t *testing.T) {
 	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
 	caSecret := getSelfCASecretEmpty()
 	cl := fake.NewFakeClientWithScheme(scheme, caSecret)
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	err = cl.Get(context.TODO(), types.NamespacedName{Name: caSecret.GetName(), Namespace: caSecret.GetNamespace()}, caSecret)
 	require.NoError(t, err)
 	validateCAConfigMap(t, cl, caSecret.Data[caFileName])
@@ -334,7 +334,7 @@ func TestSelfSignedCAUpdate(t *testing.T) {
 	caSecret2, err := getSelfCASecret(caCertValidityPeriod10Years)
 	require.NoError(t, err, "Failed to create secret with self CA")
 	require.NoError(t, cl.Update(context.TODO(), caSecret2), "Failed to update root CA")
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	require.NotEqual(t, string(caSecret.Data[caFileName]), string(caSecret2.Data[caFileName]))
 	validateCAConfigMap(t, cl, caSecret2.Data[caFileName])
 }
@@ -347,7 +347,7 @@ func TestSelfSignedCARenewal(t *testing.T) {
 	require.NoError(t, err, "Failed to create secret with self CA")
 	require.NoError(t, cl.Update(context.TODO(), caSecret), "Failed to update root CA")
 	// to update ca configmap
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	// check cert is invalide now
 	certs := getServerCerts(t, cl)
 	for _, c := range certs {
@@ -365,6 +365,111 @@ func TestSelfSignedCARenewal(t *testing.T) {
 	}
 }
 
+// shiftNow moves the clock of certificates by the duration, returns the function to restore it
+func shiftNow(d time.Duration) func() {
+	certificates.RenewalClock = func() time.Time { return time.Now().Add(d) }
+	return func() { certificates.RenewalClock = time.Now }
+}
+
+// lifetimesWith returns default lifetimes with the validity periods of certificates and CA if they are set
+func lifetimesWith(cert, caCert time.Duration) certificates.Lifetimes {
+	lifetimes := certificates.DefaultLifetimes()
+	if cert > 0 {
+		lifetimes.Cert = cert
+	}
+	if caCert > 0 {
+		lifetimes.CACert = caCert
+	}
+	return lifetimes
+}
+
+func TestSelfSignedCACertProactiveRenewal(t *testing.T) {
+	_, cl, scheme := prepareSelfCA(t)
+	lifetimes := lifetimesWith(90*24*time.Hour, 0)
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, lifetimes))
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
+	certs := getServerCerts(t, cl)
+	require.Equal(t, 90*24*time.Hour, certs[0].NotAfter.Sub(certs[0].NotBefore).Round(time.Hour))
+	require.NotNil(t, owner.Status.CertificatesNotAfter)
+	require.Equal(t, certs[0].NotAfter.Unix(), owner.Status.CertificatesNotAfter.Unix())
+	renewAt := lifetimes.RenewalTime(certs[0])
+	require.Equal(t, renewAt.Unix(), owner.Status.CertificatesRenewAt.Unix())
+	require.WithinDuration(t, certs[0].NotBefore.Add(72*24*time.Hour), renewAt, time.Minute, "renewed at 80% of lifetime")
+
+	next, err := CertificatesRenewal("tf", cl)
+	require.NoError(t, err)
+	require.WithinDuration(t, renewAt, time.Now().Add(next), time.Minute, "requeue at the nearest renewal")
+
+	certBytes1 := getServerCertsRaw(t, cl)
+	restore := shiftNow(73 * 24 * time.Hour)
+	defer restore()
+	_, err = CertificatesRenewal("tf", cl)
+	require.NoError(t, err)
+	secret := &corev1.Secret{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: owner.Name + "-secret-certificates", Namespace: "tf"}, secret))
+	require.NotEmpty(t, secret.Annotations["renew-at"], "secret is touched to trigger reconcile of the owner")
+
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to renew cert")
+	require.NotEqual(t, string(certBytes1), string(getServerCertsRaw(t, cl)), "cert must be renewed")
+	require.Equal(t, lifetimes.RenewalTime(getServerCerts(t, cl)[0]).Unix(), owner.Status.CertificatesRenewAt.Unix())
+}
+
+func TestSelfSignedCACertShortenedValidity(t *testing.T) {
+	_, cl, scheme := prepareSelfCA(t)
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
+	certBytes1 := getServerCertsRaw(t, cl)
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, lifetimesWith(90*24*time.Hour, 0)))
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
+	require.NotEqual(t, string(certBytes1), string(getServerCertsRaw(t, cl)), "cert living longer must be re-issued")
+	certs := getServerCerts(t, cl)
+	require.Equal(t, 90*24*time.Hour, certs[0].NotAfter.Sub(certs[0].NotBefore).Round(time.Hour))
+}
+
+func TestSelfSignedCARollover(t *testing.T) {
+	lifetimes := lifetimesWith(0, 365*24*time.Hour)
+	initApis(true)
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	cl := fake.NewFakeClientWithScheme(scheme)
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, lifetimes))
+	caSecret, err := certificates.GetCaCertSecret(cl, "tf")
+	require.NoError(t, err)
+	oldCA := caSecret.Data[caFileName]
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
+	oldCerts := getServerCerts(t, cl)
+
+	// not renewed before 80% of the lifetime
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, lifetimes))
+	caSecret, err = certificates.GetCaCertSecret(cl, "tf")
+	require.NoError(t, err)
+	require.Equal(t, string(oldCA), string(caSecret.Data[caFileName]))
+
+	defer shiftNow(300 * 24 * time.Hour)()
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, lifetimes))
+	caSecret, err = certificates.GetCaCertSecret(cl, "tf")
+	require.NoError(t, err)
+	bundle, err := certutil.ParseCertsPEM(caSecret.Data[caFileName])
+	require.NoError(t, err)
+	require.Equal(t, 2, len(bundle), "the previous CA is kept in the bundle")
+	require.True(t, strings.HasSuffix(string(caSecret.Data[caFileName]), string(oldCA)))
+	validateCAConfigMap(t, cl, caSecret.Data[caFileName])
+	_, err = certificates.ValidateCert(oldCerts[0], caSecret.Data[caFileName])
+	require.NoError(t, err, "certificates of the previous CA stay valid")
+
+	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
+	newCerts := getServerCerts(t, cl)
+	require.Equal(t, bundle[0].Subject.String(), newCerts[0].Issuer.String())
+	require.Equal(t, bundle[0].SubjectKeyId, newCerts[0].AuthorityKeyId, "cert is re-issued by the new CA")
+}
+
+func TestSelfSignedCAUsersSecretIsNotRenewed(t *testing.T) {
+	caSecret, cl, scheme := prepareSelfCA(t)
+	defer shiftNow(9 * 365 * 24 * time.Hour)()
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
+	validateCAConfigMap(t, cl, caSecret.Data[caFileName])
+}
+
 func TestOpenshiftSelfCAInit(t *testing.T) {
 	initApis(true)
 	// if openshift detected self ca must be used
@@ -373,7 +478,7 @@ func TestOpenshiftSelfCAInit(t *testing.T) {
 	require.NoError(t, err, "Failed to build scheme")
 	require.NoError(t, corev1.SchemeBuilder.AddToScheme(myScheme), "Failed to add CoreV1 into scheme")
 	cl := fake.NewFakeClientWithScheme(myScheme)
-	require.NoError(t, InitCA(cl, myScheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, myScheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	s, err := certificates.GetCaCertSecret(cl, "tf")
 	require.NoError(t, err)
 	require.NotEmpty(t, s.Data[caFileName])
@@ -415,7 +520,7 @@ func prepareOpenshiftCA(t *testing.T) (client.Client, *runtime.Scheme) {
 	require.NoError(t, err, "Failed to build scheme")
 	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
 	cl := fake.NewFakeClientWithScheme(scheme)
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	validateCAConfigMap(t, cl, csrIfaceImpl.caCertPem)
 	return cl, scheme
 }
@@ -423,7 +528,7 @@ func prepareOpenshiftCA(t *testing.T) (client.Client, *runtime.Scheme) {
 func TestOpenshiftCAInit(t *testing.T) {
 	cl, scheme := prepareOpenshiftCA(t)
 	// second call should not change
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	validateCAConfigMap(t, cl, csrIfaceImpl.caCertPem)
 }
 
@@ -431,7 +536,7 @@ func TestOpenshiftCAIssueCert(t *testing.T) {
 	cl, scheme := prepareOpenshiftCA(t)
 	require.NoError(t, EnsureCertificatesExist(owner, pods, owner_type, cl, scheme), "Failed to issue cert")
 	// second call should not change
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	validateCAConfigMap(t, cl, csrIfaceImpl.caCertPem)
 	// read and verify server cert
 	certs := getServerCerts(t, cl)
@@ -464,7 +569,7 @@ func TestOpenshiftCARenewal(t *testing.T) {
 	require.NoError(t, ee)
 	require.Equal(t, string(csrIfaceImpl.caCertPem), string(tt))
 	t.Logf("DBG: ocp ca\n%s", string(tt))
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	require.NotEqual(t, string(oldCA), string(csrIfaceImpl.caCertPem), "CA must be changed")
 	validateCAConfigMap(t, cl, csrIfaceImpl.caCertPem)
 	// check cert is invalid now
@@ -540,7 +645,7 @@ func prepareK8SCA(t *testing.T, fakeIntermediate bool) (client.Client, *runtime.
 	require.NoError(t, err, "Failed to build scheme")
 	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
 	cl := fake.NewFakeClientWithScheme(scheme)
-	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type))
+	require.NoError(t, InitCA(cl, scheme, ownerCA, owner_ca_type, certificates.DefaultLifetimes()))
 	return cl, scheme
 }
 
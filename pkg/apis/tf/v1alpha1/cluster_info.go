@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/tungstenfabric/tf-operator/pkg/k8s"
 	v1 "k8s.io/api/core/v1"
@@ -30,8 +31,10 @@ type KubernetesClusterConfig struct {
 // KubernetesClusterNetworking k8s cluster networking parameters
 // +k8s:openapi-gen=true
 type KubernetesClusterNetworking struct {
-	DNSDomain     string    `json:"dnsDomain,omitempty"`
-	PodSubnet     string    `json:"podSubnet,omitempty"`
+	DNSDomain string `json:"dnsDomain,omitempty"`
+	// PodSubnet is comma separated list of pod subnets, e.g. IPv4 and IPv6 subnets for dual-stack
+	PodSubnet string `json:"podSubnet,omitempty"`
+	// ServiceSubnet is comma separated list of service subnets, e.g. IPv4 and IPv6 subnets for dual-stack
 	ServiceSubnet string    `json:"serviceSubnet,omitempty"`
 	CNIConfig     CNIConfig `json:"cniConfig,omitempty"`
 }
@@ -203,32 +206,69 @@ func (c *KubernetesClusterConfig) fillWithClusterConfigMap() error {
 
 	c.ClusterName = clusterConfigStruct.Metadata.Name
 	c.Networking.DNSDomain = clusterConfigStruct.BaseDomain
-	if len(clusterConfigStruct.Networking.ClusterNetwork) > 0 {
-		c.Networking.PodSubnet = clusterConfigStruct.Networking.ClusterNetwork[0].Cidr
-	}
-	if len(clusterConfigStruct.Networking.ServiceNetwork) > 0 {
-		c.Networking.ServiceSubnet = clusterConfigStruct.Networking.ServiceNetwork[0]
+	// dual-stack cluster has networks of both IP families
+	var podSubnets []string
+	for _, n := range clusterConfigStruct.Networking.ClusterNetwork {
+		podSubnets = append(podSubnets, n.Cidr)
 	}
+	c.Networking.PodSubnet = strings.Join(podSubnets, ",")
+	c.Networking.ServiceSubnet = strings.Join(clusterConfigStruct.Networking.ServiceNetwork, ",")
 
 	return nil
 }
 
-// ClusterParameters returns cluster configuration, merged from manager configuration and system configmaps
+// ClusterParameters returns cluster configuration resolved by the manager into its status,
+// services deployed without the manager resolve it by ResolveClusterParameters
 func ClusterParameters(client client.Client) (*KubernetesClusterConfig, error) {
-	var defaultConfig, kubeadmConfig, clusterConfig KubernetesClusterConfig
+	mngr, err := GetManagerObject(client)
+	if err != nil {
+		resultConfig, _, err := ResolveClusterParameters(client)
+		return resultConfig, err
+	}
+	if mngr.Status.ClusterConfig == nil {
+		return nil, fmt.Errorf("cluster parameters are not resolved by manager %s yet", mngr.Name)
+	}
+	resultConfig := *mngr.Status.ClusterConfig
+	return &resultConfig, nil
+}
 
-	defaultConfig.fillWithDefaultValues()
+// ResolveClusterParameters returns cluster configuration, merged from defaults, values discovered
+// by ClusterInfoProviders and clusterConfig of the manager which always wins,
+// and names of providers which values are used. It is resolved by the manager once per reconcile.
+func ResolveClusterParameters(client client.Client) (*KubernetesClusterConfig, []string, error) {
+	var resultConfig KubernetesClusterConfig
+	var sources []string
 
-	if err := kubeadmConfig.fillWithKubeadmConfigMap(); err != nil {
-		return nil, err
+	resultConfig.fillWithDefaultValues()
+
+	for _, provider := range ClusterInfoProviders {
+		cfg, err := provider.Discover(client)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg != nil && *cfg != (KubernetesClusterConfig{}) {
+			resultConfig.replaceFields(*cfg)
+			sources = append(sources, provider.Name())
+		}
 	}
 
-	if err := clusterConfig.fillWithClusterConfigMap(); err != nil {
-		return nil, err
+	mngr, err := GetManagerObject(client)
+	if err != nil && !errors.IsNotFound(err) {
+		clusterInfoLog.Info("Failed to get manager for cluster config", "err", err)
+	}
+	if err == nil && mngr.Spec.CommonConfiguration.ClusterConfig != nil {
+		resultConfig.replaceFields(*mngr.Spec.CommonConfiguration.ClusterConfig)
+		sources = append(sources, "manager")
 	}
 
-	resultConfig := defaultConfig.replaceFields(kubeadmConfig).
-		replaceFields(clusterConfig)
+	// addresses of pods are allocated from the first subnet first
+	if podSubnet := strings.Split(resultConfig.Networking.PodSubnet, ",")[0]; podSubnet != "" {
+		if ip, _, err := net.ParseCIDR(strings.TrimSpace(podSubnet)); err == nil {
+			if err = CheckPrimaryPodIP(ip.String()); err != nil {
+				return nil, nil, fmt.Errorf("pod subnet %s: %w", podSubnet, err)
+			}
+		}
+	}
 
 	if resultConfig.ControlPlaneEndpoint == "" {
 		resultConfig.ControlPlaneEndpoint = fmt.Sprintf("api.%v.%v:%v",
@@ -238,7 +278,7 @@ func ClusterParameters(client client.Client) (*KubernetesClusterConfig, error) {
 		)
 	}
 
-	return resultConfig, nil
+	return &resultConfig, sources, nil
 }
 
 // KubernetesAPISSLPort gathers SSL Port from Kubernetes Cluster via kubeadm-config ConfigMap
@@ -0,0 +1,197 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tungstenfabric/tf-operator/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ClusterInfoProvider discovers parameters of the kubernetes cluster.
+// Discover returns partially filled config, empty fields are not known to the provider.
+// Provider returns error only if the source exists but can't be read.
+type ClusterInfoProvider interface {
+	Name() string
+	Discover(client client.Client) (*KubernetesClusterConfig, error)
+}
+
+// ClusterInfoProviders are used by ClusterParameters in the order,
+// values of the later providers replace values of the former ones.
+// Generic providers go first, so the configs of kubeadm and OpenShift take precedence.
+var ClusterInfoProviders = []ClusterInfoProvider{
+	&kubernetesServiceProvider{},
+	&kubeletConfigProvider{},
+	&kubeProxyConfigProvider{},
+	&controllerManagerProvider{},
+	&kubeadmConfigProvider{},
+	&openshiftConfigProvider{},
+}
+
+type kubeadmConfigProvider struct{}
+
+func (p *kubeadmConfigProvider) Name() string { return "kubeadm-config" }
+
+func (p *kubeadmConfigProvider) Discover(client client.Client) (*KubernetesClusterConfig, error) {
+	var c KubernetesClusterConfig
+	if err := c.fillWithKubeadmConfigMap(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type openshiftConfigProvider struct{}
+
+func (p *openshiftConfigProvider) Name() string { return "cluster-config-v1" }
+
+func (p *openshiftConfigProvider) Discover(client client.Client) (*KubernetesClusterConfig, error) {
+	var c KubernetesClusterConfig
+	if err := c.fillWithClusterConfigMap(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// kubeProxyConfigProvider takes pod subnets from clusterCIDR of the config of kube-proxy
+type kubeProxyConfigProvider struct{}
+
+func (p *kubeProxyConfigProvider) Name() string { return "kube-proxy" }
+
+func (p *kubeProxyConfigProvider) Discover(client client.Client) (*KubernetesClusterConfig, error) {
+	configMap, err := getConfigMapFromOtherNamespace("kube-proxy", "kube-system")
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var config struct {
+		ClusterCIDR string `json:"clusterCIDR"`
+	}
+	if err := yaml.Unmarshal([]byte(configMap.Data["config.conf"]), &config); err != nil {
+		clusterInfoLog.Info("Failed to parse kube-proxy config", "err", err)
+		return nil, nil
+	}
+	if config.ClusterCIDR == "" {
+		return nil, nil
+	}
+	return &KubernetesClusterConfig{
+		Networking: KubernetesClusterNetworking{PodSubnet: config.ClusterCIDR},
+	}, nil
+}
+
+var getControllerManagerPods = func() (*corev1.PodList, error) {
+	return k8s.GetCoreV1().Pods("kube-system").List(context.TODO(), metav1.ListOptions{LabelSelector: "component=kube-controller-manager"})
+}
+
+// controllerManagerProvider takes pod subnets from --cluster-cidr of static pods of kube-controller-manager
+type controllerManagerProvider struct{}
+
+func (p *controllerManagerProvider) Name() string { return "kube-controller-manager" }
+
+func (p *controllerManagerProvider) Discover(client client.Client) (*KubernetesClusterConfig, error) {
+	pods, err := getControllerManagerPods()
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			clusterInfoLog.Info("Failed to get kube-controller-manager pods", "err", err)
+		}
+		return nil, nil
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			for _, arg := range append(container.Command, container.Args...) {
+				if cidr := strings.TrimPrefix(arg, "--cluster-cidr="); cidr != arg && cidr != "" {
+					return &KubernetesClusterConfig{
+						Networking: KubernetesClusterNetworking{PodSubnet: cidr},
+					}, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+var getKubernetesEndpoints = func() (*corev1.Endpoints, error) {
+	return k8s.GetCoreV1().Endpoints("default").Get(context.TODO(), "kubernetes", metav1.GetOptions{})
+}
+
+// kubernetesServiceProvider takes API endpoint from endpoints of the kubernetes service,
+// that are addresses of API servers themselves, not of a load balancer.
+type kubernetesServiceProvider struct{}
+
+func (p *kubernetesServiceProvider) Name() string { return "kubernetes-service" }
+
+func (p *kubernetesServiceProvider) Discover(client client.Client) (*KubernetesClusterConfig, error) {
+	endpoints, err := getKubernetesEndpoints()
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			clusterInfoLog.Info("Failed to get endpoints of kubernetes service", "err", err)
+		}
+		return nil, nil
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) == 0 {
+			continue
+		}
+		for _, port := range subset.Ports {
+			if port.Name != "https" {
+				continue
+			}
+			return &KubernetesClusterConfig{
+				ControlPlaneEndpoint: net.JoinHostPort(subset.Addresses[0].IP, strconv.Itoa(int(port.Port))),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+var getKubeletConfig = func(nodeName string) ([]byte, error) {
+	restClient, ok := k8s.GetCoreV1().RESTClient().(*rest.RESTClient)
+	if !ok || restClient == nil {
+		return nil, fmt.Errorf("rest client is not available")
+	}
+	return restClient.Get().AbsPath("/api/v1/nodes", nodeName, "proxy", "configz").DoRaw(context.TODO())
+}
+
+// kubeletConfigProvider takes DNS domain from the running config of kubelet
+type kubeletConfigProvider struct{}
+
+func (p *kubeletConfigProvider) Name() string { return "kubelet-config" }
+
+func (p *kubeletConfigProvider) Discover(clnt client.Client) (*KubernetesClusterConfig, error) {
+	nodes := &corev1.NodeList{}
+	if err := clnt.List(context.TODO(), nodes); err != nil || len(nodes.Items) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+	data, err := getKubeletConfig(nodes.Items[0].Name)
+	if err != nil {
+		clusterInfoLog.Info("Failed to get kubelet config", "node", nodes.Items[0].Name, "err", err)
+		return nil, nil
+	}
+	var configz struct {
+		KubeletConfig struct {
+			ClusterDomain string `json:"clusterDomain"`
+		} `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(data, &configz); err != nil {
+		clusterInfoLog.Info("Failed to parse kubelet config", "err", err)
+		return nil, nil
+	}
+	if configz.KubeletConfig.ClusterDomain == "" {
+		return nil, nil
+	}
+	return &KubernetesClusterConfig{
+		Networking: KubernetesClusterNetworking{DNSDomain: configz.KubeletConfig.ClusterDomain},
+	}, nil
+}
@@ -1,7 +1,9 @@
 package v1alpha1
 
 import (
+	"context"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,6 +13,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configtemplates "github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1/templates"
 )
 
 var clusterInfoTestKubeadmConfigMap = corev1.ConfigMap{
@@ -96,6 +100,28 @@ var clusterInfoTestManager = Manager{
 	},
 }
 
+// stubAPIDiscovery makes providers which request k8s api directly find nothing,
+// returns the function restoring them
+func stubAPIDiscovery() func() {
+	oldKubernetesEndpoints := getKubernetesEndpoints
+	oldKubeletConfig := getKubeletConfig
+	oldControllerManagerPods := getControllerManagerPods
+	getKubernetesEndpoints = func() (*corev1.Endpoints, error) {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "corev1", Resource: "endpoints"}, "kubernetes")
+	}
+	getKubeletConfig = func(nodeName string) ([]byte, error) {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "corev1", Resource: "nodes"}, nodeName)
+	}
+	getControllerManagerPods = func() (*corev1.PodList, error) {
+		return &corev1.PodList{}, nil
+	}
+	return func() {
+		getKubernetesEndpoints = oldKubernetesEndpoints
+		getKubeletConfig = oldKubeletConfig
+		getControllerManagerPods = oldControllerManagerPods
+	}
+}
+
 func TestClusterParametersKubeadmExists(t *testing.T) {
 	// Mock functions using k8s api requests
 	oldConfigMapFromOtherNamespace := getConfigMapFromOtherNamespace
@@ -107,6 +133,7 @@ func TestClusterParametersKubeadmExists(t *testing.T) {
 		}
 	}
 	defer func() { getConfigMapFromOtherNamespace = oldConfigMapFromOtherNamespace }()
+	defer stubAPIDiscovery()()
 
 	// Create fake schema, client and our objects
 	scheme, err := SchemeBuilder.Build()
@@ -116,8 +143,8 @@ func TestClusterParametersKubeadmExists(t *testing.T) {
 	client := fake.NewFakeClientWithScheme(scheme, &clusterInfoTestManager)
 
 	// Test
-	clusterParameters, err := ClusterParameters(client)
-	require.NoError(t, err, "ClusterParameters ends with error")
+	clusterParameters, _, err := ResolveClusterParameters(client)
+	require.NoError(t, err, "ResolveClusterParameters ends with error")
 
 	// Fields from manager
 	assert.Equal(t, "kubernetes", clusterParameters.ClusterName)
@@ -138,8 +165,8 @@ func TestClusterParametersKubeadmExists(t *testing.T) {
 	}()
 
 	clusterInfoTestKubeadmConfigMap.Data["ClusterConfiguration"] = `controlPlaneEndpoint: my-control-plane-endpoint`
-	clusterParameters, err = ClusterParameters(client)
-	require.NoError(t, err, "ClusterParameters for Endpoints Priority ends with error")
+	clusterParameters, _, err = ResolveClusterParameters(client)
+	require.NoError(t, err, "ResolveClusterParameters for Endpoints Priority ends with error")
 
 	assert.Equal(t, "my-control-plane-endpoint", clusterParameters.ControlPlaneEndpoint)
 }
@@ -155,6 +182,7 @@ func TestClusterParametersClusterConfigExists(t *testing.T) {
 		}
 	}
 	defer func() { getConfigMapFromOtherNamespace = oldConfigMapFromOtherNamespace }()
+	defer stubAPIDiscovery()()
 
 	// Create fake schema, client and our objects
 	scheme, err := SchemeBuilder.Build()
@@ -164,8 +192,8 @@ func TestClusterParametersClusterConfigExists(t *testing.T) {
 	client := fake.NewFakeClientWithScheme(scheme, &clusterInfoTestManager)
 
 	// Test
-	clusterParameters, err := ClusterParameters(client)
-	require.NoError(t, err, "ClusterParameters ends with error")
+	clusterParameters, _, err := ResolveClusterParameters(client)
+	require.NoError(t, err, "ResolveClusterParameters ends with error")
 
 	// Fields from manager
 	assert.Equal(t, OpenShiftClusterName, clusterParameters.ClusterName)
@@ -179,3 +207,138 @@ func TestClusterParametersClusterConfigExists(t *testing.T) {
 
 	assert.Equal(t, "api."+OpenShiftClusterName+".test.example.com:"+strconv.Itoa(KubernetesApiSSLPort), clusterParameters.ControlPlaneEndpoint)
 }
+
+func TestClusterParametersDualStack(t *testing.T) {
+	dualStackConfigMap := clusterInfoTestClusterConfigConfigMap.DeepCopy()
+	dualStackConfigMap.Data["install-config"] = strings.NewReplacer(
+		"  - cidr: 10.128.0.0/14\n    hostPrefix: 23\n",
+		"  - cidr: 10.128.0.0/14\n    hostPrefix: 23\n  - cidr: fd01::/48\n    hostPrefix: 64\n",
+		"  - 172.30.0.0/16\n",
+		"  - 172.30.0.0/16\n  - fd02::/112\n",
+	).Replace(dualStackConfigMap.Data["install-config"])
+
+	oldConfigMapFromOtherNamespace := getConfigMapFromOtherNamespace
+	getConfigMapFromOtherNamespace = func(name string, namespace string) (*corev1.ConfigMap, error) {
+		if name == "cluster-config-v1" && namespace == "kube-system" {
+			return dualStackConfigMap, nil
+		}
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "corev1", Resource: "configmap"}, name)
+	}
+	defer func() { getConfigMapFromOtherNamespace = oldConfigMapFromOtherNamespace }()
+	defer stubAPIDiscovery()()
+
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	client := fake.NewFakeClientWithScheme(scheme, &clusterInfoTestManager)
+
+	clusterParameters, _, err := ResolveClusterParameters(client)
+	require.NoError(t, err, "ResolveClusterParameters ends with error")
+	assert.Equal(t, "10.128.0.0/14,fd01::/48", clusterParameters.Networking.PodSubnet)
+	assert.Equal(t, "172.30.0.0/16,fd02::/112", clusterParameters.Networking.ServiceSubnet)
+	assert.Equal(t, "10.128.0.0/14 fd01::/48", configtemplates.SubnetList(clusterParameters.Networking.PodSubnet))
+
+	assert.Equal(t, []string{"10.0.0.1:8082", "[fd00::1]:8082"}, configtemplates.EndpointList([]string{"10.0.0.1", "fd00::1"}, 8082))
+
+	dualStackConfigMap.Data["install-config"] = strings.Replace(dualStackConfigMap.Data["install-config"],
+		"  - cidr: 10.128.0.0/14\n    hostPrefix: 23\n  - cidr: fd01::/48\n    hostPrefix: 64\n",
+		"  - cidr: fd01::/48\n    hostPrefix: 64\n  - cidr: 10.128.0.0/14\n    hostPrefix: 23\n", 1)
+	_, _, err = ResolveClusterParameters(client)
+	assert.Error(t, err, "IPv6-primary cluster is not supported")
+}
+
+func TestClusterParametersProviders(t *testing.T) {
+	oldConfigMapFromOtherNamespace := getConfigMapFromOtherNamespace
+	getConfigMapFromOtherNamespace = func(name string, namespace string) (*corev1.ConfigMap, error) {
+		if name == "kube-proxy" && namespace == "kube-system" {
+			return &corev1.ConfigMap{Data: map[string]string{
+				"config.conf": "apiVersion: kubeproxy.config.k8s.io/v1alpha1\nclusterCIDR: 10.42.0.0/16,fd42::/48\nmode: iptables\n",
+			}}, nil
+		}
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "corev1", Resource: "configmap"}, name)
+	}
+	defer func() { getConfigMapFromOtherNamespace = oldConfigMapFromOtherNamespace }()
+	defer stubAPIDiscovery()()
+	getKubernetesEndpoints = func() (*corev1.Endpoints, error) {
+		return &corev1.Endpoints{Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.10"}},
+			Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443}},
+		}}}, nil
+	}
+	getKubeletConfig = func(nodeName string) ([]byte, error) {
+		return []byte(`{"kubeletconfig":{"clusterDomain":"k3s.local"}}`), nil
+	}
+
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	manager := clusterInfoTestManager.DeepCopy()
+	client := fake.NewFakeClientWithScheme(scheme, manager,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Spec: corev1.NodeSpec{PodCIDRs: []string{"10.42.0.0/24", "fd42::/64"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Spec: corev1.NodeSpec{PodCIDRs: []string{"10.42.3.0/24", "fd42:0:0:1::/64"}}},
+	)
+
+	clusterParameters, sources, err := ResolveClusterParameters(client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes-service", "kubelet-config", "kube-proxy"}, sources)
+	assert.Equal(t, "10.42.0.0/16,fd42::/48", clusterParameters.Networking.PodSubnet, "podCIDRs of nodes are not the pod subnet")
+	assert.Equal(t, "k3s.local", clusterParameters.Networking.DNSDomain)
+	assert.Equal(t, "10.0.0.10:6443", clusterParameters.ControlPlaneEndpoint)
+
+	// manager overrides discovered values
+	manager.Spec.CommonConfiguration.ClusterConfig = &KubernetesClusterConfig{
+		Networking: KubernetesClusterNetworking{PodSubnet: "10.100.0.0/16"},
+	}
+	require.NoError(t, client.Update(context.Background(), manager))
+	clusterParameters, sources, err = ResolveClusterParameters(client)
+	require.NoError(t, err)
+	assert.Equal(t, "manager", sources[len(sources)-1])
+	assert.Equal(t, "10.100.0.0/16", clusterParameters.Networking.PodSubnet)
+	assert.Equal(t, "k3s.local", clusterParameters.Networking.DNSDomain)
+}
+
+func TestClusterParametersControllerManager(t *testing.T) {
+	defer stubAPIDiscovery()()
+	getControllerManagerPods = func() (*corev1.PodList, error) {
+		return &corev1.PodList{Items: []corev1.Pod{{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:    "kube-controller-manager",
+			Command: []string{"kube-controller-manager", "--allocate-node-cidrs=true", "--cluster-cidr=10.244.0.0/16"},
+		}}}}}}, nil
+	}
+	discovered, err := (&controllerManagerProvider{}).Discover(nil)
+	require.NoError(t, err)
+	require.NotNil(t, discovered)
+	assert.Equal(t, "10.244.0.0/16", discovered.Networking.PodSubnet)
+
+	getControllerManagerPods = func() (*corev1.PodList, error) {
+		return &corev1.PodList{}, nil
+	}
+	discovered, err = (&controllerManagerProvider{}).Discover(nil)
+	require.NoError(t, err)
+	assert.Nil(t, discovered, "managed control plane has no static pods")
+}
+
+func TestClusterParametersFromManagerStatus(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	manager := clusterInfoTestManager.DeepCopy()
+	client := fake.NewFakeClientWithScheme(scheme, manager, &clusterInfoTestKubeadmConfigMap)
+
+	_, err = ClusterParameters(client)
+	assert.Error(t, err, "cluster parameters are not resolved by manager yet")
+
+	manager.Status.ClusterConfig = &KubernetesClusterConfig{
+		ClusterName: "resolved",
+		Networking:  KubernetesClusterNetworking{PodSubnet: "10.100.0.0/16"},
+	}
+	require.NoError(t, client.Update(context.Background(), manager))
+	clusterParameters, err := ClusterParameters(client)
+	require.NoError(t, err)
+	assert.Equal(t, "resolved", clusterParameters.ClusterName)
+	assert.Equal(t, "10.100.0.0/16", clusterParameters.Networking.PodSubnet)
+}
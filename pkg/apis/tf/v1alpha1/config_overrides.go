@@ -0,0 +1,140 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigFileOverrides are values of INI file: section -> key -> value
+type ConfigFileOverrides map[string]ConfigSectionOverrides
+
+// ConfigSectionOverrides are values of INI section: key -> value
+type ConfigSectionOverrides map[string]string
+
+// configOverrideFiles are the INI files of services which values can be overridden,
+// names are keys of the service configmap without pod IP suffix
+var configOverrideFiles = map[string][]string{
+	"analytics":      {"analyticsapi", "collector", "analytics-nodemgr.conf", "contrail-keystone-auth.conf", "vnc_api_lib.ini"},
+	"analyticsalarm": {"tf-alarm-gen", "analytics-alarm-nodemgr.conf", "vnc_api_lib.ini"},
+	"analyticssnmp":  {"tf-snmp-collector", "tf-topology", "analytics-snmp-nodemgr.conf", "vnc_api_lib.ini"},
+	"cassandra":      {"database-nodemgr.conf", "config-database-nodemgr.conf", "vnc_api_lib.ini"},
+	"config": {"api", "devicemanager", "schematransformer", "servicemonitor", "contrail-fabric-ansible.conf",
+		"contrail-keystone-auth.conf", "config-nodemgr.conf", "vnc_api_lib.ini"},
+	"control":     {"control", "dns", "control-nodemgr.conf", "vnc_api_lib.ini"},
+	"kubemanager": {"kubemanager", "vnc_api_lib.ini"},
+	"queryengine": {"queryengine", "vnc_api_lib.ini"},
+	"vrouter":     {"contrail-vrouter-agent.conf", "contrail-lbaas.auth.conf", "vrouter-nodemgr.conf", "vnc_api_lib.ini"},
+}
+
+// ValidateConfigOverrides checks that overrides refer to known INI files of the service
+func ValidateConfigOverrides(instanceType string, overrides map[string]ConfigFileOverrides) error {
+	for file := range overrides {
+		known := false
+		for _, f := range configOverrideFiles[instanceType] {
+			if f == file {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown file %q in configOverrides of %s, known files are: %s",
+				file, instanceType, strings.Join(configOverrideFiles[instanceType], ", "))
+		}
+	}
+	return nil
+}
+
+// ApplyConfigOverrides merges overrides into the rendered files of the service configmap.
+// The file of overrides applies to the key with the same name and to keys with pod IP suffix.
+func ApplyConfigOverrides(instanceType string, overrides map[string]ConfigFileOverrides, data map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	if err := ValidateConfigOverrides(instanceType, overrides); err != nil {
+		return err
+	}
+	for key := range data {
+		for file, sections := range overrides {
+			if key == file || strings.HasPrefix(key, file+".") {
+				data[key] = mergeINI(data[key], sections)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m ConfigSectionOverrides) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeINI sets values of keys in sections of INI content,
+// keys absent in the content are added to the end of section,
+// absent sections are added to the end of content
+func mergeINI(content string, sections ConfigFileOverrides) string {
+	lines := strings.Split(content, "\n")
+	sectionNames := make([]string, 0, len(sections))
+	for s := range sections {
+		sectionNames = append(sectionNames, s)
+	}
+	sort.Strings(sectionNames)
+
+	for _, section := range sectionNames {
+		values := sections[section]
+		start, end := -1, len(lines)
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+				continue
+			}
+			if start >= 0 {
+				end = i
+				break
+			}
+			if strings.TrimSpace(trimmed[1:len(trimmed)-1]) == section {
+				start = i
+			}
+		}
+		if start < 0 {
+			if len(lines) > 0 && lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			lines = append(lines, "", "["+section+"]")
+			for _, k := range sortedKeys(values) {
+				lines = append(lines, k+"="+values[k])
+			}
+			lines = append(lines, "")
+			continue
+		}
+
+		var added []string
+		for _, k := range sortedKeys(values) {
+			found := false
+			for i := start + 1; i < end; i++ {
+				trimmed := strings.TrimSpace(lines[i])
+				if idx := strings.Index(trimmed, "="); idx > 0 && strings.TrimSpace(trimmed[:idx]) == k {
+					lines[i] = k + "=" + values[k]
+					found = true
+				}
+			}
+			if !found {
+				added = append(added, k+"="+values[k])
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+		// insert after the last not empty line of the section
+		pos := end
+		for pos > start+1 && strings.TrimSpace(lines[pos-1]) == "" {
+			pos--
+		}
+		lines = append(lines[:pos], append(added, lines[pos:]...)...)
+	}
+	return strings.Join(lines, "\n")
+}
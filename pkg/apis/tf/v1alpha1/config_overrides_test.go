@@ -0,0 +1,62 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var renderedControlConfig = `[DEFAULT]
+hostip=1.1.1.1
+log_files_count=10
+# log_file_size=1048576
+
+[SANDESH]
+introspect_ssl_enable=True
+`
+
+func TestMergeINI(t *testing.T) {
+	merged := mergeINI(renderedControlConfig, ConfigFileOverrides{
+		"DEFAULT":  {"log_files_count": "20", "gr_helper_bgp_disable": "1"},
+		"SANDESH":  {"sandesh_send_rate_limit": "100"},
+		"NEW_SECT": {"key": "value"},
+	})
+	assert.Equal(t, `[DEFAULT]
+hostip=1.1.1.1
+log_files_count=20
+# log_file_size=1048576
+gr_helper_bgp_disable=1
+
+[SANDESH]
+introspect_ssl_enable=True
+sandesh_send_rate_limit=100
+
+[NEW_SECT]
+key=value
+`, merged)
+	// merge is idempotent
+	assert.Equal(t, merged, mergeINI(merged, ConfigFileOverrides{"DEFAULT": {"log_files_count": "20", "gr_helper_bgp_disable": "1"}}))
+}
+
+func TestApplyConfigOverrides(t *testing.T) {
+	data := map[string]string{
+		"control.1.1.1.1":              renderedControlConfig,
+		"control.2.2.2.2":              renderedControlConfig,
+		"control-nodemgr.conf.1.1.1.1": renderedControlConfig,
+		"run-control.sh":               "exec contrail-control",
+	}
+	overrides := map[string]ConfigFileOverrides{
+		"control": {"DEFAULT": {"log_files_count": "20"}},
+	}
+	require.NoError(t, ApplyConfigOverrides("control", overrides, data))
+	assert.Contains(t, data["control.1.1.1.1"], "log_files_count=20")
+	assert.Contains(t, data["control.2.2.2.2"], "log_files_count=20")
+	assert.Equal(t, renderedControlConfig, data["control-nodemgr.conf.1.1.1.1"])
+	assert.Equal(t, "exec contrail-control", data["run-control.sh"])
+
+	err := ApplyConfigOverrides("control", map[string]ConfigFileOverrides{"run-control.sh": {"DEFAULT": {"a": "b"}}}, data)
+	assert.Error(t, err)
+	assert.Error(t, ValidateConfigOverrides("webui", overrides))
+	assert.NoError(t, ValidateConfigOverrides("vrouter", map[string]ConfigFileOverrides{"contrail-vrouter-agent.conf": nil}))
+}
@@ -0,0 +1,207 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigSecretChecksumAnnotation is set on configmaps to the checksum of data of the companion secret,
+// so the change of credentials is detected by comparing configmaps.
+const ConfigSecretChecksumAnnotation = "tf.tungsten.io/secret-checksum"
+
+// sensitiveConfigFiles are prefixes of names of files with passwords, keys or tokens rendered by services,
+// the files are kept in a secret whatever their content is. The keystone password is rendered into
+// vnc_api_lib.ini and provisioner env files of every service.
+var sensitiveConfigFiles = map[string][]string{
+	"analytics":      {"analyticsapi.", "collector.", "contrail-keystone-auth.conf.", "vnc_api_lib.ini.", "analytics-provisioner.env"},
+	"analyticsalarm": {"tf-alarm-gen.", "kafka.config.", "vnc_api_lib.ini.", "analyticsalarm-provisioner.env"},
+	"analyticssnmp":  {"tf-snmp-collector.", "tf-topology.", "vnc_api_lib.ini.", "analyticssnmp-provisioner.env"},
+	"cassandra": {"run-cassandra.sh", "cassandra.", "cqlshrc.", "jmxremote.password.", "nodetool-ssl.properties.", "reaper.",
+		"vnc_api_lib.ini.", "cassandra-provisioner.env"},
+	"config": {"api.", "devicemanager.", "contrail-keystone-auth.conf.", "schematransformer.", "servicemonitor.",
+		"deprovision.py.", "vnc_api_lib.ini.", "config-provisioner.env"},
+	"control":     {"control.", "named.", "dns.", "contrail-rndc.conf", "deprovision.py.", "vnc_api_lib.ini.", "control-provisioner.env"},
+	"kubemanager": {"kubemanager.", "vnc_api_lib.ini.", remoteClusterCAKey, remoteClusterCertKey, remoteClusterKeyKey},
+	"queryengine": {"queryengine.", "vnc_api_lib.ini."},
+	"rabbitmq":    {"definitions.json", "rabbitmq-common.env"},
+	"redis":       {},
+	"vrouter": {"params.env.", "contrail-vrouter-agent.conf.", "contrail-lbaas.auth.conf.", "vnc_api_lib.ini.",
+		"vrouter-provisioner.env"},
+	"webui":     {"config.global.js.", "contrail-webui-userauth.js.", "webui-provisioner.env"},
+	"zookeeper": {},
+}
+
+// sensitiveConfigRegexp matches files with passwords of configmaps of services missing in sensitiveConfigFiles
+var sensitiveConfigRegexp = regexp.MustCompile(`(?i)(password|passwd|secret)`)
+
+// isSensitiveConfig returns true if the rendered file of the service must be kept in a secret
+func isSensitiveConfig(instanceType, key, content string) bool {
+	prefixes, ok := sensitiveConfigFiles[instanceType]
+	if !ok {
+		return sensitiveConfigRegexp.MatchString(content)
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigSecretName returns name of the secret which keeps sensitive files of the configmap.
+// The secret is mounted together with the configmap into the same volume.
+func ConfigSecretName(configMapName string) string {
+	return configMapName + "-secret"
+}
+
+func configSecretChecksum(data map[string][]byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(data[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// plainConfigData returns data of configmap without sensitive files,
+// the service is taken from the tf_manager label set by CreateConfigMap
+func plainConfigData(configMap *corev1.ConfigMap) map[string]string {
+	instanceType := configMap.GetLabels()["tf_manager"]
+	data := make(map[string]string)
+	for k, v := range configMap.Data {
+		if !isSensitiveConfig(instanceType, k, v) {
+			data[k] = v
+		}
+	}
+	return data
+}
+
+// GetConfigMap returns config map with data of the companion secret merged,
+// so callers work with the whole set of rendered files.
+func GetConfigMap(name, ns string, client client.Client) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, configMap); err != nil {
+		return configMap, err
+	}
+	secret := &corev1.Secret{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: ConfigSecretName(name), Namespace: ns}, secret)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return configMap, nil
+		}
+		return configMap, err
+	}
+	if len(secret.Data) > 0 && configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	for k, v := range secret.Data {
+		configMap.Data[k] = string(v)
+	}
+	return configMap, nil
+}
+
+// saveConfigMap creates or updates config map, files with credentials are stored
+// in the companion secret instead of the configmap.
+// The data of configMap is left untouched, only metadata is updated from the server.
+func saveConfigMap(configMap *corev1.ConfigMap, create bool, clnt client.Client) error {
+	plain := configMap.DeepCopy()
+	plain.Data = plainConfigData(configMap)
+	secretData := make(map[string][]byte)
+	for k, v := range configMap.Data {
+		if _, ok := plain.Data[k]; !ok {
+			secretData[k] = []byte(v)
+		}
+	}
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Name: ConfigSecretName(configMap.Name), Namespace: configMap.Namespace}
+	err := clnt.Get(context.TODO(), secretName, secret)
+	switch {
+	case k8serrors.IsNotFound(err):
+		if len(secretData) > 0 {
+			secret.SetName(secretName.Name)
+			secret.SetNamespace(secretName.Namespace)
+			secret.SetLabels(configMap.GetLabels())
+			secret.SetOwnerReferences(configMap.GetOwnerReferences())
+			secret.Data = secretData
+			if err = clnt.Create(context.TODO(), secret); err != nil && !k8serrors.IsAlreadyExists(err) {
+				return err
+			}
+		}
+	case err != nil:
+		return err
+	case !reflect.DeepEqual(secret.Data, secretData) && !(len(secret.Data) == 0 && len(secretData) == 0):
+		secret.Data = secretData
+		if err = clnt.Update(context.TODO(), secret); err != nil {
+			return err
+		}
+	}
+
+	annotations := plain.GetAnnotations()
+	if checksum := configSecretChecksum(secretData); checksum != "" {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[ConfigSecretChecksumAnnotation] = checksum
+	} else {
+		delete(annotations, ConfigSecretChecksumAnnotation)
+	}
+	plain.SetAnnotations(annotations)
+
+	if create {
+		err = clnt.Create(context.TODO(), plain)
+	} else {
+		err = clnt.Update(context.TODO(), plain)
+	}
+	if err != nil {
+		return err
+	}
+	plain.ObjectMeta.DeepCopyInto(&configMap.ObjectMeta)
+	return nil
+}
+
+// configVolumeSource returns source of volume with files of configmap and of its companion secret
+func configVolumeSource(configMapName string) corev1.VolumeSource {
+	optional := true
+	return corev1.VolumeSource{
+		Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{
+				{
+					ConfigMap: &corev1.ConfigMapProjection{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: configMapName,
+						},
+					},
+				},
+				{
+					Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: ConfigSecretName(configMapName),
+						},
+						Optional: &optional,
+					},
+				},
+			},
+		},
+	}
+}
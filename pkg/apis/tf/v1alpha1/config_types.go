@@ -79,6 +79,7 @@ type ConfigConfiguration struct {
 	BgpAutoMesh                 *bool                   `json:"bgpAutoMesh,omitempty"`
 	BgpEnable4Byte              *bool                   `json:"bgpEnable4Byte,omitempty"`
 	GlobalASNNumber             *int                    `json:"globalASNNumber,omitempty"`
+	Storage                     *Storage                `json:"storage,omitempty"`
 }
 
 // LinklocalServiceConfig is the Spec for link local coniguration
@@ -95,7 +96,8 @@ type LinklocalServiceConfig struct {
 // +k8s:openapi-gen=true
 type ConfigStatus struct {
 	CommonStatus `json:",inline"`
-	Endpoint     string `json:"endpoint,omitempty"`
+	Endpoint     string         `json:"endpoint,omitempty"`
+	Storage      *StorageStatus `json:"storage,omitempty"`
 }
 
 // ConfigList contains a list of Config.
@@ -498,12 +500,35 @@ func (c *Config) InstanceConfiguration(podList []corev1.Pod, client client.Clien
 		data["config-nodemgr.conf."+podIP] = configNodemanagerconfigConfigBuffer.String()
 		// empty env as no db tracking
 		data["config-nodemgr.env."+podIP] = ""
+
+		var configDeProvisionBuffer bytes.Buffer
+		// TODO: use auth options from config instead of defaults
+		err = configtemplates.ConfigDeProvisionConfig.Execute(&configDeProvisionBuffer, struct {
+			AdminUsername string
+			AdminPassword string
+			AdminTenant   string
+			APIServerList string
+			APIServerPort string
+			Hostname      string
+		}{
+			AdminUsername: KeystoneAuthAdminUser,
+			AdminPassword: KeystoneAuthAdminPassword,
+			AdminTenant:   KeystoneAuthAdminTenant,
+			APIServerList: configtemplates.JoinListWithSeparatorAndSingleQuotes(nodes, ","),
+			APIServerPort: strconv.Itoa(*configConfig.APIPort),
+			Hostname:      hostname,
+		})
+		if err != nil {
+			panic(err)
+		}
+		data["deprovision.py."+podIP] = configDeProvisionBuffer.String()
 	}
 
 	clusterNodes := ClusterNodes{ConfigNodes: apiServerList}
 	// update with provisioner configs
 	data["config-provisioner.env"] = ProvisionerEnvData(&clusterNodes, "", c.Spec.CommonConfiguration.AuthParameters)
 
+	err = ApplyConfigOverrides("config", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -620,12 +645,18 @@ func (c *Config) SetInstanceActive(client client.Client, activeStatus *bool, deg
 	}
 	*activeStatus = sts.Status.ReadyReplicas >= *sts.Spec.Replicas/2+1
 	*degradedStstus = sts.Status.ReadyReplicas < *sts.Spec.Replicas
+	c.Status.SetStatefulSetConditions(c.Generation, sts)
 	if err := client.Status().Update(context.TODO(), c); err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Config) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // PodIPListAndIPMapFromInstance gets a list with POD IPs and a map of POD names and IPs.
 func (c *Config) PodIPListAndIPMapFromInstance(request reconcile.Request, reconcileClient client.Client) ([]corev1.Pod, map[string]NodeInfo, error) {
 	return PodIPListAndIPMapFromInstance("config", request, reconcileClient, "")
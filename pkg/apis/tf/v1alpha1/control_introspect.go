@@ -0,0 +1,214 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ControlServiceStatus is the state of control-node service of a pod as reported by introspect.
+// +k8s:openapi-gen=true
+type ControlServiceStatus struct {
+	// State is the state of contrail-control process, e.g. Functional
+	State string `json:"state,omitempty"`
+	// BGPPeer is the number of established and total BGP peers
+	BGPPeer BGPPeer `json:"bgpPeer,omitempty"`
+	// NumberOfXMPPPeers is the number of established XMPP sessions with vrouter agents
+	NumberOfXMPPPeers string       `json:"numberOfXMPPPeers,omitempty"`
+	Connections       []Connection `json:"connections,omitempty"`
+}
+
+// IsDegraded returns true if the service is not functional or some BGP peer or connection is down
+func (s *ControlServiceStatus) IsDegraded() bool {
+	if s.State != "Functional" || s.BGPPeer.Up != s.BGPPeer.Number {
+		return true
+	}
+	for _, c := range s.Connections {
+		if c.Status != "Up" {
+			return true
+		}
+	}
+	return false
+}
+
+type introspectBgpNeighbor struct {
+	Peer     string `xml:"peer"`
+	Address  string `xml:"peer_address"`
+	Encoding string `xml:"encoding"`
+	State    string `xml:"state"`
+}
+
+type introspectXmppConnection struct {
+	Name  string `xml:"name"`
+	State string `xml:"state"`
+}
+
+type introspectProcessStatus struct {
+	ModuleID    string `xml:"module_id"`
+	State       string `xml:"state"`
+	Description string `xml:"description"`
+	Connections []struct {
+		Type        string   `xml:"type"`
+		Name        string   `xml:"name"`
+		ServerAddrs []string `xml:"server_addrs>list>element"`
+		Status      string   `xml:"status"`
+	} `xml:"connection_infos>list>ConnectionInfo"`
+}
+
+// decodeIntrospectElements decodes all elements with the name from sandesh xml response,
+// responses can be wrapped into lists and pages, so elements are searched at any level
+func decodeIntrospectElements(data []byte, name string, decode func(d *xml.Decoder, start *xml.StartElement) error) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			if err := decode(d, &start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseControlIntrospect makes service status from responses of
+// ShowBgpNeighborSummaryReq, ShowXmppConnectionReq and NodeStatus UVE
+func parseControlIntrospect(neighbors, xmpp, nodeStatus []byte) (ControlServiceStatus, error) {
+	var status ControlServiceStatus
+
+	bgpUp, bgpTotal := 0, 0
+	err := decodeIntrospectElements(neighbors, "BgpNeighborResp", func(d *xml.Decoder, start *xml.StartElement) error {
+		var n introspectBgpNeighbor
+		if err := d.DecodeElement(&n, start); err != nil {
+			return err
+		}
+		// xmpp peers are also reported as neighbors
+		if n.Encoding != "BGP" {
+			return nil
+		}
+		bgpTotal++
+		if n.State == "Established" {
+			bgpUp++
+		}
+		return nil
+	})
+	if err != nil {
+		return status, err
+	}
+	status.BGPPeer = BGPPeer{Up: strconv.Itoa(bgpUp), Number: strconv.Itoa(bgpTotal)}
+
+	xmppUp := 0
+	err = decodeIntrospectElements(xmpp, "ShowXmppConnection", func(d *xml.Decoder, start *xml.StartElement) error {
+		var c introspectXmppConnection
+		if err := d.DecodeElement(&c, start); err != nil {
+			return err
+		}
+		if c.State == "Established" {
+			xmppUp++
+		}
+		return nil
+	})
+	if err != nil {
+		return status, err
+	}
+	status.NumberOfXMPPPeers = strconv.Itoa(xmppUp)
+
+	err = decodeIntrospectElements(nodeStatus, "ProcessStatus", func(d *xml.Decoder, start *xml.StartElement) error {
+		var p introspectProcessStatus
+		if err := d.DecodeElement(&p, start); err != nil {
+			return err
+		}
+		if p.ModuleID != "contrail-control" {
+			return nil
+		}
+		status.State = p.State
+		for _, c := range p.Connections {
+			status.Connections = append(status.Connections, Connection{
+				Type:   c.Type,
+				Name:   c.Name,
+				Status: c.Status,
+				Nodes:  c.ServerAddrs,
+			})
+		}
+		return nil
+	})
+	return status, err
+}
+
+// controlExec runs commands in containers, it is replaced in unit tests
+var controlExec = ExecToContainer
+
+// introspectResponseEnd is written by curl after each response to split the responses of one call
+const introspectResponseEnd = "\n--- end of introspect response ---\n"
+
+// introspect requests the control-node introspect of the pod on the port of the spec by one curl call,
+// the responses are returned in the order of the requests
+func (c *Control) introspect(pod *corev1.Pod, requests ...string) ([][]byte, error) {
+	config := c.ConfigurationParameters()
+	command := []string{"curl", "-sSk", "--max-time", "10", "-w", introspectResponseEnd}
+	for _, request := range requests {
+		command = append(command, fmt.Sprintf("https://%s/%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(*config.ControlIntrospectPort)), request))
+	}
+	stdout, stderr, err := controlExec(pod, "control", command, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to request introspect %v: %v (stderr=%s)", requests, err, stderr)
+	}
+	parts := strings.Split(stdout, introspectResponseEnd)
+	if len(parts) < len(requests) {
+		return nil, fmt.Errorf("Failed to request introspect %v: %d responses", requests, len(parts)-1)
+	}
+	responses := make([][]byte, len(requests))
+	for i := range requests {
+		responses[i] = []byte(parts[i])
+	}
+	return responses, nil
+}
+
+// ServiceStatusFromIntrospect polls introspect of the pod for the state of BGP peers,
+// XMPP sessions and connections of the control-node
+func (c *Control) ServiceStatusFromIntrospect(pod *corev1.Pod) (ControlServiceStatus, error) {
+	responses, err := c.introspect(pod,
+		"Snh_ShowBgpNeighborSummaryReq?search_string=",
+		"Snh_ShowXmppConnectionReq",
+		"Snh_SandeshUVECacheReq?x=NodeStatus")
+	if err != nil {
+		return ControlServiceStatus{}, err
+	}
+	return parseControlIntrospect(responses[0], responses[1], responses[2])
+}
+
+// UpdateServiceStatus polls introspect of the pods and sets service statuses,
+// pods which introspect is not available are reported with unknown state
+func (c *Control) UpdateServiceStatus(podList []corev1.Pod) {
+	serviceStatus := make(map[string]ControlServiceStatus)
+	for idx := range podList {
+		pod := &podList[idx]
+		status, err := c.ServiceStatusFromIntrospect(pod)
+		if err != nil {
+			log.Info("Failed to get control service status", "pod", pod.Name, "err", err)
+			status = ControlServiceStatus{State: "Unknown"}
+		}
+		serviceStatus[pod.Name] = status
+	}
+	c.Status.ServiceStatus = serviceStatus
+}
+
+// IsServiceDegraded returns true if service of some pod is degraded
+func (c *Control) IsServiceDegraded() bool {
+	for _, s := range c.Status.ServiceStatus {
+		if s.IsDegraded() {
+			return true
+		}
+	}
+	return false
+}
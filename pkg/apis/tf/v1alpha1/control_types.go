@@ -25,6 +25,8 @@ import (
 // Control is the Schema for the controls API.
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.status.active`
+// +kubebuilder:printcolumn:name="Degraded",type=boolean,JSONPath=`.status.degraded`
 type Control struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -49,8 +51,10 @@ type ControlConfiguration struct {
 	XMPPPort          *int         `json:"xmppPort,omitempty"`
 	DNSPort           *int         `json:"dnsPort,omitempty"`
 	DNSIntrospectPort *int         `json:"dnsIntrospectPort,omitempty"`
-	Subcluster        string       `json:"subcluster,omitempty"`
-	RndcKey           string       `json:"rndckey,omitempty"`
+	// ControlIntrospectPort is the introspect port of the control-node, 8083 by default
+	ControlIntrospectPort *int   `json:"controlIntrospectPort,omitempty"`
+	Subcluster            string `json:"subcluster,omitempty"`
+	RndcKey               string `json:"rndckey,omitempty"`
 	// DataSubnet allow to set alternative network in which control, nodemanager
 	// and dns services will listen. Local pod address from this subnet will be
 	// discovered and used both in configuration for hostip directive and provision
@@ -66,6 +70,8 @@ type ControlStatus struct {
 	Ports        ControlStatusPorts `json:"ports,omitempty"`
 	Subcluster   string             `json:"subcluster,omitempty"`
 	ASNNumber    string             `json:"asnNumber,omitempty"`
+	// ServiceStatus is the state of BGP peers, XMPP sessions and connections per pod
+	ServiceStatus map[string]ControlServiceStatus `json:"serviceStatus,omitempty"`
 }
 
 // StaticRoutes statuic routes
@@ -232,6 +238,7 @@ func (c *Control) InstanceConfiguration(podList []corev1.Pod, client client.Clie
 			Hostname                 string
 			ListenAddress            string
 			InstrospectListenAddress string
+			IntrospectPort           string
 			BGPPort                  string
 			ASNNumber                string
 			APIServerList            string
@@ -250,6 +257,7 @@ func (c *Control) InstanceConfiguration(podList []corev1.Pod, client client.Clie
 			Hostname:                 controlHostname,
 			ListenAddress:            podListenAddress,
 			InstrospectListenAddress: instrospectListenAddress,
+			IntrospectPort:           strconv.Itoa(*controlConfig.ControlIntrospectPort),
 			BGPPort:                  strconv.Itoa(*controlConfig.BGPPort),
 			ASNNumber:                strconv.Itoa(*controlConfig.ASNNumber),
 			APIServerList:            configApiIPListSpaceSeparated,
@@ -400,6 +408,7 @@ func (c *Control) InstanceConfiguration(podList []corev1.Pod, client client.Clie
 		data["deprovision.py."+podIP] = controlDeProvisionBuffer.String()
 	}
 
+	err = ApplyConfigOverrides("control", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -479,9 +488,21 @@ func (c *Control) PodIPListAndIPMapFromInstance(instanceType string, request rec
 	return PodIPListAndIPMapFromInstance(instanceType, request, reconcileClient, datanetwork)
 }
 
-// SetInstanceActive sets instance to active.
+// SetInstanceActive sets instance to active, instance is degraded
+// if not all pods are ready or BGP peers or connections of some pod are down.
 func (c *Control) SetInstanceActive(client client.Client, activeStatus *bool, degradedStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: sts.Name, Namespace: request.Namespace}, sts); err != nil {
+		return err
+	}
+	*activeStatus = sts.Status.ReadyReplicas == *sts.Spec.Replicas
+	*degradedStatus = sts.Status.ReadyReplicas < *sts.Spec.Replicas || c.IsServiceDegraded()
+	c.Status.SetStatefulSetConditions(c.Generation, sts)
+	return client.Status().Update(context.TODO(), c)
+}
+
+// GetCommonStatus returns the common status of the instance
+func (c *Control) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
 }
 
 func (c *Control) ManageNodeStatus(nodes map[string]NodeInfo,
@@ -567,6 +588,11 @@ func (c *Control) ConfigurationParameters() ControlConfiguration {
 		dnsIntrospectPort = DnsIntrospectPort
 	}
 
+	controlIntrospectPort := ControlIntrospectPort
+	if c.Spec.ServiceConfiguration.ControlIntrospectPort != nil {
+		controlIntrospectPort = *c.Spec.ServiceConfiguration.ControlIntrospectPort
+	}
+
 	controlConfiguration.DataSubnet = c.Spec.ServiceConfiguration.DataSubnet
 	controlConfiguration.Subcluster = c.Spec.ServiceConfiguration.Subcluster
 	controlConfiguration.ASNNumber = &asnNumber
@@ -574,6 +600,7 @@ func (c *Control) ConfigurationParameters() ControlConfiguration {
 	controlConfiguration.XMPPPort = &xmppPort
 	controlConfiguration.DNSPort = &dnsPort
 	controlConfiguration.DNSIntrospectPort = &dnsIntrospectPort
+	controlConfiguration.ControlIntrospectPort = &controlIntrospectPort
 	controlConfiguration.RndcKey = rndckey
 
 	return controlConfiguration
@@ -0,0 +1,77 @@
+package v1alpha1
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var controlNeighborsResp = `<?xml-stylesheet type="text/xsl" href="/universal_parse.xsl"?>
+<ShowBgpNeighborSummaryResp type="sandesh"><neighbors type="list" identifier="1"><list type="struct" size="3">
+<BgpNeighborResp><peer type="string" identifier="1">node2</peer><peer_address type="string" identifier="2">10.0.0.2</peer_address><encoding type="string" identifier="3">BGP</encoding><state type="string" identifier="4">Established</state></BgpNeighborResp>
+<BgpNeighborResp><peer type="string" identifier="1">node3</peer><peer_address type="string" identifier="2">10.0.0.3</peer_address><encoding type="string" identifier="3">BGP</encoding><state type="string" identifier="4">Active</state></BgpNeighborResp>
+<BgpNeighborResp><peer type="string" identifier="1">compute1</peer><peer_address type="string" identifier="2">10.0.0.10</peer_address><encoding type="string" identifier="3">XMPP</encoding><state type="string" identifier="4">Established</state></BgpNeighborResp>
+</list></neighbors><more type="bool" identifier="0">false</more></ShowBgpNeighborSummaryResp>`
+
+var controlXmppResp = `<ShowXmppConnectionResp type="sandesh"><connections type="list" identifier="1"><list type="struct" size="2">
+<ShowXmppConnection><name type="string" identifier="1">compute1</name><state type="string" identifier="3">Established</state></ShowXmppConnection>
+<ShowXmppConnection><name type="string" identifier="1">compute2</name><state type="string" identifier="3">Idle</state></ShowXmppConnection>
+</list></connections></ShowXmppConnectionResp>`
+
+var controlNodeStatusResp = `<__NodeStatusUVE_list type="slist"><NodeStatusUVE type="sandesh"><data type="struct" identifier="1"><NodeStatus>
+<process_status type="list" identifier="4"><list type="struct" size="1"><ProcessStatus>
+<module_id type="string" identifier="1">contrail-control</module_id><instance_id type="string" identifier="2">0</instance_id><state type="string" identifier="3">Functional</state>
+<connection_infos type="list" identifier="4"><list type="struct" size="2">
+<ConnectionInfo><type type="string" identifier="1">Collector</type><name type="string" identifier="2"></name><server_addrs type="list" identifier="3"><list type="string" size="1"><element>10.0.0.1:8086</element></list></server_addrs><status type="string" identifier="4">Up</status></ConnectionInfo>
+<ConnectionInfo><type type="string" identifier="1">Database</type><name type="string" identifier="2">Cassandra</name><server_addrs type="list" identifier="3"><list type="string" size="2"><element>10.0.0.1:9041</element><element>10.0.0.2:9041</element></list></server_addrs><status type="string" identifier="4">Up</status></ConnectionInfo>
+</list></connection_infos></ProcessStatus></list></process_status>
+</NodeStatus></data></NodeStatusUVE></__NodeStatusUVE_list>`
+
+func TestParseControlIntrospect(t *testing.T) {
+	status, err := parseControlIntrospect([]byte(controlNeighborsResp), []byte(controlXmppResp), []byte(controlNodeStatusResp))
+	require.NoError(t, err)
+	require.Equal(t, "Functional", status.State)
+	require.Equal(t, BGPPeer{Up: "1", Number: "2"}, status.BGPPeer)
+	require.Equal(t, "1", status.NumberOfXMPPPeers)
+	require.Equal(t, []Connection{
+		{Type: "Collector", Status: "Up", Nodes: []string{"10.0.0.1:8086"}},
+		{Type: "Database", Name: "Cassandra", Status: "Up", Nodes: []string{"10.0.0.1:9041", "10.0.0.2:9041"}},
+	}, status.Connections)
+	require.True(t, status.IsDegraded())
+
+	status.BGPPeer.Up = "2"
+	require.False(t, status.IsDegraded())
+	status.Connections[1].Status = "Down"
+	require.True(t, status.IsDegraded())
+}
+
+func TestControlServiceStatusFromIntrospect(t *testing.T) {
+	var calls [][]string
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		controlExec = exec
+	}(controlExec)
+	controlExec = func(pod *corev1.Pod, container string, command []string, stdin io.Reader) (string, string, error) {
+		calls = append(calls, command)
+		return controlNeighborsResp + introspectResponseEnd + controlXmppResp + introspectResponseEnd +
+			controlNodeStatusResp + introspectResponseEnd, "", nil
+	}
+
+	port := 18083
+	control := &Control{}
+	control.Spec.ServiceConfiguration.ControlIntrospectPort = &port
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}
+	status, err := control.ServiceStatusFromIntrospect(pod)
+	require.NoError(t, err)
+	require.Equal(t, "Functional", status.State)
+	require.Equal(t, BGPPeer{Up: "1", Number: "2"}, status.BGPPeer)
+	require.Equal(t, "1", status.NumberOfXMPPPeers)
+
+	require.Len(t, calls, 1, "introspect is requested by one call per pod")
+	require.Equal(t, []string{
+		"https://10.0.0.1:18083/Snh_ShowBgpNeighborSummaryReq?search_string=",
+		"https://10.0.0.1:18083/Snh_ShowXmppConnectionReq",
+		"https://10.0.0.1:18083/Snh_SandeshUVECacheReq?x=NodeStatus",
+	}, calls[0][len(calls[0])-3:], "port of the spec is requested")
+}
@@ -0,0 +1,206 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CredentialsSource is a source of credentials managed outside of the operator,
+// only one of Secret, Vault and File may be set
+// +k8s:openapi-gen=true
+type CredentialsSource struct {
+	Secret *SecretCredentialsSource `json:"secret,omitempty"`
+	Vault  *VaultCredentialsSource  `json:"vault,omitempty"`
+	File   *FileCredentialsSource   `json:"file,omitempty"`
+	// RefreshPeriod is the period in seconds to read credentials again, they are read once if not set
+	// +kubebuilder:validation:Minimum=0
+	RefreshPeriod *int `json:"refreshPeriod,omitempty"`
+}
+
+// SecretCredentialsSource reads credentials from keys of the Secret in the namespace of the resource
+// +k8s:openapi-gen=true
+type SecretCredentialsSource struct {
+	Name string `json:"name"`
+}
+
+// VaultCredentialsSource reads credentials from the KV secrets engine of HashiCorp Vault
+// +k8s:openapi-gen=true
+type VaultCredentialsSource struct {
+	// Address is the URL of Vault server, e.g. http://127.0.0.1:8200
+	Address string `json:"address"`
+	// Path is the API path of the secret without /v1 prefix, e.g. secret/data/tf/rabbitmq for KV version 2
+	Path string `json:"path"`
+	// TokenSecretName is the name of the Secret with the Vault token in the token key
+	TokenSecretName string `json:"tokenSecretName"`
+}
+
+// FileCredentialsSource reads credentials from files of the directory mounted into the operator pod,
+// the name of the file is the key, e.g. the directory of the mounted Secret
+// +k8s:openapi-gen=true
+type FileCredentialsSource struct {
+	Path string `json:"path"`
+}
+
+// CredentialsProvider reads credentials from the source
+type CredentialsProvider interface {
+	Name() string
+	Credentials(namespace string, client client.Client) (map[string][]byte, error)
+}
+
+// Provider returns the provider of the configured source
+func (s *CredentialsSource) Provider() (CredentialsProvider, error) {
+	var providers []CredentialsProvider
+	if s.Secret != nil {
+		providers = append(providers, &secretCredentialsProvider{source: s.Secret})
+	}
+	if s.Vault != nil {
+		providers = append(providers, &vaultCredentialsProvider{source: s.Vault})
+	}
+	if s.File != nil {
+		providers = append(providers, &fileCredentialsProvider{source: s.File})
+	}
+	if len(providers) != 1 {
+		return nil, fmt.Errorf("exactly one of secret, vault and file credentials sources must be set")
+	}
+	return providers[0], nil
+}
+
+// Credentials reads credentials from the source and checks that the keys are present
+func (s *CredentialsSource) Credentials(namespace string, client client.Client, keys ...string) (map[string][]byte, error) {
+	provider, err := s.Provider()
+	if err != nil {
+		return nil, err
+	}
+	data, err := provider.Credentials(namespace, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials from %s: %w", provider.Name(), err)
+	}
+	for _, key := range keys {
+		if len(data[key]) == 0 {
+			return nil, fmt.Errorf("no %s key in credentials of %s", key, provider.Name())
+		}
+	}
+	return data, nil
+}
+
+// RefreshInterval returns the interval to read credentials again, 0 if refresh is disabled
+func (s *CredentialsSource) RefreshInterval() time.Duration {
+	if s == nil || s.RefreshPeriod == nil || *s.RefreshPeriod <= 0 {
+		return 0
+	}
+	return time.Duration(*s.RefreshPeriod) * time.Second
+}
+
+// CredentialsVersion returns the hash of credentials to detect their change
+func CredentialsVersion(data map[string][]byte, keys ...string) string {
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+type secretCredentialsProvider struct {
+	source *SecretCredentialsSource
+}
+
+func (p *secretCredentialsProvider) Name() string { return "secret " + p.source.Name }
+
+func (p *secretCredentialsProvider) Credentials(namespace string, clnt client.Client) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := clnt.Get(context.TODO(), types.NamespacedName{Name: p.source.Name, Namespace: namespace}, secret); err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+type fileCredentialsProvider struct {
+	source *FileCredentialsSource
+}
+
+func (p *fileCredentialsProvider) Name() string { return "file " + p.source.Path }
+
+func (p *fileCredentialsProvider) Credentials(namespace string, clnt client.Client) (map[string][]byte, error) {
+	files, err := ioutil.ReadDir(p.source.Path)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string][]byte)
+	for _, f := range files {
+		// files of mounted secrets are symlinks, hidden entries are internals of the mount
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		value, err := ioutil.ReadFile(filepath.Join(p.source.Path, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		data[f.Name()] = []byte(strings.TrimRight(string(value), "\r\n"))
+	}
+	return data, nil
+}
+
+// vaultHTTPClient is used for requests to Vault
+var vaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+type vaultCredentialsProvider struct {
+	source *VaultCredentialsSource
+}
+
+func (p *vaultCredentialsProvider) Name() string { return "vault " + p.source.Path }
+
+func (p *vaultCredentialsProvider) Credentials(namespace string, clnt client.Client) (map[string][]byte, error) {
+	tokenSecret := &corev1.Secret{}
+	if err := clnt.Get(context.TODO(), types.NamespacedName{Name: p.source.TokenSecretName, Namespace: namespace}, tokenSecret); err != nil {
+		return nil, err
+	}
+	url := strings.TrimRight(p.source.Address, "/") + "/v1/" + strings.TrimLeft(p.source.Path, "/")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", string(tokenSecret.Data["token"]))
+	resp, err := vaultHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded %s", resp.Status)
+	}
+	// KV version 2 wraps the secret into data.data, version 1 returns it in data
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	values := body.Data
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		values = nested
+	}
+	data := make(map[string][]byte)
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			data[key] = []byte(s)
+		}
+	}
+	return data, nil
+}
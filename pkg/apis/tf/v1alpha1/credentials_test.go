@@ -0,0 +1,144 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCredentialsSources(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	vaultPassword := "vault-password"
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.URL.Path != "/v1/secret/data/tf/keystone" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": map[string]string{"password": vaultPassword}},
+		})
+	}))
+	defer vault.Close()
+
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "password"), []byte("file-password\n"), 0600))
+
+	cl := fake.NewFakeClientWithScheme(scheme,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "tf"},
+			Data:       map[string][]byte{"password": []byte("secret-password")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "tf"},
+			Data:       map[string][]byte{"token": []byte("root")},
+		})
+
+	vaultSource := &VaultCredentialsSource{Address: vault.URL, Path: "secret/data/tf/keystone", TokenSecretName: "vault-token"}
+	sources := map[string]*CredentialsSource{
+		"secret-password": {Secret: &SecretCredentialsSource{Name: "keystone"}},
+		"vault-password":  {Vault: vaultSource},
+		"file-password":   {File: &FileCredentialsSource{Path: dir}},
+	}
+	for password, source := range sources {
+		auth := AuthParameters{AuthMode: AuthenticationModeKeystone, KeystoneCredentialsSource: source}
+		require.NoError(t, auth.Prepare("tf", cl), password)
+		assert.Equal(t, password, *auth.KeystoneAuthParameters.AdminPassword)
+	}
+
+	secretName := "keystone"
+	auth := AuthParameters{KeystoneSecretName: &secretName}
+	require.NoError(t, auth.Prepare("tf", cl))
+	assert.Equal(t, "secret-password", *auth.KeystoneAuthParameters.AdminPassword, "legacy secret name is a secret source")
+
+	_, err = (&CredentialsSource{Vault: vaultSource}).Credentials("tf", cl, "password", "user")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no user key")
+
+	badToken := &CredentialsSource{Vault: &VaultCredentialsSource{Address: vault.URL, Path: "secret/data/tf/keystone", TokenSecretName: "keystone"}}
+	_, err = badToken.Credentials("tf", cl)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+
+	version := CredentialsVersion(map[string][]byte{"user": []byte("u"), "password": []byte("p1")}, "user", "password")
+	assert.NotEqual(t, version, CredentialsVersion(map[string][]byte{"user": []byte("u"), "password": []byte("p2")}, "user", "password"))
+}
+
+func TestRabbitmqCredentialsSource(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"user": []byte("tf"), "password": []byte("password1")},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq1-secret", Namespace: "test-ns"}}
+	instance := Rabbitmq{ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq1", Namespace: "test-ns"}}
+	instance.Spec.ServiceConfiguration.Vhost = "tf"
+	instance.Spec.ServiceConfiguration.CredentialsSource = &CredentialsSource{Secret: &SecretCredentialsSource{Name: source.Name}}
+	cl := fake.NewFakeClientWithScheme(scheme, source, secret)
+
+	updated, err := instance.UpdateSecret(secret, cl)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, "tf", string(secret.Data["user"]))
+	assert.Equal(t, "password1", string(secret.Data["password"]))
+	assert.Equal(t, "tf", string(secret.Data["vhost"]), "vhost of the spec is used if the source has no vhost")
+	salted := secret.Data["salted_password"]
+
+	source.Data["password"] = []byte("password2")
+	require.NoError(t, cl.Update(context.TODO(), source))
+	updated, err = instance.UpdateSecret(secret, cl)
+	require.NoError(t, err)
+	assert.True(t, updated, "rotated password is taken from the source")
+	assert.Equal(t, "password2", string(secret.Data["password"]))
+	assert.NotEqual(t, salted, secret.Data["salted_password"])
+}
+
+func TestCredentialsSourceValidation(t *testing.T) {
+	path := field.NewPath("credentialsSource")
+	assert.Empty(t, validateCredentialsSource(nil, path))
+	assert.Empty(t, validateCredentialsSource(&CredentialsSource{File: &FileCredentialsSource{Path: "/etc/tf/keystone"}}, path))
+
+	errs := validateCredentialsSource(&CredentialsSource{
+		Secret: &SecretCredentialsSource{Name: "keystone"},
+		File:   &FileCredentialsSource{Path: "/etc/tf/keystone"},
+	}, path)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs.ToAggregate().Error(), "exactly one of")
+
+	errs = validateCredentialsSource(&CredentialsSource{Vault: &VaultCredentialsSource{Address: "127.0.0.1:8200"}}, path)
+	assert.Contains(t, errs.ToAggregate().Error(), "credentialsSource.vault.address")
+	assert.Contains(t, errs.ToAggregate().Error(), "credentialsSource.vault.path")
+	assert.Contains(t, errs.ToAggregate().Error(), "credentialsSource.vault.tokenSecretName")
+
+	interval := 24
+	rabbitmq := RabbitmqSpec{ServiceConfiguration: RabbitmqConfiguration{
+		CredentialsSource:                &CredentialsSource{Secret: &SecretCredentialsSource{Name: "rabbitmq"}},
+		CredentialsRotationIntervalHours: &interval,
+	}}
+	assert.Contains(t, rabbitmq.validate(field.NewPath("spec")).ToAggregate().Error(), "spec.serviceConfiguration.credentialsRotationIntervalHours")
+
+	m := newValidationManager()
+	m.Spec.CommonConfiguration.AuthParameters.KeystoneCredentialsSource = &CredentialsSource{File: &FileCredentialsSource{Path: "keystone"}}
+	err := m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.authParameters.keystoneCredentialsSource.file.path")
+}
@@ -47,7 +47,7 @@ const (
 	AnalyticsAlarmNodes                         string = ""
 	AlarmgenIntrospectPort                      int    = 5995
 	AlarmgenPartitions                          int    = 30
-	AlarmgenRedisAggregateDbOffset              string = "1"
+	AlarmgenRedisAggregateDbOffset              int    = 1
 	BgpPort                                     int    = 179
 	BgpAutoMesh                                 bool   = true
 	BgpEnable4Byte                              bool   = false
@@ -60,6 +60,9 @@ const (
 	CollectorProtobufPort                       int    = 3333
 	CollectorStructuredSyslogPort               int    = 3514
 	SnmpcollectorIntrospectPort                 int    = 5920
+	SnmpcollectorScanFrequency                  int    = 600
+	SnmpcollectorFastScanFrequency              int    = 60
+	TopologyScanFrequency                       int    = 600
 	CollectorServers                            string = ""
 	CassandraPort                               int    = 9161
 	CassandraCqlPort                            int    = 9041
@@ -89,6 +92,10 @@ const (
 	ConfigSchemaIntrospectPort                  int    = 8087
 	ConfigSvcMonitorIntrospectPort              int    = 8088
 	ConfigDeviceManagerIntrospectPort           int    = 8096
+	ConfigNodemgrIntrospectPort                 int    = 8100
+	AnalyticsNodemgrIntrospectPort              int    = 8104
+	AnalyticsAlarmNodemgrIntrospectPort         int    = 8113
+	AnalyticsSnmpNodemgrIntrospectPort          int    = 8114
 	CassandraSslEnable                          bool   = true
 	CassandraSslCertfile                        string = "/etc/contrail/ssl/certs/server.pem"
 	CassandraSslKeyfile                         string = "/etc/contrail/ssl/private/server-privkey.pem"
@@ -174,6 +181,7 @@ const (
 	KubernetesApiServer                         string = "10.96.0.1"
 	KubernetesApiPort                           int    = 8080
 	KubernetesApiSSLPort                        int    = 6443
+	KubemanagerIntrospectPort                   int    = 8108
 	KubernetesClusterName                       string = "k8s"
 	KubernetesDNSDomainName                     string = "k8s"
 	KubernetesPodSubnet                         string = "10.32.0.0/12"
@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// These are reasons of events recorded for TF resources.
+const (
+	EventReasonStatefulSetCreated = "StatefulSetCreated"
+	EventReasonStatefulSetUpdated = "StatefulSetUpdated"
+	EventReasonDaemonSetCreated   = "DaemonSetCreated"
+	EventReasonConfigChanged      = "ConfigChanged"
+	EventReasonCertificatesIssued = "CertificatesIssued"
+	EventReasonVhostReload        = "VhostReload"
+	EventReasonZiuStarted         = "ZiuStarted"
+	EventReasonZiuStageStarted    = "ZiuStageStarted"
+	EventReasonZiuStageCompleted  = "ZiuStageCompleted"
+	EventReasonZiuStageFailed     = "ZiuStageFailed"
+	EventReasonZiuStageRolledBack = "ZiuStageRolledBack"
+	EventReasonZiuRollback        = "ZiuRollback"
+	EventReasonZiuCompleted       = "ZiuCompleted"
+	EventReasonProcessFailed      = "ProcessFailed"
+	EventReasonReconcileFailed    = "ReconcileFailed"
+
+	EventReasonCredentialsRotationStarted = "CredentialsRotationStarted"
+	EventReasonCredentialsRotated         = "CredentialsRotated"
+
+	EventReasonScaleDownStarted = "ScaleDownStarted"
+	EventReasonScaledDown       = "ScaledDown"
+)
+
+// eventRecorder records events of the common helpers which have no reconciler at hand,
+// it is set once at the operator start
+var eventRecorder record.EventRecorder
+
+// SetEventRecorder sets the recorder of events of the common helpers
+func SetEventRecorder(recorder record.EventRecorder) {
+	eventRecorder = recorder
+}
+
+// RecordEvent records the event of the object with the recorder of the common helpers
+func RecordEvent(object interface{}, eventType, reason, messageFmt string, args ...interface{}) {
+	Eventf(eventRecorder, object, eventType, reason, messageFmt, args...)
+}
+
+// Eventf records the event of the object, nothing is recorded if the recorder is not set,
+// e.g. for reconcilers created in unit tests
+func Eventf(recorder record.EventRecorder, object interface{}, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	if obj, ok := object.(runtime.Object); ok {
+		recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
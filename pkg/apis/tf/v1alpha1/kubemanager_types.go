@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"sort"
 	"strconv"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -47,6 +51,8 @@ type KubemanagerStatus struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
 	CommonStatus `json:",inline"`
+	// ClusterName is the name of the kubernetes cluster served by the kubemanager
+	ClusterName string `json:"clusterName,omitempty"`
 }
 
 // KubemanagerConfiguration is the configuration for the kubemanager API.
@@ -65,8 +71,19 @@ type KubemanagerConfiguration struct {
 	HostNetworkService   *bool        `json:"hostNetworkService,omitempty"`
 	KubernetesTokenFile  string       `json:"kubernetesTokenFile,omitempty"`
 	PublicFIPPool        string       `json:"publicFIPPool,omitempty"`
+	// ClusterName is the name of the kubernetes cluster served by the kubemanager,
+	// the name of the local cluster is used by default.
+	ClusterName string `json:"clusterName,omitempty"`
+	// KubeconfigSecretName is the name of Secret with kubeconfig of a remote cluster
+	// under the key "kubeconfig". If set the kubemanager serves the remote cluster:
+	// API server and token are taken from the current context of the kubeconfig,
+	// podSubnet and serviceSubnet are required.
+	KubeconfigSecretName string `json:"kubeconfigSecretName,omitempty"`
 }
 
+// KubeconfigSecretKey is the key of kubeconfig in the Secret referred by KubeconfigSecretName
+const KubeconfigSecretKey = "kubeconfig"
+
 // KubemanagerList contains a list of Kubemanager.
 // +k8s:openapi-gen=true
 type KubemanagerList struct {
@@ -133,15 +150,21 @@ func (c *Kubemanager) InstanceConfiguration(podList []corev1.Pod, client client.
 		rabbitmqSecretVhost = string(rabbitmqSecret.Data["vhost"])
 	}
 
-	cinfo, err := ClusterParameters(client)
+	cinfo, remoteConfig, err := c.KubernetesClusterParameters(client)
 	if err != nil {
 		return nil, err
 	}
+	tlsFiles := remoteClusterTLSFiles(remoteConfig)
+	for key, value := range tlsFiles {
+		data[key] = string(value)
+	}
 
 	kubemanagerConfig, err := c.ConfigurationParameters(cinfo)
 	if err != nil {
 		return
 	}
+	// keep the resolved name to be reported in status
+	c.Status.ClusterName = kubemanagerConfig.ClusterName
 	if rabbitmqSecretUser == "" {
 		rabbitmqSecretUser = RabbitmqUser
 	}
@@ -165,11 +188,16 @@ func (c *Kubemanager) InstanceConfiguration(podList []corev1.Pod, client client.
 
 	for _, pod := range podList {
 		var kubemanagerConfigBuffer bytes.Buffer
-		secret := &corev1.Secret{}
-		if err = client.Get(context.TODO(), types.NamespacedName{Name: c.Name + "-kubemanager-secret", Namespace: c.Namespace}, secret); err != nil {
-			return
+		var token string
+		if remoteConfig != nil {
+			token = remoteConfig.BearerToken
+		} else {
+			secret := &corev1.Secret{}
+			if err = client.Get(context.TODO(), types.NamespacedName{Name: c.Name + "-kubemanager-secret", Namespace: c.Namespace}, secret); err != nil {
+				return
+			}
+			token = string(secret.Data["token"])
 		}
-		token := string(secret.Data["token"])
 		err = configtemplates.KubemanagerConfig.Execute(&kubemanagerConfigBuffer, struct {
 			Token                    string
 			ListenAddress            string
@@ -179,6 +207,9 @@ func (c *Kubemanager) InstanceConfiguration(podList []corev1.Pod, client client.
 			KubernetesAPIPort        string
 			KubernetesAPISSLPort     string
 			KubernetesClusterName    string
+			KubernetesCAFile         string
+			KubernetesCertFile       string
+			KubernetesKeyFile        string
 			PodSubnet                string
 			IPFabricSubnet           string
 			ServiceSubnet            string
@@ -208,10 +239,13 @@ func (c *Kubemanager) InstanceConfiguration(podList []corev1.Pod, client client.
 			KubernetesAPIServer:      kubemanagerConfig.KubernetesAPIServer,
 			KubernetesAPIPort:        strconv.Itoa(*kubemanagerConfig.KubernetesAPIPort),
 			KubernetesAPISSLPort:     strconv.Itoa(*kubemanagerConfig.KubernetesAPISSLPort),
-			KubernetesClusterName:    cinfo.ClusterName,
-			PodSubnet:                kubemanagerConfig.PodSubnet,
+			KubernetesClusterName:    kubemanagerConfig.ClusterName,
+			KubernetesCAFile:         remoteClusterTLSFilePath(tlsFiles, remoteClusterCAKey),
+			KubernetesCertFile:       remoteClusterTLSFilePath(tlsFiles, remoteClusterCertKey),
+			KubernetesKeyFile:        remoteClusterTLSFilePath(tlsFiles, remoteClusterKeyKey),
+			PodSubnet:                configtemplates.SubnetList(kubemanagerConfig.PodSubnet),
 			IPFabricSubnet:           kubemanagerConfig.IPFabricSubnets,
-			ServiceSubnet:            kubemanagerConfig.ServiceSubnet,
+			ServiceSubnet:            configtemplates.SubnetList(kubemanagerConfig.ServiceSubnet),
 			IPFabricForwarding:       strconv.FormatBool(*kubemanagerConfig.IPFabricForwarding),
 			IPFabricSnat:             strconv.FormatBool(*kubemanagerConfig.IPFabricSnat),
 			APIServerList:            configApiIPListCommaSeparated,
@@ -258,6 +292,7 @@ func (c *Kubemanager) InstanceConfiguration(podList []corev1.Pod, client client.
 		data["vnc_api_lib.ini."+pod.Status.PodIP] = vncApiConfigBuffer.String()
 	}
 
+	err = ApplyConfigOverrides("kubemanager", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -317,6 +352,11 @@ func (c *Kubemanager) SetInstanceActive(client client.Client, activeStatus *bool
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Kubemanager) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // ManageNodeStatus updates node status
 func (c *Kubemanager) ManageNodeStatus(nodes map[string]NodeInfo,
 	client client.Client) (updated bool, err error) {
@@ -376,7 +416,22 @@ func (c *Kubemanager) ConfigurationParameters(cinfo *KubernetesClusterConfig) (*
 		ipFabricSnat = *c.Spec.ServiceConfiguration.IPFabricSnat
 	}
 
-	var publicFIPPool string = fmt.Sprintf(KubernetesPublicFIPPoolTemplate, cinfo.ClusterName)
+	var clusterName string = cinfo.ClusterName
+	if c.Spec.ServiceConfiguration.ClusterName != "" {
+		clusterName = c.Spec.ServiceConfiguration.ClusterName
+	}
+
+	var podSubnet string = cinfo.Networking.PodSubnet
+	if c.Spec.ServiceConfiguration.PodSubnet != "" {
+		podSubnet = c.Spec.ServiceConfiguration.PodSubnet
+	}
+
+	var serviceSubnet string = cinfo.Networking.ServiceSubnet
+	if c.Spec.ServiceConfiguration.ServiceSubnet != "" {
+		serviceSubnet = c.Spec.ServiceConfiguration.ServiceSubnet
+	}
+
+	var publicFIPPool string = fmt.Sprintf(KubernetesPublicFIPPoolTemplate, clusterName)
 	if c.Spec.ServiceConfiguration.PublicFIPPool != "" {
 		publicFIPPool = c.Spec.ServiceConfiguration.PublicFIPPool
 	}
@@ -387,17 +442,100 @@ func (c *Kubemanager) ConfigurationParameters(cinfo *KubernetesClusterConfig) (*
 	kubemanagerConfiguration.KubernetesAPISSLPort = &kubernetesAPISSLPort
 	var kubernetesAPIPort int = KubernetesApiPort
 	kubemanagerConfiguration.KubernetesAPIPort = &kubernetesAPIPort
-	kubemanagerConfiguration.PodSubnet = cinfo.Networking.PodSubnet
-	kubemanagerConfiguration.ServiceSubnet = cinfo.Networking.ServiceSubnet
+	kubemanagerConfiguration.PodSubnet = podSubnet
+	kubemanagerConfiguration.ServiceSubnet = serviceSubnet
 	kubemanagerConfiguration.IPFabricSubnets = ipFabricSubnets
 	kubemanagerConfiguration.IPFabricForwarding = &ipFabricForwarding
 	kubemanagerConfiguration.HostNetworkService = &hostNetworkService
 	kubemanagerConfiguration.IPFabricSnat = &ipFabricSnat
 	kubemanagerConfiguration.PublicFIPPool = publicFIPPool
+	kubemanagerConfiguration.ClusterName = clusterName
 
 	return kubemanagerConfiguration, nil
 }
 
+// KubernetesClusterParameters returns parameters of the cluster served by the kubemanager.
+// For the remote cluster the rest config of the kubeconfig is returned as well
+// with the CA and client certificates loaded into it, the local cluster is
+// accessed with the token of the kubemanager service account and nil config is returned.
+func (c *Kubemanager) KubernetesClusterParameters(clnt client.Client) (*KubernetesClusterConfig, *rest.Config, error) {
+	if c.Spec.ServiceConfiguration.KubeconfigSecretName == "" {
+		cinfo, err := ClusterParameters(clnt)
+		return cinfo, nil, err
+	}
+	if c.Spec.ServiceConfiguration.PodSubnet == "" || c.Spec.ServiceConfiguration.ServiceSubnet == "" {
+		return nil, nil, fmt.Errorf("podSubnet and serviceSubnet are required for the remote cluster of kubemanager %s", c.Name)
+	}
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Name: c.Spec.ServiceConfiguration.KubeconfigSecretName, Namespace: c.Namespace}
+	if err := clnt.Get(context.TODO(), name, secret); err != nil {
+		return nil, nil, err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data[KubeconfigSecretKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load kubeconfig from secret %s: %w", name.Name, err)
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*kubeconfig, nil).ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid kubeconfig in secret %s: %w", name.Name, err)
+	}
+	// files referred by the kubeconfig are not available in the pods, so data must be inlined
+	if err = rest.LoadTLSFiles(restConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed to load certificates of kubeconfig in secret %s: %w", name.Name, err)
+	}
+	hasClientCert := len(restConfig.CertData) > 0 && len(restConfig.KeyData) > 0
+	if restConfig.BearerToken == "" && !hasClientCert {
+		return nil, nil, fmt.Errorf("kubeconfig in secret %s has neither token nor client certificate", name.Name)
+	}
+	server, err := url.Parse(restConfig.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	port := server.Port()
+	if port == "" {
+		port = "443"
+	}
+	cinfo := &KubernetesClusterConfig{
+		ControlPlaneEndpoint: net.JoinHostPort(server.Hostname(), port),
+		ClusterName:          KubernetesClusterName,
+	}
+	if ctx, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]; ok && ctx.Cluster != "" {
+		cinfo.ClusterName = ctx.Cluster
+	}
+	return cinfo, restConfig, nil
+}
+
+const (
+	remoteClusterCAKey   = "remote-cluster-ca.crt"
+	remoteClusterCertKey = "remote-cluster-client.crt"
+	remoteClusterKeyKey  = "remote-cluster-client.key"
+)
+
+// remoteClusterTLSFiles returns entries with the CA and client certificates of the remote cluster,
+// they are stored in the secret of the configmap as sensitiveConfigFiles.
+// CA is omitted if the kubeconfig skips the server verification
+func remoteClusterTLSFiles(restConfig *rest.Config) map[string][]byte {
+	files := make(map[string][]byte)
+	if restConfig == nil {
+		return files
+	}
+	if len(restConfig.CAData) > 0 && !restConfig.Insecure {
+		files[remoteClusterCAKey] = restConfig.CAData
+	}
+	if len(restConfig.CertData) > 0 && len(restConfig.KeyData) > 0 {
+		files[remoteClusterCertKey] = restConfig.CertData
+		files[remoteClusterKeyKey] = restConfig.KeyData
+	}
+	return files
+}
+
+func remoteClusterTLSFilePath(files map[string][]byte, key string) string {
+	if _, ok := files[key]; !ok {
+		return ""
+	}
+	return "/etc/contrailconfigmaps/" + key
+}
+
 // CommonStartupScript prepare common run service script
 //  command - is a final command to run
 //  configs - config files to be waited for and to be linked from configmap mount
@@ -0,0 +1,202 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var remoteKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote1
+  cluster:
+    server: https://10.0.0.10:6443
+    insecure-skip-tls-verify: true
+contexts:
+- name: remote1-admin
+  context:
+    cluster: remote1
+    user: admin
+current-context: remote1-admin
+users:
+- name: admin
+  user:
+    token: remote-token
+`
+
+var remoteCertKubeconfig = fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: remote2
+  cluster:
+    server: https://remote2.example.com
+    certificate-authority-data: %s
+contexts:
+- name: remote2-admin
+  context:
+    cluster: remote2
+    user: admin
+current-context: remote2-admin
+users:
+- name: admin
+  user:
+    client-certificate-data: %s
+    client-key-data: %s
+`, "cmVtb3RlLWNh", "cmVtb3RlLWNsaWVudC1jZXJ0", "cmVtb3RlLWNsaWVudC1rZXk=")
+
+func TestKubemanagerRemoteClusterCertificates(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote2-kubeconfig", Namespace: "tf"},
+		Data:       map[string][]byte{KubeconfigSecretKey: []byte(remoteCertKubeconfig)},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, secret)
+
+	kubemanager := &Kubemanager{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubemanager3", Namespace: "tf"},
+		Spec: KubemanagerSpec{ServiceConfiguration: KubemanagerConfiguration{
+			KubeconfigSecretName: "remote2-kubeconfig",
+			PodSubnet:            "10.48.0.0/12",
+			ServiceSubnet:        "10.112.0.0/12",
+		}},
+	}
+	cinfo, restConfig, err := kubemanager.KubernetesClusterParameters(cl)
+	require.NoError(t, err)
+	assert.Empty(t, restConfig.BearerToken)
+	assert.Equal(t, "remote2.example.com:443", cinfo.ControlPlaneEndpoint)
+
+	files := remoteClusterTLSFiles(restConfig)
+	assert.Equal(t, "remote-ca", string(files[remoteClusterCAKey]))
+	assert.Equal(t, "remote-client-cert", string(files[remoteClusterCertKey]))
+	assert.Equal(t, "remote-client-key", string(files[remoteClusterKeyKey]))
+	assert.Equal(t, "/etc/contrailconfigmaps/"+remoteClusterCAKey, remoteClusterTLSFilePath(files, remoteClusterCAKey))
+
+	data := map[string]string{
+		"kubemanager.10.0.0.1":     "[KUBERNETES]\ntoken=remote-token\n",
+		"vnc_api_lib.ini.10.0.0.1": "[global]\n",
+		"run-kubemanager.sh":       "exec /usr/bin/contrail-kube-manager",
+	}
+	for key, value := range files {
+		data[key] = string(value)
+	}
+	name := "kubemanager3-kubemanager-configmap"
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "kubemanager3", Namespace: "tf"}}
+	_, err = CreateConfigMap(name, cl, scheme, request, "kubemanager", data, kubemanager)
+	require.NoError(t, err)
+	stored := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "tf"}, stored))
+	assert.Contains(t, stored.Data, "run-kubemanager.sh")
+	for _, key := range []string{"kubemanager.10.0.0.1", remoteClusterCAKey, remoteClusterCertKey, remoteClusterKeyKey} {
+		assert.NotContains(t, stored.Data, key, "token and certificates of the remote cluster are kept in the secret")
+	}
+	configSecret := &corev1.Secret{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: ConfigSecretName(name), Namespace: "tf"}, configSecret))
+	assert.Equal(t, "remote-client-key", string(configSecret.Data[remoteClusterKeyKey]))
+	assert.Equal(t, "remote-ca", string(configSecret.Data[remoteClusterCAKey]))
+	assert.Contains(t, configSecret.Data, "kubemanager.10.0.0.1")
+}
+
+func TestKubemanagerRemoteClusterParameters(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote1-kubeconfig", Namespace: "tf"},
+		Data:       map[string][]byte{KubeconfigSecretKey: []byte(remoteKubeconfig)},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, secret)
+
+	kubemanager := &Kubemanager{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubemanager2", Namespace: "tf"},
+		Spec: KubemanagerSpec{ServiceConfiguration: KubemanagerConfiguration{
+			KubeconfigSecretName: "remote1-kubeconfig",
+		}},
+	}
+	_, _, err = kubemanager.KubernetesClusterParameters(cl)
+	assert.Error(t, err, "subnets of remote cluster are required")
+
+	kubemanager.Spec.ServiceConfiguration.PodSubnet = "10.48.0.0/12"
+	kubemanager.Spec.ServiceConfiguration.ServiceSubnet = "10.112.0.0/12"
+	cinfo, restConfig, err := kubemanager.KubernetesClusterParameters(cl)
+	require.NoError(t, err)
+	assert.Equal(t, "remote-token", restConfig.BearerToken)
+	assert.Empty(t, remoteClusterTLSFiles(restConfig), "server verification is skipped")
+	assert.Equal(t, "10.0.0.10:6443", cinfo.ControlPlaneEndpoint)
+	assert.Equal(t, "remote1", cinfo.ClusterName)
+
+	config, err := kubemanager.ConfigurationParameters(cinfo)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", config.KubernetesAPIServer)
+	assert.Equal(t, 6443, *config.KubernetesAPISSLPort)
+	assert.Equal(t, "remote1", config.ClusterName)
+	assert.Equal(t, "10.48.0.0/12", config.PodSubnet)
+	assert.Equal(t, "10.112.0.0/12", config.ServiceSubnet)
+	assert.Contains(t, config.PublicFIPPool, "'remote1-default'")
+
+	kubemanager.Spec.ServiceConfiguration.ClusterName = "east"
+	config, err = kubemanager.ConfigurationParameters(cinfo)
+	require.NoError(t, err)
+	assert.Equal(t, "east", config.ClusterName)
+}
+
+func TestManagerKubemanagersReady(t *testing.T) {
+	trueVal := true
+	falseVal := false
+	name1, name2 := "kubemanager1", "kubemanager2"
+	m := Manager{}
+	m.Spec.Services.Kubemanagers = []*KubemanagerInput{
+		{Metadata: Metadata{Name: name1}},
+		{Metadata: Metadata{Name: name2}},
+	}
+	m.Status.Kubemanagers = []*KubemanagerServiceStatus{
+		{ServiceStatus: ServiceStatus{Name: &name1, Active: &trueVal}, ClusterName: "k8s"},
+	}
+	assert.False(t, m.IsClusterReady(), "status of the second cluster is not reported yet")
+
+	m.Status.Kubemanagers = append(m.Status.Kubemanagers,
+		&KubemanagerServiceStatus{ServiceStatus: ServiceStatus{Name: &name2, Active: &falseVal}, ClusterName: "remote1"})
+	assert.False(t, m.IsClusterReady())
+
+	m.Status.Kubemanagers[1].Active = &trueVal
+	assert.True(t, m.IsClusterReady())
+
+	stages := m.GetZiuPlan(nil).Stages
+	assert.Equal(t, "Kubemanager", stages[len(stages)-1].Kind, "kubemanagers are upgraded by ZIU")
+}
+
+func TestManagerDeprecatedKubemanager(t *testing.T) {
+	trueVal := true
+	name1, name2 := "kubemanager1", "kubemanager2"
+	m := Manager{}
+	m.Spec.Services.Kubemanager = &KubemanagerInput{Metadata: Metadata{Name: name1}}
+	assert.Len(t, m.Spec.Services.KubemanagerInputs(), 1)
+	stages := m.GetZiuPlan(nil).Stages
+	assert.Equal(t, "Kubemanager", stages[len(stages)-1].Kind, "kubemanager of the former API is upgraded by ZIU")
+
+	m.Spec.Services.Kubemanagers = []*KubemanagerInput{{Metadata: Metadata{Name: name2}}}
+	kubemanagers := m.Spec.Services.KubemanagerInputs()
+	require.Len(t, kubemanagers, 2)
+	assert.Equal(t, name1, kubemanagers[0].Metadata.Name)
+	assert.Len(t, m.Spec.Services.Kubemanagers, 1, "spec is not modified")
+
+	m.Spec.Services.Kubemanagers = append(m.Spec.Services.Kubemanagers, &KubemanagerInput{Metadata: Metadata{Name: name1}})
+	assert.Len(t, m.Spec.Services.KubemanagerInputs(), 2, "kubemanager listed twice is served once")
+
+	m.Status.Kubemanagers = []*KubemanagerServiceStatus{
+		{ServiceStatus: ServiceStatus{Name: &name2, Active: &trueVal}},
+	}
+	assert.Contains(t, m.NotReadyServices(), "Kubemanager/"+name1)
+}
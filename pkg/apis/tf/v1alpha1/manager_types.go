@@ -2,7 +2,9 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
+	"github.com/tungstenfabric/tf-operator/pkg/certificates"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -20,8 +22,73 @@ type ManagerSpec struct {
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
 	CommonConfiguration ManagerConfiguration `json:"commonConfiguration,omitempty"`
 	Services            Services             `json:"services,omitempty"`
+	// ZiuPlan is the plan of zero impact upgrade,
+	// if not set the default plan is made of hooks of the ZIU versions table
+	// (configmap tf-operator-ziu-versions) matching the deployed version
+	// +optional
+	ZiuPlan *ZiuPlan `json:"ziuPlan,omitempty"`
+	// Backup configures scheduled backups of Cassandra and Zookeeper data
+	// +optional
+	Backup *BackupConfiguration `json:"backup,omitempty"`
+	// Restore brings up Cassandra and Zookeeper from the backup
+	// +optional
+	Restore *RestoreConfiguration `json:"restore,omitempty"`
+}
+
+// ZiuPlan is an ordered plan of zero impact upgrade.
+// +k8s:openapi-gen=true
+type ZiuPlan struct {
+	// PreHooks are run once when ZIU is started
+	PreHooks []string `json:"preHooks,omitempty"`
+	// Stages are processed in the order
+	Stages []ZiuPlanStage `json:"stages,omitempty"`
+	// AutoRollback reverts upgraded stages if a stage is not ready in its timeout
+	// +optional
+	AutoRollback *bool `json:"autoRollback,omitempty"`
+}
+
+// ZiuRollbackAnnotation on Manager with value "true" starts rollback of ZIU in progress
+const ZiuRollbackAnnotation = "tf.tungsten.io/ziu-rollback"
+
+// ZiuPlanStage is a stage of ZIU, all services of the kind are upgraded at the stage.
+// +k8s:openapi-gen=true
+type ZiuPlanStage struct {
+	// Kind of services to upgrade
+	Kind string `json:"kind"`
+	// ReadinessChecks are checks of services to pass before the next stage
+	// (default all: Image, Replicas, Pods, Active)
+	// +optional
+	ReadinessChecks []ZiuReadinessCheck `json:"readinessChecks,omitempty"`
+	// PreHooks are run before services are updated
+	// +optional
+	PreHooks []string `json:"preHooks,omitempty"`
+	// PostHooks are run after services are ready
+	// +optional
+	PostHooks []string `json:"postHooks,omitempty"`
+	// Timeout is the time in seconds for services to get ready (default no timeout)
+	// +optional
+	Timeout *int `json:"timeout,omitempty"`
 }
 
+// ZiuReadinessCheck is a check of a service readiness at ZIU stage.
+// +kubebuilder:validation:Enum=Image;Replicas;Pods;Active
+type ZiuReadinessCheck string
+
+// These are valid ZIU readiness checks.
+const (
+	// ZiuCheckImage checks STS has images from Manager spec
+	ZiuCheckImage ZiuReadinessCheck = "Image"
+	// ZiuCheckReplicas checks all STS replicas are updated
+	ZiuCheckReplicas ZiuReadinessCheck = "Replicas"
+	// ZiuCheckPods checks all pods are running with STS images
+	ZiuCheckPods ZiuReadinessCheck = "Pods"
+	// ZiuCheckActive checks service is active
+	ZiuCheckActive ZiuReadinessCheck = "Active"
+)
+
+// ZiuReadinessChecksAll is the default list of ZIU readiness checks
+var ZiuReadinessChecksAll = []ZiuReadinessCheck{ZiuCheckImage, ZiuCheckReplicas, ZiuCheckPods, ZiuCheckActive}
+
 // Services defines the desired state of Services.
 // +k8s:openapi-gen=true
 type Services struct {
@@ -30,7 +97,7 @@ type Services struct {
 	Analytics      *AnalyticsInput      `json:"analytics,omitempty"`
 	Config         *ConfigInput         `json:"config,omitempty"`
 	Controls       []*ControlInput      `json:"controls,omitempty"`
-	Kubemanager    *KubemanagerInput    `json:"kubemanager,omitempty"`
+	Kubemanagers   []*KubemanagerInput  `json:"kubemanagers,omitempty"`
 	QueryEngine    *QueryEngineInput    `json:"queryengine,omitempty"`
 	Webui          *WebuiInput          `json:"webui,omitempty"`
 	Vrouters       []*VrouterInput      `json:"vrouters,omitempty"`
@@ -38,6 +105,10 @@ type Services struct {
 	Zookeeper      *ZookeeperInput      `json:"zookeeper,omitempty"`
 	Rabbitmq       *RabbitmqInput       `json:"rabbitmq,omitempty"`
 	Redis          []*RedisInput        `json:"redis,omitempty"`
+	// Kubemanager is the single kubemanager of the former API,
+	// it is served along with Kubemanagers.
+	// Deprecated: use Kubemanagers.
+	Kubemanager *KubemanagerInput `json:"kubemanager,omitempty"`
 }
 
 // AnalyticsSnmpInput is the Schema for the analytics API.
@@ -167,6 +238,47 @@ type ManagerConfiguration struct {
 	// Certificate signer
 	// +optional
 	CertSigner *string `json:"certSigner,omitempty"`
+	// CertValidityDays is the lifetime of certificates of services issued by SelfSignedCA, 3650 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CertValidityDays *int `json:"certValidityDays,omitempty"`
+	// CACertValidityDays is the lifetime of the self-signed CA generated by the operator, 3650 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CACertValidityDays *int `json:"caCertValidityDays,omitempty"`
+	// CertRenewalPercent is the percentage of the lifetime after which certificates
+	// and the self-signed CA are renewed, 80 by default
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	CertRenewalPercent *int `json:"certRenewalPercent,omitempty"`
+	// ClusterConfig overrides parameters of kubernetes cluster discovered by the operator,
+	// only set fields are overridden
+	// +optional
+	ClusterConfig *KubernetesClusterConfig `json:"clusterConfig,omitempty"`
+}
+
+// CertLifetimes returns lifetimes of certificates defined by the configuration, defaults for unset ones
+func (c *ManagerConfiguration) CertLifetimes() certificates.Lifetimes {
+	const day = 24 * time.Hour
+	lifetimes := certificates.DefaultLifetimes()
+	if c.CertValidityDays != nil {
+		lifetimes.Cert = time.Duration(*c.CertValidityDays) * day
+	}
+	if c.CACertValidityDays != nil {
+		lifetimes.CACert = time.Duration(*c.CACertValidityDays) * day
+	}
+	if c.CertRenewalPercent != nil {
+		lifetimes.RenewalPercent = *c.CertRenewalPercent
+	}
+	return lifetimes
+}
+
+// KubemanagerServiceStatus is the status of kubemanager serving a kubernetes cluster.
+// +k8s:openapi-gen=true
+type KubemanagerServiceStatus struct {
+	ServiceStatus `json:",inline"`
+	ClusterName   string `json:"clusterName,omitempty"`
 }
 
 // ZIU status for orchestrating cluster ZIU process
@@ -181,36 +293,71 @@ type ManagerStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
-	AnalyticsSnmp  *ServiceStatus   `json:"analyticsSnmp,omitempty"`
-	AnalyticsAlarm *ServiceStatus   `json:"analyticsAlarm,omitempty"`
-	Analytics      *ServiceStatus   `json:"analytics,omitempty"`
-	Config         *ServiceStatus   `json:"config,omitempty"`
-	Controls       []*ServiceStatus `json:"controls,omitempty"`
-	Kubemanager    *ServiceStatus   `json:"kubemanager,omitempty"`
-	QueryEngine    *ServiceStatus   `json:"queryengine,omitempty"`
-	Webui          *ServiceStatus   `json:"webui,omitempty"`
-	Vrouters       []*ServiceStatus `json:"vrouters,omitempty"`
-	Cassandras     []*ServiceStatus `json:"cassandras,omitempty"`
-	Zookeeper      *ServiceStatus   `json:"zookeeper,omitempty"`
-	Rabbitmq       *ServiceStatus   `json:"rabbitmq,omitempty"`
-	Redis          []*ServiceStatus `json:"redis,omitempty"`
-	CrdStatus      []CrdStatus      `json:"crdStatus,omitempty"`
-	ZiuState       ZIUStatus        `json:"ziuState,omitempty"`
+	AnalyticsSnmp  *ServiceStatus              `json:"analyticsSnmp,omitempty"`
+	AnalyticsAlarm *ServiceStatus              `json:"analyticsAlarm,omitempty"`
+	Analytics      *ServiceStatus              `json:"analytics,omitempty"`
+	Config         *ServiceStatus              `json:"config,omitempty"`
+	Controls       []*ServiceStatus            `json:"controls,omitempty"`
+	Kubemanagers   []*KubemanagerServiceStatus `json:"kubemanagers,omitempty"`
+	QueryEngine    *ServiceStatus              `json:"queryengine,omitempty"`
+	Webui          *ServiceStatus              `json:"webui,omitempty"`
+	Vrouters       []*ServiceStatus            `json:"vrouters,omitempty"`
+	Cassandras     []*ServiceStatus            `json:"cassandras,omitempty"`
+	Zookeeper      *ServiceStatus              `json:"zookeeper,omitempty"`
+	Rabbitmq       *ServiceStatus              `json:"rabbitmq,omitempty"`
+	Redis          []*ServiceStatus            `json:"redis,omitempty"`
+	CrdStatus      []CrdStatus                 `json:"crdStatus,omitempty"`
+	ZiuState       ZIUStatus                   `json:"ziuState,omitempty"`
+	// ZiuStages is the history of stages of the last ZIU
+	ZiuStages []ZiuStageStatus `json:"ziuStages,omitempty"`
+	// ZiuFromVersion is the version of services the last ZIU is started from
+	ZiuFromVersion string `json:"ziuFromVersion,omitempty"`
+	// Kubemanager is the status of the single kubemanager of the former API,
+	// it is cleared once the kubemanager is reported in Kubemanagers.
+	// Deprecated: use Kubemanagers.
+	Kubemanager *ServiceStatus `json:"kubemanager,omitempty"`
+	// ZiuRollback is set when ZIU stages are being reverted
+	ZiuRollback bool `json:"ziuRollback,omitempty"`
+	// ZiuRolledBackImage is the image ZIU to which has been rolled back,
+	// services are not updated till the Manager spec is changed
+	ZiuRolledBackImage string `json:"ziuRolledBackImage,omitempty"`
+	// ZiuRolledBackGeneration is the generation of the Manager spec ZIU of which has been rolled back
+	ZiuRolledBackGeneration int64 `json:"ziuRolledBackGeneration,omitempty"`
+	// LastBackupTime is the time of the last scheduled backup
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+	// Backups are the statuses of backups per service
+	Backups []BackupStatus `json:"backups,omitempty"`
+	// Restore is the status of the last restore
+	Restore *RestoreStatus `json:"restore,omitempty"`
+	// ClusterConfig is the resolved configuration of kubernetes cluster used for services
+	ClusterConfig *KubernetesClusterConfig `json:"clusterConfig,omitempty"`
+	// ClusterConfigSources are the sources of ClusterConfig values in the order of precedence
+	ClusterConfigSources []string `json:"clusterConfigSources,omitempty"`
+	// CACertificateNotAfter is the expiry of the CA signing certificates of services
+	CACertificateNotAfter *metav1.Time `json:"caCertificateNotAfter,omitempty"`
+	// ObservedGeneration is the generation of the spec the status is reported for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard conditions of the cluster, e.g. Ready and Upgrading
 	// +optional
+	// +listType=map
+	// +listMapKey=type
 	// +patchMergeKey=type
 	// +patchStrategy=merge
-	Conditions []ManagerCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // ManagerConditionType is used to represent condition of manager.
+// Deprecated: conditions of the status are metav1.Condition with types of status.go.
 type ManagerConditionType string
 
 // These are valid conditions of manager.
 const (
+	// ManagerReady is true if all services are active, it is kept along with Available for compatibility.
 	ManagerReady ManagerConditionType = "Ready"
 )
 
 // ConditionStatus is used to indicate state of condition.
+// Deprecated: use metav1.ConditionStatus.
 type ConditionStatus string
 
 // These are valid condition statuses. "ConditionTrue" means a resource is in the condition.
@@ -220,7 +367,8 @@ const (
 	ConditionFalse ConditionStatus = "False"
 )
 
-// ManagerCondition is used to represent cluster condition
+// ManagerCondition is used to represent cluster condition.
+// Deprecated: use metav1.Condition, the type and the status are serialized the same way.
 type ManagerCondition struct {
 	// Type of manager condition.
 	Type ManagerConditionType `json:"type"`
@@ -228,6 +376,38 @@ type ManagerCondition struct {
 	Status ConditionStatus `json:"status"`
 }
 
+// ManagerConditions returns conditions of the status as the former ManagerCondition type.
+// Deprecated: use Status.Conditions.
+func (m *Manager) ManagerConditions() []ManagerCondition {
+	var conditions []ManagerCondition
+	for _, c := range m.Status.Conditions {
+		conditions = append(conditions, ManagerCondition{Type: ManagerConditionType(c.Type), Status: ConditionStatus(c.Status)})
+	}
+	return conditions
+}
+
+// ZiuStagePhase is the phase of ZIU stage.
+type ZiuStagePhase string
+
+// These are valid phases of ZIU stage.
+const (
+	ZiuStagePending    ZiuStagePhase = "Pending"
+	ZiuStageInProgress ZiuStagePhase = "InProgress"
+	ZiuStageCompleted  ZiuStagePhase = "Completed"
+	ZiuStageFailed     ZiuStagePhase = "Failed"
+	ZiuStageRolledBack ZiuStagePhase = "RolledBack"
+)
+
+// ZiuStageStatus tracks status of ZIU stage.
+// +k8s:openapi-gen=true
+type ZiuStageStatus struct {
+	Kind           string        `json:"kind"`
+	Phase          ZiuStagePhase `json:"phase,omitempty"`
+	StartTime      *metav1.Time  `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time  `json:"completionTime,omitempty"`
+	Message        string        `json:"message,omitempty"`
+}
+
 // CrdStatus tracks status of CRD.
 // +k8s:openapi-gen=true
 type CrdStatus struct {
@@ -297,17 +477,37 @@ func (m *Manager) GetObjectFromObjectList(objectList *[]*interface{}, request re
 }
 
 func (m Manager) IsClusterReady() bool {
+	return len(m.NotReadyServices()) == 0
+}
+
+// KubemanagerInputs returns kubemanagers of the cluster,
+// the one of the deprecated Kubemanager field is merged unless it is listed in Kubemanagers
+func (s *Services) KubemanagerInputs() []*KubemanagerInput {
+	if s.Kubemanager == nil {
+		return s.Kubemanagers
+	}
+	for _, kubemanager := range s.Kubemanagers {
+		if kubemanager.Metadata.Name == s.Kubemanager.Metadata.Name {
+			return s.Kubemanagers
+		}
+	}
+	return append([]*KubemanagerInput{s.Kubemanager}, s.Kubemanagers...)
+}
+
+// NotReadyServices returns kinds and names of services of the cluster which are not ready
+func (m Manager) NotReadyServices() []string {
+	var notReady []string
 	for _, cassandraService := range m.Spec.Services.Cassandras {
 		for _, cassandraStatus := range m.Status.Cassandras {
 			if cassandraService.Metadata.Name == *cassandraStatus.Name && !cassandraStatus.ready() {
-				return false
+				notReady = append(notReady, "Cassandra/"+cassandraService.Metadata.Name)
 			}
 		}
 	}
 	for _, controlService := range m.Spec.Services.Controls {
 		for _, controlStatus := range m.Status.Controls {
 			if controlService.Metadata.Name == *controlStatus.Name && !controlStatus.ready() {
-				return false
+				notReady = append(notReady, "Control/"+controlService.Metadata.Name)
 			}
 		}
 	}
@@ -315,7 +515,7 @@ func (m Manager) IsClusterReady() bool {
 	for _, vrouterService := range m.Spec.Services.Vrouters {
 		for _, vrouterStatus := range m.Status.Vrouters {
 			if vrouterService.Metadata.Name == *vrouterStatus.Name && !vrouterStatus.ready() {
-				return false
+				notReady = append(notReady, "Vrouter/"+vrouterService.Metadata.Name)
 			}
 		}
 	}
@@ -323,29 +523,84 @@ func (m Manager) IsClusterReady() bool {
 	for _, redisService := range m.Spec.Services.Redis {
 		for _, redisStatus := range m.Status.Redis {
 			if redisService.Metadata.Name == *redisStatus.Name && !redisStatus.ready() {
-				return false
+				notReady = append(notReady, "Redis/"+redisService.Metadata.Name)
 			}
 		}
 	}
 
 	if m.Spec.Services.Zookeeper != nil && !m.Status.Zookeeper.ready() {
-		return false
+		notReady = append(notReady, "Zookeeper/"+m.Spec.Services.Zookeeper.Metadata.Name)
 	}
 
-	if m.Spec.Services.Kubemanager != nil && !m.Status.Kubemanager.ready() {
-		return false
+	for _, kubemanagerService := range m.Spec.Services.KubemanagerInputs() {
+		found := false
+		for _, kubemanagerStatus := range m.Status.Kubemanagers {
+			if kubemanagerService.Metadata.Name == *kubemanagerStatus.Name {
+				found = true
+				if !kubemanagerStatus.ready() {
+					notReady = append(notReady, "Kubemanager/"+kubemanagerService.Metadata.Name)
+				}
+			}
+		}
+		if !found {
+			notReady = append(notReady, "Kubemanager/"+kubemanagerService.Metadata.Name)
+		}
 	}
 
 	if m.Spec.Services.Webui != nil && !m.Status.Webui.ready() {
-		return false
+		notReady = append(notReady, "Webui/"+m.Spec.Services.Webui.Metadata.Name)
 	}
 	if m.Spec.Services.Config != nil && !m.Status.Config.ready() {
-		return false
+		notReady = append(notReady, "Config/"+m.Spec.Services.Config.Metadata.Name)
 	}
 	if m.Spec.Services.Rabbitmq != nil && !m.Status.Rabbitmq.ready() {
-		return false
+		notReady = append(notReady, "Rabbitmq/"+m.Spec.Services.Rabbitmq.Metadata.Name)
+	}
+	return notReady
+}
+
+// DegradedServices returns kinds and names of services of the cluster which are degraded
+func (m Manager) DegradedServices() []string {
+	var degraded []string
+	add := func(kind string, s *ServiceStatus) {
+		if s != nil && s.Name != nil && s.Degraded != nil && *s.Degraded {
+			degraded = append(degraded, kind+"/"+*s.Name)
+		}
+	}
+	add("Analytics", m.Status.Analytics)
+	add("AnalyticsSnmp", m.Status.AnalyticsSnmp)
+	add("AnalyticsAlarm", m.Status.AnalyticsAlarm)
+	for _, s := range m.Status.Cassandras {
+		add("Cassandra", s)
 	}
-	return true
+	add("Config", m.Status.Config)
+	for _, s := range m.Status.Controls {
+		add("Control", s)
+	}
+	for _, s := range m.Status.Kubemanagers {
+		if s != nil {
+			add("Kubemanager", &s.ServiceStatus)
+		}
+	}
+	add("QueryEngine", m.Status.QueryEngine)
+	add("Rabbitmq", m.Status.Rabbitmq)
+	for _, s := range m.Status.Redis {
+		add("Redis", s)
+	}
+	add("Webui", m.Status.Webui)
+	add("Zookeeper", m.Status.Zookeeper)
+	return degraded
+}
+
+// IsZiuInProgress returns true if ZIU stages are being processed
+func (m Manager) IsZiuInProgress() bool {
+	return m.Status.ZiuState >= 0 && len(m.Status.ZiuStages) > 0
+}
+
+// IsZiuRolledBack returns true if ZIU to the current Manager spec has been rolled back,
+// the next change of the spec clears it
+func (m *Manager) IsZiuRolledBack() bool {
+	return m.Status.ZiuRolledBackImage != "" && m.Status.ZiuRolledBackGeneration == m.Generation
 }
 
 // IsVrouterActiveOnControllers checks if vrouters are active on master nodes
@@ -0,0 +1,116 @@
+package v1alpha1
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// parseNodemgrFunctional checks that all processes reported by NodeStatus UVE of nodemgr are functional,
+// returns the description of the first process which is not.
+// Processes which are backups of the active instance, e.g. kube-manager, are reported healthy.
+func parseNodemgrFunctional(nodeStatus []byte) (bool, string, error) {
+	found, notFunctional := false, ""
+	err := decodeIntrospectElements(nodeStatus, "ProcessStatus", func(d *xml.Decoder, start *xml.StartElement) error {
+		var p introspectProcessStatus
+		if err := d.DecodeElement(&p, start); err != nil {
+			return err
+		}
+		found = true
+		if p.State != "Functional" && !strings.HasPrefix(p.Description, "Backup") && notFunctional == "" {
+			notFunctional = fmt.Sprintf("%s is %s", p.ModuleID, p.State)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, "", err
+	}
+	if !found {
+		return false, "no process status is reported", nil
+	}
+	return notFunctional == "", notFunctional, nil
+}
+
+// curl requests the url from the container of the pod and returns the response body and the http code
+var curl = func(pod *corev1.Pod, container, url string) (body string, code int, err error) {
+	stdout, stderr, err := ExecToContainer(pod, container, []string{"curl", "-sk", "--max-time", "10", "-w", "\n%{http_code}", url}, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("Failed to request %s: %v (stderr=%s)", url, err, stderr)
+	}
+	if idx := strings.LastIndex(stdout, "\n"); idx >= 0 {
+		body, stdout = stdout[:idx], stdout[idx+1:]
+	}
+	code, err = strconv.Atoi(strings.TrimSpace(stdout))
+	return body, code, err
+}
+
+func podURL(pod *corev1.Pod, port int, path string) string {
+	return fmt.Sprintf("https://%s/%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port)), path)
+}
+
+// podServiceHealthy checks that the service of the pod responds on the port from the container and,
+// if nodeStatusPort is set, the introspect on that port reports the processes of the pod functional.
+// It is nodemgr introspect for the pods with nodemgr and the introspect of the service itself otherwise.
+func podServiceHealthy(pod *corev1.Pod, container string, port int, nodeStatusContainer string, nodeStatusPort int) (bool, error) {
+	if _, code, err := curl(pod, container, podURL(pod, port, "")); err != nil || code == 0 || code >= 500 {
+		log.Info("Service is not responding", "pod", pod.Name, "container", container, "code", code, "err", err)
+		return false, nil
+	}
+	if nodeStatusPort == 0 {
+		return true, nil
+	}
+	nodeStatus, _, err := curl(pod, nodeStatusContainer, podURL(pod, nodeStatusPort, "Snh_SandeshUVECacheReq?x=NodeStatus"))
+	if err != nil {
+		return false, err
+	}
+	functional, message, err := parseNodemgrFunctional([]byte(nodeStatus))
+	if err == nil && !functional {
+		log.Info("Service is not functional", "pod", pod.Name, "reason", message)
+	}
+	return functional, err
+}
+
+// PodHealthy checks that Config API of the pod responds and nodemgr reports the processes of the pod functional
+func (c *Config) PodHealthy(pod *corev1.Pod) (bool, error) {
+	config := c.ConfigurationParameters()
+	return podServiceHealthy(pod, "api", *config.APIPort, "nodemanager", ConfigNodemgrIntrospectPort)
+}
+
+// PodHealthy checks that Analytics API of the pod responds and nodemgr reports the processes of the pod functional
+func (c *Analytics) PodHealthy(pod *corev1.Pod) (bool, error) {
+	config := c.ConfigurationParameters()
+	return podServiceHealthy(pod, "analyticsapi", *config.AnalyticsPort, "nodemanager", AnalyticsNodemgrIntrospectPort)
+}
+
+// PodHealthy checks that alarmgen introspect of the pod responds and nodemgr reports the processes of the pod functional
+func (c *AnalyticsAlarm) PodHealthy(pod *corev1.Pod) (bool, error) {
+	config := c.ConfigurationParameters()
+	return podServiceHealthy(pod, "analytics-alarm-gen", *config.AlarmgenIntrospectListenPort, "nodemanager", AnalyticsAlarmNodemgrIntrospectPort)
+}
+
+// PodHealthy checks that SNMP collector introspect of the pod responds and nodemgr reports the processes of the pod functional
+func (c *AnalyticsSnmp) PodHealthy(pod *corev1.Pod) (bool, error) {
+	config := c.ConfigurationParameters()
+	return podServiceHealthy(pod, "analytics-snmp-collector", *config.SnmpCollectorIntrospectListenPort, "nodemanager", AnalyticsSnmpNodemgrIntrospectPort)
+}
+
+// PodHealthy checks that query engine introspect of the pod responds and reports the query engine functional,
+// the pod has no nodemgr
+func (c *QueryEngine) PodHealthy(pod *corev1.Pod) (bool, error) {
+	return podServiceHealthy(pod, "queryengine", QueryengineIntrospectPort, "queryengine", QueryengineIntrospectPort)
+}
+
+// PodHealthy checks that kube-manager introspect of the pod responds and reports kube-manager functional
+// or the backup of the active one, the pod has no nodemgr
+func (c *Kubemanager) PodHealthy(pod *corev1.Pod) (bool, error) {
+	return podServiceHealthy(pod, "kubemanager", KubemanagerIntrospectPort, "kubemanager", KubemanagerIntrospectPort)
+}
+
+// PodHealthy checks that WebUI of the pod responds, the pod has neither nodemgr nor introspect
+func (c *Webui) PodHealthy(pod *corev1.Pod) (bool, error) {
+	return podServiceHealthy(pod, "webuiweb", WebuiHttpsListenPort, "", 0)
+}
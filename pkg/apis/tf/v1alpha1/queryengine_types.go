@@ -173,6 +173,7 @@ func (c *QueryEngine) InstanceConfiguration(podList []corev1.Pod, client client.
 		data["vnc_api_lib.ini."+podIP] = vncApiBuffer.String()
 	}
 
+	err = ApplyConfigOverrides("queryengine", c.Spec.CommonConfiguration.ConfigOverrides, data)
 	return
 }
 
@@ -228,12 +229,18 @@ func (c *QueryEngine) SetInstanceActive(client client.Client, activeStatus *bool
 	}
 	*activeStatus = sts.Status.ReadyReplicas >= *sts.Spec.Replicas/2+1
 	*degradedStatus = sts.Status.ReadyReplicas < *sts.Spec.Replicas
+	c.Status.SetStatefulSetConditions(c.Generation, sts)
 	if err := client.Status().Update(context.TODO(), c); err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *QueryEngine) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // PodIPListAndIPMapFromInstance gets a list with POD IPs and a map of POD names and IPs.
 func (c *QueryEngine) PodIPListAndIPMapFromInstance(request reconcile.Request, reconcileClient client.Client) ([]corev1.Pod, map[string]NodeInfo, error) {
 	return PodIPListAndIPMapFromInstance("queryengine", request, reconcileClient, "")
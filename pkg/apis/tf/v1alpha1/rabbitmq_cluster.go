@@ -0,0 +1,61 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rabbitmqClusterStatus is the part of rabbitmqctl cluster_status --formatter json output
+type rabbitmqClusterStatus struct {
+	DiskNodes    []string `json:"disk_nodes"`
+	RAMNodes     []string `json:"ram_nodes"`
+	RunningNodes []string `json:"running_nodes"`
+}
+
+// parseRabbitmqClusterStatus returns the members of the cluster and if the node is running
+func parseRabbitmqClusterStatus(out, node string) (member bool, running bool, err error) {
+	var status rabbitmqClusterStatus
+	if err = json.Unmarshal([]byte(out), &status); err != nil {
+		return false, false, err
+	}
+	for _, n := range append(status.DiskNodes, status.RAMNodes...) {
+		member = member || n == node
+	}
+	for _, n := range status.RunningNodes {
+		running = running || n == node
+	}
+	return member, running, nil
+}
+
+// RemoveMember removes the node of the pod out of replicas from the cluster:
+// the app of the node is stopped and the node is forgotten by a remaining node.
+func (c *Rabbitmq) RemoveMember(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+	if len(remaining) == 0 {
+		return false, "No pod to query the cluster", nil
+	}
+	node := "rabbit@" + pod2node(*pod)
+	out, err := rabbitmqctl(&remaining[0], "", "cluster_status", "--formatter", "json")
+	if err != nil {
+		return false, "", err
+	}
+	member, running, err := parseRabbitmqClusterStatus(out, node)
+	if err != nil {
+		return false, "", err
+	}
+	if !member {
+		return true, "", nil
+	}
+	ll := log.WithName("RemoveMember").WithName(c.Name)
+	if running {
+		ll.Info("Stop node", "node", node)
+		if _, err = rabbitmqctl(pod, "", "stop_app"); err != nil {
+			return false, "", err
+		}
+	}
+	ll.Info("Forget cluster node", "node", node)
+	if _, err = rabbitmqctl(&remaining[0], "", "forget_cluster_node", node); err != nil {
+		return false, "", err
+	}
+	return false, "Node " + node + " is forgotten by the cluster", nil
+}
@@ -0,0 +1,309 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tungstenfabric/tf-operator/pkg/randomstring"
+)
+
+// RabbitmqRotateCredentialsAnnotation on Rabbitmq starts rotation of credentials
+// each time its value is changed, e.g. to the current date
+const RabbitmqRotateCredentialsAnnotation = "tf.tungsten.io/rotate-credentials"
+
+// These are phases of rotation of RabbitMQ credentials.
+const (
+	RabbitmqRotationAddingUser         = "AddingUser"
+	RabbitmqRotationReloadingConsumers = "ReloadingConsumers"
+	RabbitmqRotationVerifying          = "VerifyingConnections"
+	RabbitmqRotationRemovingUser       = "RemovingUser"
+	RabbitmqRotationCompleted          = "Completed"
+)
+
+// RabbitmqCredentialsRotationStatus is the progress of rotation of RabbitMQ credentials.
+// +k8s:openapi-gen=true
+type RabbitmqCredentialsRotationStatus struct {
+	// Phase is one of AddingUser, ReloadingConsumers, VerifyingConnections, RemovingUser and Completed
+	Phase string `json:"phase,omitempty"`
+	// User is the user created by the rotation
+	User string `json:"user,omitempty"`
+	// PreviousUser is the user removed at the end of the rotation
+	PreviousUser string `json:"previousUser,omitempty"`
+	// Trigger is the value of the annotation which started the last rotation
+	Trigger          string       `json:"trigger,omitempty"`
+	StartTime        *metav1.Time `json:"startTime,omitempty"`
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	Message          string       `json:"message,omitempty"`
+}
+
+// InProgress returns true if the rotation is started and not completed
+func (s *RabbitmqCredentialsRotationStatus) InProgress() bool {
+	return s != nil && s.Phase != "" && s.Phase != RabbitmqRotationCompleted
+}
+
+// Rotated returns true if credentials in the secret are managed by the rotation
+func (s *RabbitmqCredentialsRotationStatus) Rotated() bool {
+	return s != nil && s.User != ""
+}
+
+// key of the secret with the password of the user being added
+const rabbitmqRotationPasswordKey = "rotation_password"
+
+// rabbitmqRotationCheckInterval is the interval of checks of the rotation in progress
+var rabbitmqRotationCheckInterval = 10 * time.Second
+
+// suffix of names of rotated users, it is replaced on each rotation
+var rabbitmqRotatedUserSuffix = regexp.MustCompile(`-rot[a-z]{6}$`)
+
+// rabbitmqExec runs commands in containers, it is replaced in unit tests
+var rabbitmqExec = ExecToContainer
+
+// rabbitmqctl runs rabbitmqctl command against the node of the pod, stdin is used to pass passwords
+func rabbitmqctl(pod *corev1.Pod, stdin string, args ...string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	command := "source /etc/rabbitmq/rabbitmq-common.env && source /etc/rabbitmq/rabbitmq-env.conf && " +
+		"rabbitmqctl --node $RABBITMQ_NODENAME " + strings.Join(quoted, " ")
+	stdout, stderr, err := rabbitmqExec(pod, "rabbitmq", []string{"/usr/bin/bash", "-c", command}, strings.NewReader(stdin))
+	if err != nil {
+		return stdout, fmt.Errorf("rabbitmqctl %s failed: %v (stderr=%s)", args[0], err, stderr)
+	}
+	return stdout, nil
+}
+
+// rabbitmqList returns the first column of rabbitmqctl list_* output
+func rabbitmqList(pod *corev1.Pod, args ...string) ([]string, error) {
+	out, err := rabbitmqctl(pod, "", append(args, "--quiet", "--no-table-headers")...)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, line := range strings.Split(out, "\n") {
+		if f := strings.Fields(line); len(f) > 0 {
+			res = append(res, f[0])
+		}
+	}
+	return res, nil
+}
+
+func countItems(items []string, item string) int {
+	n := 0
+	for _, i := range items {
+		if i == item {
+			n++
+		}
+	}
+	return n
+}
+
+// credentialsRotationDue returns the trigger of the rotation to start,
+// or the interval till the next scheduled rotation if it is not due
+func (c *Rabbitmq) credentialsRotationDue(now time.Time) (trigger string, due bool, next time.Duration) {
+	s := c.Status.CredentialsRotation
+	if s != nil {
+		trigger = s.Trigger
+	}
+	if v := c.Annotations[RabbitmqRotateCredentialsAnnotation]; v != "" && v != trigger {
+		return v, true, 0
+	}
+	interval := c.Spec.ServiceConfiguration.CredentialsRotationIntervalHours
+	if interval == nil || *interval <= 0 {
+		return trigger, false, 0
+	}
+	last := c.CreationTimestamp.Time
+	if s != nil && s.LastRotationTime != nil {
+		last = s.LastRotationTime.Time
+	}
+	if next = last.Add(time.Duration(*interval) * time.Hour).Sub(now); next <= 0 {
+		return trigger, true, 0
+	}
+	return trigger, false, next
+}
+
+// ReconcileCredentialsRotation rotates credentials without downtime of consumers:
+//   - a new user is added with the permissions of the current one
+//   - the secret is switched to the new user, dependent services re-render configs
+//     and reconnect as they are triggered by the change of Status.CredentialsVersion
+//   - the rotation waits till no connections of the previous user remain
+//   - the new user is verified and the previous user is removed
+//
+// Rotation is started by the change of RabbitmqRotateCredentialsAnnotation
+// or by CredentialsRotationIntervalHours. Credentials of CredentialsSource are
+// rotated in the source. One step is done per call, the progress is in the status.
+// Returns the interval to call it again, 0 if no rotation is scheduled.
+func (c *Rabbitmq) ReconcileCredentialsRotation(podList []corev1.Pod, secret *corev1.Secret, clnt client.Client) (time.Duration, error) {
+	if c.Spec.ServiceConfiguration.CredentialsSource != nil {
+		return 0, nil
+	}
+	s := c.Status.CredentialsRotation
+	if !s.InProgress() {
+		trigger, due, next := c.credentialsRotationDue(time.Now())
+		if !due {
+			return next, nil
+		}
+		return rabbitmqRotationCheckInterval, c.startCredentialsRotation(trigger, secret, clnt)
+	}
+	if len(podList) == 0 {
+		return rabbitmqRotationCheckInterval, nil
+	}
+	pod := &podList[0]
+
+	var phase, message string
+	var err error
+	switch s.Phase {
+	case RabbitmqRotationAddingUser:
+		phase, message, err = c.addRotatedUser(pod, secret, clnt)
+	case RabbitmqRotationReloadingConsumers:
+		phase, message, err = c.waitPreviousUserDisconnected(pod)
+	case RabbitmqRotationVerifying:
+		phase, message, err = c.verifyRotatedUser(pod, secret)
+	case RabbitmqRotationRemovingUser:
+		phase, message, err = c.removePreviousUser(pod)
+	default:
+		err = fmt.Errorf("unknown phase %s of credentials rotation", s.Phase)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if phase == s.Phase && message == s.Message {
+		return rabbitmqRotationCheckInterval, nil
+	}
+	s.Phase, s.Message = phase, message
+	if phase == RabbitmqRotationCompleted {
+		s.LastRotationTime = &metav1.Time{Time: time.Now()}
+		RecordEvent(c, corev1.EventTypeNormal, EventReasonCredentialsRotated, "Credentials are rotated to user %s", s.User)
+	}
+	if err = clnt.Status().Update(context.TODO(), c); err != nil {
+		return 0, err
+	}
+	if phase == RabbitmqRotationCompleted {
+		_, _, next := c.credentialsRotationDue(time.Now())
+		return next, nil
+	}
+	return rabbitmqRotationCheckInterval, nil
+}
+
+// startCredentialsRotation generates credentials of the new user and keeps the password in the secret
+func (c *Rabbitmq) startCredentialsRotation(trigger string, secret *corev1.Secret, clnt client.Client) error {
+	previous := string(secret.Data["user"])
+	user := rabbitmqRotatedUserSuffix.ReplaceAllString(previous, "") + "-rot" + strings.ToLower(randomstring.RandString{Size: 6}.Generate())
+	secret.Data[rabbitmqRotationPasswordKey] = []byte(randomstring.RandString{Size: 32}.Generate())
+	if err := clnt.Update(context.TODO(), secret); err != nil {
+		return err
+	}
+	var last *metav1.Time
+	if c.Status.CredentialsRotation != nil {
+		last = c.Status.CredentialsRotation.LastRotationTime
+	}
+	c.Status.CredentialsRotation = &RabbitmqCredentialsRotationStatus{
+		Phase:            RabbitmqRotationAddingUser,
+		User:             user,
+		PreviousUser:     previous,
+		Trigger:          trigger,
+		StartTime:        &metav1.Time{Time: time.Now()},
+		LastRotationTime: last,
+		Message:          "Adding user " + user,
+	}
+	RecordEvent(c, corev1.EventTypeNormal, EventReasonCredentialsRotationStarted, "Rotation of credentials of user %s is started", previous)
+	return clnt.Status().Update(context.TODO(), c)
+}
+
+// addRotatedUser adds the new user with the permissions of the current one and switches the secret to it
+func (c *Rabbitmq) addRotatedUser(pod *corev1.Pod, secret *corev1.Secret, clnt client.Client) (string, string, error) {
+	s := c.Status.CredentialsRotation
+	password := secret.Data[rabbitmqRotationPasswordKey]
+	if len(password) == 0 && string(secret.Data["user"]) == s.User {
+		// the secret is switched but the status is not updated by the previous attempt
+		return RabbitmqRotationReloadingConsumers, "Waiting for consumers to reconnect with user " + s.User, nil
+	}
+	if len(password) == 0 {
+		return "", "", fmt.Errorf("no password of user %s in secret %s", s.User, secret.Name)
+	}
+	users, err := rabbitmqList(pod, "list_users")
+	if err != nil {
+		return "", "", err
+	}
+	// the user may be added by the previous attempt
+	if countItems(users, s.User) == 0 {
+		_, err = rabbitmqctl(pod, string(password), "add_user", s.User)
+	} else {
+		_, err = rabbitmqctl(pod, string(password), "change_password", s.User)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	vhost := string(secret.Data["vhost"])
+	if _, err = rabbitmqctl(pod, "", "set_permissions", "-p", vhost, s.User, ".*", ".*", ".*"); err != nil {
+		return "", "", err
+	}
+	if _, err = rabbitmqctl(pod, "", "set_user_tags", s.User, "administrator"); err != nil {
+		return "", "", err
+	}
+
+	salted, err := rabbitmqSaltedPassword(password)
+	if err != nil {
+		return "", "", err
+	}
+	secret.Data["user"] = []byte(s.User)
+	secret.Data["password"] = password
+	secret.Data["salted_password"] = salted
+	delete(secret.Data, rabbitmqRotationPasswordKey)
+	if err = clnt.Update(context.TODO(), secret); err != nil {
+		return "", "", err
+	}
+	c.Status.CredentialsVersion = CredentialsVersion(secret.Data, "user", "password", "vhost")
+	return RabbitmqRotationReloadingConsumers, "Waiting for consumers to reconnect with user " + s.User, nil
+}
+
+// waitPreviousUserDisconnected waits till consumers reconnect with the new user
+func (c *Rabbitmq) waitPreviousUserDisconnected(pod *corev1.Pod) (string, string, error) {
+	s := c.Status.CredentialsRotation
+	connections, err := rabbitmqList(pod, "list_connections", "user")
+	if err != nil {
+		return "", "", err
+	}
+	if n := countItems(connections, s.PreviousUser); n > 0 {
+		return s.Phase, fmt.Sprintf("%d connections of user %s remain", n, s.PreviousUser), nil
+	}
+	return RabbitmqRotationVerifying, "Verifying connections of user " + s.User, nil
+}
+
+// verifyRotatedUser checks the new user is authenticated and consumers are connected with it
+func (c *Rabbitmq) verifyRotatedUser(pod *corev1.Pod, secret *corev1.Secret) (string, string, error) {
+	s := c.Status.CredentialsRotation
+	if _, err := rabbitmqctl(pod, string(secret.Data["password"]), "authenticate_user", s.User); err != nil {
+		return s.Phase, fmt.Sprintf("User %s is not authenticated: %v", s.User, err), nil
+	}
+	connections, err := rabbitmqList(pod, "list_connections", "user")
+	if err != nil {
+		return "", "", err
+	}
+	if len(connections) > 0 && countItems(connections, s.User) == 0 {
+		return s.Phase, "No connections of user " + s.User, nil
+	}
+	return RabbitmqRotationRemovingUser, "Removing user " + s.PreviousUser, nil
+}
+
+// removePreviousUser removes the previous user
+func (c *Rabbitmq) removePreviousUser(pod *corev1.Pod) (string, string, error) {
+	s := c.Status.CredentialsRotation
+	users, err := rabbitmqList(pod, "list_users")
+	if err != nil {
+		return "", "", err
+	}
+	if countItems(users, s.PreviousUser) > 0 {
+		if _, err = rabbitmqctl(pod, "", "delete_user", s.PreviousUser); err != nil {
+			return "", "", err
+		}
+	}
+	return RabbitmqRotationCompleted, "Credentials are rotated to user " + s.User, nil
+}
@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeRabbitmq serves rabbitmqctl commands of the credentials rotation
+type fakeRabbitmq struct {
+	users       map[string]string
+	connections []string
+}
+
+func (f *fakeRabbitmq) exec(pod *corev1.Pod, container string, command []string, stdin io.Reader) (string, string, error) {
+	cmd := command[len(command)-1]
+	cmd = cmd[strings.Index(cmd, "$RABBITMQ_NODENAME ")+len("$RABBITMQ_NODENAME '") : len(cmd)-1]
+	args := strings.Split(cmd, "' '")
+	input, _ := ioutil.ReadAll(stdin)
+	switch args[0] {
+	case "list_users":
+		var out []string
+		for u := range f.users {
+			out = append(out, u+"\t[administrator]")
+		}
+		return strings.Join(out, "\n"), "", nil
+	case "list_connections":
+		return strings.Join(f.connections, "\n"), "", nil
+	case "add_user", "change_password":
+		f.users[args[1]] = string(input)
+	case "authenticate_user":
+		if p, ok := f.users[args[1]]; !ok || p != string(input) {
+			return "", "Error: failed to authenticate", fmt.Errorf("exit code 1")
+		}
+	case "delete_user":
+		delete(f.users, args[1])
+	case "set_permissions", "set_user_tags":
+	default:
+		return "", "", fmt.Errorf("unexpected command %v", args)
+	}
+	return "", "", nil
+}
+
+func TestRabbitmqCredentialsRotation(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	rabbitmq := &fakeRabbitmq{users: map[string]string{"tf": "password1"}, connections: []string{"tf", "tf"}}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) { rabbitmqExec = exec }(rabbitmqExec)
+	rabbitmqExec = rabbitmq.exec
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq1-secret", Namespace: "test-ns"},
+		Data:       map[string][]byte{"user": []byte("tf"), "password": []byte("password1"), "vhost": []byte("tf")},
+	}
+	instance := &Rabbitmq{ObjectMeta: metav1.ObjectMeta{
+		Name:        "rabbitmq1",
+		Namespace:   "test-ns",
+		Annotations: map[string]string{RabbitmqRotateCredentialsAnnotation: "2021-01-01"},
+	}}
+	instance.Spec.ServiceConfiguration.User = "tf"
+	instance.Spec.ServiceConfiguration.Password = "password1"
+	cl := fake.NewFakeClientWithScheme(scheme, instance, secret)
+	pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq1-rabbitmq-statefulset-0", Namespace: "test-ns"}}}
+
+	step := func(phase string) {
+		_, err := instance.ReconcileCredentialsRotation(pods, secret, cl)
+		require.NoError(t, err)
+		require.Equal(t, phase, instance.Status.CredentialsRotation.Phase, instance.Status.CredentialsRotation.Message)
+	}
+
+	step(RabbitmqRotationAddingUser)
+	rotation := instance.Status.CredentialsRotation
+	assert.Regexp(t, "^tf-rot[a-z]{6}$", rotation.User)
+	assert.Equal(t, "tf", rotation.PreviousUser)
+	assert.NotEmpty(t, secret.Data[rabbitmqRotationPasswordKey])
+
+	step(RabbitmqRotationReloadingConsumers)
+	assert.Equal(t, rotation.User, string(secret.Data["user"]))
+	assert.Equal(t, rabbitmq.users[rotation.User], string(secret.Data["password"]))
+	assert.NotContains(t, secret.Data, rabbitmqRotationPasswordKey)
+	assert.Equal(t, CredentialsVersion(secret.Data, "user", "password", "vhost"), instance.Status.CredentialsVersion)
+
+	updated, err := instance.UpdateSecret(secret, cl)
+	require.NoError(t, err)
+	assert.False(t, updated, "rotated credentials are not replaced by the spec")
+
+	step(RabbitmqRotationReloadingConsumers)
+	assert.Equal(t, "2 connections of user tf remain", rotation.Message)
+
+	rabbitmq.connections = []string{rotation.User, rotation.User}
+	step(RabbitmqRotationVerifying)
+	step(RabbitmqRotationRemovingUser)
+	step(RabbitmqRotationCompleted)
+	assert.NotContains(t, rabbitmq.users, "tf")
+	assert.Contains(t, rabbitmq.users, rotation.User)
+	assert.NotNil(t, rotation.LastRotationTime)
+
+	next, err := instance.ReconcileCredentialsRotation(pods, secret, cl)
+	require.NoError(t, err)
+	assert.Zero(t, next, "no rotation until the annotation is changed")
+	assert.Equal(t, RabbitmqRotationCompleted, instance.Status.CredentialsRotation.Phase)
+
+	interval := 24
+	instance.Spec.ServiceConfiguration.CredentialsRotationIntervalHours = &interval
+	next, err = instance.ReconcileCredentialsRotation(pods, secret, cl)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(24*time.Hour), float64(next), float64(time.Minute))
+
+	rotation.LastRotationTime = &metav1.Time{Time: time.Now().Add(-25 * time.Hour)}
+	step(RabbitmqRotationAddingUser)
+	assert.Equal(t, rotation.User, instance.Status.CredentialsRotation.PreviousUser)
+	assert.Regexp(t, "^tf-rot[a-z]{6}$", instance.Status.CredentialsRotation.User, "suffix of the rotated user is replaced")
+}
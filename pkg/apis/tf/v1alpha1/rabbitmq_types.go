@@ -58,6 +58,13 @@ type RabbitmqConfiguration struct {
 	User         string       `json:"user,omitempty"`
 	Password     string       `json:"password,omitempty"`
 	Secret       string       `json:"secret,omitempty"`
+	// CredentialsSource is the source of user, password and optional vhost keys,
+	// it takes precedence over User, Password and Vhost
+	CredentialsSource *CredentialsSource `json:"credentialsSource,omitempty"`
+	// CredentialsRotationIntervalHours is the period of rotation of credentials,
+	// they are rotated on change of tf.tungsten.io/rotate-credentials annotation if not set
+	// +kubebuilder:validation:Minimum=1
+	CredentialsRotationIntervalHours *int `json:"credentialsRotationIntervalHours,omitempty"`
 	// +kubebuilder:validation:Enum=exactly;all;nodes
 	MirroredQueueMode        *string                 `json:"mirroredQueueMode,omitempty"`
 	ClusterPartitionHandling *string                 `json:"clusterPartitionHandling,omitempty"`
@@ -68,6 +75,10 @@ type RabbitmqConfiguration struct {
 type RabbitmqStatus struct {
 	CommonStatus `json:",inline"`
 	Secret       string `json:"secret,omitempty"`
+	// CredentialsVersion is the hash of credentials in the secret, dependent services re-render configs when it is changed
+	CredentialsVersion string `json:"credentialsVersion,omitempty"`
+	// CredentialsRotation is the progress of the last rotation of credentials
+	CredentialsRotation *RabbitmqCredentialsRotationStatus `json:"credentialsRotation,omitempty"`
 }
 
 // TCPListenOptionsConfig is configuration for RabbitMQ TCP listen
@@ -207,7 +218,8 @@ func setRandomField(data map[string][]byte, field string, value string, size int
 	return data
 }
 
-// UpdateSecret
+// UpdateSecret fills the secret with credentials from the credentials source, the spec or random ones,
+// rotated credentials are kept in the secret
 func (c *Rabbitmq) UpdateSecret(secret *corev1.Secret, client client.Client) (updated bool, err error) {
 	updated, err = false, nil
 
@@ -219,23 +231,27 @@ func (c *Rabbitmq) UpdateSecret(secret *corev1.Secret, client client.Client) (up
 		oldData[k] = v
 	}
 
-	setRandomField(secret.Data, "user", c.Spec.ServiceConfiguration.User, 8)
-	setRandomField(secret.Data, "password", c.Spec.ServiceConfiguration.Password, 32)
-	setRandomField(secret.Data, "vhost", c.Spec.ServiceConfiguration.Vhost, 6)
-
-	if old, ok := oldData["password"]; !ok || string(old) != string(secret.Data["password"]) {
-		salt := [4]byte{}
-		if _, err = rand.Read(salt[:]); err != nil {
+	user, password, vhost := c.Spec.ServiceConfiguration.User, c.Spec.ServiceConfiguration.Password, c.Spec.ServiceConfiguration.Vhost
+	if source := c.Spec.ServiceConfiguration.CredentialsSource; source != nil {
+		var data map[string][]byte
+		if data, err = source.Credentials(c.Namespace, client, "user", "password"); err != nil {
 			return
 		}
-		saltedP := append(salt[:], secret.Data["password"]...)
-		hash := sha256.New()
-		if _, err = hash.Write(saltedP); err != nil {
+		user, password = string(data["user"]), string(data["password"])
+		if v, ok := data["vhost"]; ok && len(v) > 0 {
+			vhost = string(v)
+		}
+	} else if c.Status.CredentialsRotation.Rotated() {
+		user, password = string(oldData["user"]), string(oldData["password"])
+	}
+	setRandomField(secret.Data, "user", user, 8)
+	setRandomField(secret.Data, "password", password, 32)
+	setRandomField(secret.Data, "vhost", vhost, 6)
+
+	if old, ok := oldData["password"]; !ok || string(old) != string(secret.Data["password"]) {
+		if secret.Data["salted_password"], err = rabbitmqSaltedPassword(secret.Data["password"]); err != nil {
 			return
 		}
-		hashPass := hash.Sum(nil)
-		saltedP = append(salt[:], hashPass...)
-		secret.Data["salted_password"] = saltedP
 	}
 
 	if reflect.DeepEqual(oldData, secret.Data) {
@@ -249,6 +265,21 @@ func (c *Rabbitmq) UpdateSecret(secret *corev1.Secret, client client.Client) (up
 	return
 }
 
+// rabbitmqSaltedPassword returns the salted SHA-256 hash of the password for definitions.json
+func rabbitmqSaltedPassword(password []byte) ([]byte, error) {
+	salt := [4]byte{}
+	if _, err := rand.Read(salt[:]); err != nil {
+		return nil, err
+	}
+	saltedP := append(salt[:], password...)
+	hash := sha256.New()
+	if _, err := hash.Write(saltedP); err != nil {
+		return nil, err
+	}
+	hashPass := hash.Sum(nil)
+	return append(salt[:], hashPass...), nil
+}
+
 func (c *Rabbitmq) CreateConfigMap(configMapName string,
 	client client.Client,
 	scheme *runtime.Scheme,
@@ -345,6 +376,11 @@ func (c *Rabbitmq) SetInstanceActive(client client.Client, activeStatus *bool, d
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Rabbitmq) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 func (c *Rabbitmq) ManageNodeStatus(nodes map[string]NodeInfo,
 	client client.Client) (updated bool, err error) {
 	updated = false
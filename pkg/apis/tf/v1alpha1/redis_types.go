@@ -44,12 +44,14 @@ type RedisConfiguration struct {
 	Containers  []*Container `json:"containers,omitempty"`
 	ClusterName string       `json:"clusterName,omitempty"`
 	RedisPort   *int         `json:"redisPort,omitempty"`
+	Storage     *Storage     `json:"storage,omitempty"`
 }
 
 // RedisStatus defines the status of the redis object.
 // +k8s:openapi-gen=true
 type RedisStatus struct {
 	CommonStatus `json:",inline"`
+	Storage      *StorageStatus `json:"storage,omitempty"`
 }
 
 // RedisList contains a list of Redis.
@@ -124,7 +126,7 @@ func (c *Redis) CreateConfigMap(configMapName string,
 		return nil, err
 	}
 
-	if err = client.Update(context.TODO(), configMap); err != nil {
+	if err = saveConfigMap(configMap, false, client); err != nil {
 		return nil, err
 	}
 	return configMap, nil
@@ -161,12 +163,18 @@ func (c *Redis) SetInstanceActive(client client.Client, activeStatus *bool, degr
 	}
 	*activeStatus = sts.Status.ReadyReplicas >= *sts.Spec.Replicas/2+1
 	*degradedStatus = sts.Status.ReadyReplicas < *sts.Spec.Replicas
+	c.Status.SetStatefulSetConditions(c.Generation, sts)
 	if err := client.Status().Update(context.TODO(), c); err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Redis) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // PodIPListAndIPMapFromInstance gets a list with POD IPs and a map of POD names and IPs.
 func (c *Redis) PodIPListAndIPMapFromInstance(instanceType string, request reconcile.Request, reconcileClient client.Client) ([]corev1.Pod, map[string]NodeInfo, error) {
 	return PodIPListAndIPMapFromInstance(instanceType, request, reconcileClient, "")
@@ -0,0 +1,150 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodHealthCheck checks that the service of the ready pod is healthy, e.g. its API responds
+type PodHealthCheck func(pod *corev1.Pod) (bool, error)
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// RestartOnDeletePods restarts pods of the OnDelete statefulset of the instance which are not
+// of the update revision of the statefulset. Pods are deleted one by one from the highest ordinal,
+// the next pod is deleted when the restarted one is ready and healthy, so that no more than
+// maxUnavailable (1 by default) pods are unavailable at once. Pods which are not ready
+// are restarted without waiting as they are unavailable anyway.
+// Pods being restarted are kept in RestartingPods of the status.
+// Returns true if the restart is in progress.
+func RestartOnDeletePods(instance StatusObject, instanceType string, maxUnavailable *int, healthy PodHealthCheck, clnt client.Client) (bool, error) {
+	ll := log.WithName("RestartOnDeletePods").WithName(instance.GetName())
+	status := instance.GetCommonStatus()
+
+	sts, err := QuerySTS(instance.GetName()+"-"+instanceType+"-statefulset", instance.GetNamespace(), clnt)
+	if err != nil {
+		return false, err
+	}
+	if sts.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
+		status.RestartingPods = nil
+		return false, nil
+	}
+	if sts.Status.UpdateRevision == "" {
+		// pods are not created by the statefulset controller yet
+		return false, nil
+	}
+	if sts.Status.ObservedGeneration < sts.Generation {
+		// the revision of the updated template is not known yet
+		return true, nil
+	}
+
+	podList := &corev1.PodList{}
+	if err = clnt.List(context.TODO(), podList, listOptions(instance.GetName(), instanceType, instance.GetNamespace())); err != nil {
+		return false, err
+	}
+	pods := make(map[string]*corev1.Pod)
+	for idx := range podList.Items {
+		pods[podList.Items[idx].Name] = &podList.Items[idx]
+	}
+	updated := func(pod *corev1.Pod) bool {
+		return pod.DeletionTimestamp == nil && pod.Labels[appsv1.StatefulSetRevisionLabel] == sts.Status.UpdateRevision
+	}
+
+	// restarted pods are done when they are back ready and healthy
+	var restarting []string
+	for _, name := range status.RestartingPods {
+		if pod, ok := pods[name]; ok && updated(pod) && isPodReady(pod) {
+			done := true
+			if healthy != nil {
+				if done, err = healthy(pod); err != nil {
+					ll.Info("Failed to check health of the pod", "pod", name, "err", err)
+					done = false
+				}
+			}
+			if done {
+				ll.Info("Pod is restarted", "pod", name)
+				continue
+			}
+		}
+		restarting = append(restarting, name)
+	}
+
+	unavailable := len(restarting)
+	if sts.Spec.Replicas != nil {
+		for i := 0; i < int(*sts.Spec.Replicas); i++ {
+			name := fmt.Sprintf("%s-%d", sts.Name, i)
+			if _, ok := pods[name]; !ok && !isRestarting(restarting, name) {
+				unavailable++
+			}
+		}
+	}
+	var outdated []*corev1.Pod
+	for _, pod := range pods {
+		if isRestarting(restarting, pod.Name) {
+			continue
+		}
+		if !isPodReady(pod) {
+			unavailable++
+		}
+		if pod.DeletionTimestamp == nil && !updated(pod) {
+			outdated = append(outdated, pod)
+		}
+	}
+	sort.Slice(outdated, func(i, j int) bool {
+		// pods which are not ready go first
+		if ri, rj := isPodReady(outdated[i]), isPodReady(outdated[j]); ri != rj {
+			return rj
+		}
+		idi, _ := getPodId(outdated[i])
+		idj, _ := getPodId(outdated[j])
+		return idi > idj
+	})
+
+	limit := 1
+	if maxUnavailable != nil && *maxUnavailable > 0 {
+		limit = *maxUnavailable
+	}
+	for _, pod := range outdated {
+		ready := isPodReady(pod)
+		if ready && unavailable >= limit {
+			break
+		}
+		ll.Info("Restart pod", "pod", pod.Name, "revision", pod.Labels[appsv1.StatefulSetRevisionLabel], "updateRevision", sts.Status.UpdateRevision)
+		if err = clnt.Delete(context.TODO(), pod); err != nil && !k8serrors.IsNotFound(err) {
+			return true, err
+		}
+		if ready {
+			unavailable++
+		}
+		restarting = append(restarting, pod.Name)
+	}
+
+	sort.Strings(restarting)
+	status.RestartingPods = restarting
+	return len(restarting) > 0 || len(outdated) > 0, nil
+}
+
+func isRestarting(restarting []string, name string) bool {
+	for _, n := range restarting {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
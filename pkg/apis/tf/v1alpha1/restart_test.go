@@ -0,0 +1,193 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func restartTestPod(id int, revision string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("config1-config-statefulset-%d", id),
+			Namespace: "tf",
+			Labels: map[string]string{
+				"tf_manager":                    "config",
+				"config":                        "config1",
+				appsv1.StatefulSetRevisionLabel: revision,
+			},
+		},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func podExists(cl client.Client, id int) bool {
+	pod := &corev1.Pod{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("config1-config-statefulset-%d", id), Namespace: "tf"}, pod)
+	return err == nil
+}
+
+func TestRestartOnDeletePods(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "config1-config-statefulset", Namespace: "tf"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{CurrentRevision: "rev1", UpdateRevision: "rev2"},
+	}
+	instance := &Config{ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: "tf"}}
+	cl := fake.NewFakeClientWithScheme(scheme, sts, instance,
+		restartTestPod(0, "rev1", true), restartTestPod(1, "rev1", false), restartTestPod(2, "rev1", true))
+
+	healthy := false
+	check := func(pod *corev1.Pod) (bool, error) { return healthy, nil }
+	restart := func() bool {
+		restarting, err := RestartOnDeletePods(instance, "config", nil, check, cl)
+		require.NoError(t, err)
+		return restarting
+	}
+
+	assert.True(t, restart())
+	assert.Equal(t, []string{"config1-config-statefulset-1"}, instance.Status.RestartingPods,
+		"not ready pod is restarted first, the ready one waits as a pod is unavailable")
+	assert.True(t, podExists(cl, 2))
+
+	require.NoError(t, cl.Create(context.TODO(), restartTestPod(1, "rev2", true)))
+	assert.True(t, restart())
+	assert.Equal(t, []string{"config1-config-statefulset-1"}, instance.Status.RestartingPods, "restarted pod is not healthy yet")
+
+	healthy = true
+	assert.True(t, restart())
+	assert.Equal(t, []string{"config1-config-statefulset-2"}, instance.Status.RestartingPods, "pods are restarted from the highest ordinal")
+	assert.False(t, podExists(cl, 2))
+	assert.True(t, podExists(cl, 0))
+
+	assert.True(t, restart())
+	assert.True(t, podExists(cl, 0), "one pod is unavailable at once")
+
+	require.NoError(t, cl.Create(context.TODO(), restartTestPod(2, "rev2", true)))
+	assert.True(t, restart())
+	assert.Equal(t, []string{"config1-config-statefulset-0"}, instance.Status.RestartingPods)
+
+	require.NoError(t, cl.Create(context.TODO(), restartTestPod(0, "rev2", true)))
+	assert.False(t, restart())
+	assert.Empty(t, instance.Status.RestartingPods)
+
+	// all outdated ready pods are restarted at once if max unavailable allows
+	sts.Status.UpdateRevision = "rev3"
+	require.NoError(t, cl.Status().Update(context.TODO(), sts))
+	maxUnavailable := 2
+	restarting, err := RestartOnDeletePods(instance, "config", &maxUnavailable, check, cl)
+	require.NoError(t, err)
+	assert.True(t, restarting)
+	assert.Equal(t, []string{"config1-config-statefulset-1", "config1-config-statefulset-2"}, instance.Status.RestartingPods)
+}
+
+func TestParseNodemgrFunctional(t *testing.T) {
+	functional, _, err := parseNodemgrFunctional([]byte(`<__SandeshUVECacheResp><NodeStatusUVE><data><NodeStatus><process_status><list>
+<ProcessStatus><module_id>contrail-api</module_id><state>Functional</state></ProcessStatus>
+<ProcessStatus><module_id>contrail-schema</module_id><state>Functional</state></ProcessStatus>
+</list></process_status></NodeStatus></data></NodeStatusUVE></__SandeshUVECacheResp>`))
+	require.NoError(t, err)
+	assert.True(t, functional)
+
+	functional, message, err := parseNodemgrFunctional([]byte(`<NodeStatus><process_status><list>
+<ProcessStatus><module_id>contrail-api</module_id><state>Functional</state></ProcessStatus>
+<ProcessStatus><module_id>contrail-svc-monitor</module_id><state>Non-Functional</state></ProcessStatus>
+</list></process_status></NodeStatus>`))
+	require.NoError(t, err)
+	assert.False(t, functional)
+	assert.Equal(t, "contrail-svc-monitor is Non-Functional", message)
+
+	functional, _, err = parseNodemgrFunctional([]byte(`<NodeStatus></NodeStatus>`))
+	require.NoError(t, err)
+	assert.False(t, functional, "nodemgr which reports nothing is not functional")
+}
+
+func TestPodHealthy(t *testing.T) {
+	functional := `<NodeStatus><process_status><list>
+<ProcessStatus><module_id>contrail-analytics-api</module_id><state>Functional</state></ProcessStatus>
+</list></process_status></NodeStatus>`
+	backup := `<NodeStatus><process_status><list>
+<ProcessStatus><module_id>contrail-kube-manager</module_id><state>Non-Functional</state><description>Backup</description></ProcessStatus>
+</list></process_status></NodeStatus>`
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}}
+
+	var requests []string
+	apiCode, nodeStatus := 200, functional
+	defer func(f func(*corev1.Pod, string, string) (string, int, error)) { curl = f }(curl)
+	curl = func(pod *corev1.Pod, container, url string) (string, int, error) {
+		requests = append(requests, container+" "+url)
+		if strings.HasSuffix(url, "NodeStatus") {
+			return nodeStatus, 200, nil
+		}
+		return "", apiCode, nil
+	}
+
+	tests := []struct {
+		name     string
+		healthy  func(*corev1.Pod) (bool, error)
+		requests []string
+	}{
+		{"analytics", (&Analytics{}).PodHealthy, []string{
+			"analyticsapi https://10.0.0.1:8081/",
+			"nodemanager https://10.0.0.1:8104/Snh_SandeshUVECacheReq?x=NodeStatus"}},
+		{"analyticsalarm", (&AnalyticsAlarm{}).PodHealthy, []string{
+			"analytics-alarm-gen https://10.0.0.1:5995/",
+			"nodemanager https://10.0.0.1:8113/Snh_SandeshUVECacheReq?x=NodeStatus"}},
+		{"analyticssnmp", (&AnalyticsSnmp{}).PodHealthy, []string{
+			"analytics-snmp-collector https://10.0.0.1:5920/",
+			"nodemanager https://10.0.0.1:8114/Snh_SandeshUVECacheReq?x=NodeStatus"}},
+		{"queryengine", (&QueryEngine{}).PodHealthy, []string{
+			"queryengine https://10.0.0.1:8091/",
+			"queryengine https://10.0.0.1:8091/Snh_SandeshUVECacheReq?x=NodeStatus"}},
+		{"kubemanager", (&Kubemanager{}).PodHealthy, []string{
+			"kubemanager https://10.0.0.1:8108/",
+			"kubemanager https://10.0.0.1:8108/Snh_SandeshUVECacheReq?x=NodeStatus"}},
+		{"webui", (&Webui{}).PodHealthy, []string{"webuiweb https://10.0.0.1:8143/"}},
+	}
+	for _, tt := range tests {
+		requests = nil
+		apiCode, nodeStatus = 200, functional
+		healthy, err := tt.healthy(pod)
+		require.NoError(t, err, tt.name)
+		assert.True(t, healthy, tt.name)
+		assert.Equal(t, tt.requests, requests, tt.name)
+
+		apiCode = 503
+		healthy, err = tt.healthy(pod)
+		require.NoError(t, err, tt.name)
+		assert.False(t, healthy, tt.name+": API is not responding")
+	}
+
+	apiCode, nodeStatus = 200, backup
+	healthy, err := (&Kubemanager{}).PodHealthy(pod)
+	require.NoError(t, err)
+	assert.True(t, healthy, "backup kube-manager is healthy")
+
+	nodeStatus = strings.Replace(functional, "<state>Functional", "<state>Non-Functional", 1)
+	healthy, err = (&Analytics{}).PodHealthy(pod)
+	require.NoError(t, err)
+	assert.False(t, healthy, "analytics with non functional process is not healthy")
+}
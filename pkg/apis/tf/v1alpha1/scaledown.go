@@ -0,0 +1,263 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScaleDownStatus is the progress of the scale-down of the statefulset of the instance.
+// +k8s:openapi-gen=true
+type ScaleDownStatus struct {
+	// Replicas is the number of replicas the statefulset is scaled down to
+	Replicas int32 `json:"replicas"`
+	// Phase is the step of the scale-down, RemovingMembers or DeletingPods
+	Phase string `json:"phase"`
+	// Members are the pods out of replicas which are removed from the cluster of the service
+	Members []ScaleDownMember `json:"members,omitempty"`
+}
+
+// ScaleDownMember is the state of the removal of the pod from the cluster of the service.
+// +k8s:openapi-gen=true
+type ScaleDownMember struct {
+	Pod     string `json:"pod"`
+	Removed bool   `json:"removed,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Phases of the scale-down
+const (
+	ScaleDownRemovingMembers = "RemovingMembers"
+	ScaleDownDeletingPods    = "DeletingPods"
+)
+
+// String describes the step of the scale-down in progress
+func (s *ScaleDownStatus) String() string {
+	message := fmt.Sprintf("Scale down to %d replicas: %s", s.Replicas, s.Phase)
+	for _, m := range s.Members {
+		if !m.Removed {
+			message += fmt.Sprintf(", %s is not removed yet", m.Pod)
+			if m.Message != "" {
+				message += " (" + m.Message + ")"
+			}
+			break
+		}
+	}
+	return message
+}
+
+// MemberRemoval removes the pod out of replicas from the cluster of the service before the pod is deleted,
+// the pods which remain are passed to query the cluster.
+// Returns true when the pod is not a member of the cluster anymore, the message describes the step in progress.
+type MemberRemoval func(pod *corev1.Pod, remaining []corev1.Pod) (removed bool, message string, err error)
+
+// ScaleDownSTS reduces replicas of the statefulset of the instance to the intended replicas,
+// the configured replicas or the number of nodes matching the nodeSelector if they are not set.
+// Replicas following the number of nodes are not reduced if ScaleDownOnNodeRemoval is disabled.
+// Pods leaving the cluster of the service are chosen by scaleDownMembers, they are removed from
+// the cluster one by one and then leaving pods of lower ordinals are deleted to be re-created
+// on the nodes which are left and the replicas are lowered. The started scale-down is completed even
+// if replicas are raised back, they are raised by UpdateSTS afterwards. Progress is kept in ScaleDown of the status.
+// Returns true if the scale-down is in progress.
+func ScaleDownSTS(instance StatusObject, instanceType string, configuration *PodConfiguration, removeMember MemberRemoval, clnt client.Client) (bool, error) {
+	ll := log.WithName("ScaleDownSTS").WithName(instance.GetName())
+	status := instance.GetCommonStatus()
+
+	sts, err := QuerySTS(instance.GetName()+"-"+instanceType+"-statefulset", instance.GetNamespace(), clnt)
+	if err != nil {
+		return false, err
+	}
+	if sts.Spec.Replicas == nil {
+		return false, nil
+	}
+	current := *sts.Spec.Replicas
+	replicas := current
+	if configuration.Replicas != nil || configuration.ScaleDownOnNodeRemoval == nil || *configuration.ScaleDownOnNodeRemoval {
+		intended, err := GetSTSReplicas(clnt, configuration.Replicas, sts.Spec.Template.Spec.NodeSelector)
+		if err != nil {
+			return false, err
+		}
+		if intended > 0 && intended < replicas {
+			replicas = intended
+		}
+	}
+	if status.ScaleDown != nil && status.ScaleDown.Replicas < replicas {
+		replicas = status.ScaleDown.Replicas
+	}
+
+	podList := &corev1.PodList{}
+	if err = clnt.List(context.TODO(), podList, listOptions(instance.GetName(), instanceType, instance.GetNamespace())); err != nil {
+		return false, err
+	}
+	pods := make(map[string]*corev1.Pod)
+	for idx := range podList.Items {
+		pods[podList.Items[idx].Name] = &podList.Items[idx]
+	}
+
+	if replicas >= current {
+		if status.ScaleDown == nil {
+			return false, nil
+		}
+		for _, pod := range pods {
+			if id, err := getPodId(pod); err == nil && id >= int(replicas) {
+				// pods out of replicas are being deleted
+				return true, nil
+			}
+		}
+		ll.Info("Scale-down is completed", "replicas", current)
+		RecordEvent(instance, corev1.EventTypeNormal, EventReasonScaledDown, "Scaled down to %d replicas", current)
+		status.ScaleDown = nil
+		return false, nil
+	}
+
+	if status.ScaleDown == nil || status.ScaleDown.Replicas != replicas {
+		nodes, err := GetNodes(sts.Spec.Template.Spec.NodeSelector, clnt)
+		if err != nil {
+			return false, err
+		}
+		var started []ScaleDownMember
+		if status.ScaleDown != nil {
+			started = status.ScaleDown.Members
+		}
+		members := scaleDownMembers(sts.Name, current, replicas, pods, nodes, started)
+		ll.Info("Start scale-down", "current", current, "replicas", replicas, "members", members)
+		RecordEvent(instance, corev1.EventTypeNormal, EventReasonScaleDownStarted, "Scale down from %d to %d replicas", current, replicas)
+		status.ScaleDown = &ScaleDownStatus{Replicas: replicas, Phase: ScaleDownRemovingMembers, Members: members}
+	}
+
+	leaving := make(map[string]bool)
+	for _, m := range status.ScaleDown.Members {
+		leaving[m.Pod] = true
+	}
+	var remaining []corev1.Pod
+	for _, pod := range podList.Items {
+		if !leaving[pod.Name] {
+			remaining = append(remaining, pod)
+		}
+	}
+
+	pending := false
+	for idx := range status.ScaleDown.Members {
+		m := &status.ScaleDown.Members[idx]
+		pod := pods[m.Pod]
+		switch {
+		case m.Removed || pending:
+		case pod == nil:
+			m.Removed, m.Message = true, "Pod does not exist"
+		case removeMember == nil:
+			m.Removed = true
+		default:
+			// one member at a time, the rest on next reconciles
+			removed, message, err := removeMember(pod, remaining)
+			if err != nil {
+				ll.Info("Failed to remove member", "pod", m.Pod, "err", err)
+				removed, message = false, err.Error()
+			}
+			m.Removed, m.Message = removed, message
+			if removed {
+				ll.Info("Member is removed", "pod", m.Pod)
+			}
+		}
+		pending = pending || !m.Removed
+	}
+	if pending {
+		return true, nil
+	}
+
+	for _, m := range status.ScaleDown.Members {
+		pod, ok := pods[m.Pod]
+		if !ok {
+			continue
+		}
+		if id, err := getPodId(pod); err != nil || id >= int(replicas) {
+			continue
+		}
+		ll.Info("Delete pod to re-create it on a node which is left", "pod", m.Pod, "node", pod.Spec.NodeName)
+		if err = clnt.Delete(context.TODO(), pod); err != nil && !k8serrors.IsNotFound(err) {
+			return true, err
+		}
+	}
+	ll.Info("Reduce replicas", "current", current, "replicas", replicas)
+	sts.Spec.Replicas = &replicas
+	if err = clnt.Update(context.TODO(), sts); err != nil {
+		return true, err
+	}
+	status.ScaleDown.Phase = ScaleDownDeletingPods
+	return true, nil
+}
+
+// scaleDownMembers chooses pods leaving the cluster of the service when the statefulset is scaled down
+// from current to replicas. Pods on departed nodes, i.e. not scheduled or on nodes which are gone or don't match
+// the node selector anymore, are chosen first and then pods of the highest ordinals. The statefulset deletes
+// pods of the highest ordinals, so when a pod of a lower ordinal is chosen the pods out of replicas leave the cluster
+// too and the node of such a pod is re-joined by the pod of the lower ordinal re-created there.
+// Members of the started scale-down keep their state, the order of removal is the order of the choice.
+func scaleDownMembers(stsName string, current, replicas int32, pods map[string]*corev1.Pod, nodes []corev1.Node, started []ScaleDownMember) []ScaleDownMember {
+	nodeNames := make(map[string]bool)
+	for _, n := range nodes {
+		nodeNames[n.Name] = true
+	}
+	departed := func(id int) bool {
+		pod := pods[fmt.Sprintf("%s-%d", stsName, id)]
+		return pod == nil || pod.Spec.NodeName == "" || !nodeNames[pod.Spec.NodeName]
+	}
+	var ids []int
+	for id := 0; id < int(current); id++ {
+		ids = append(ids, id)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		if di, dj := departed(ids[i]), departed(ids[j]); di != dj {
+			return di
+		}
+		return ids[i] > ids[j]
+	})
+
+	chosen := make(map[int]bool)
+	for _, id := range ids[:current-replicas] {
+		chosen[id] = true
+	}
+	for id := int(replicas); id < int(current); id++ {
+		chosen[id] = true
+	}
+	states := make(map[string]ScaleDownMember)
+	for _, m := range started {
+		states[m.Pod] = m
+	}
+	var members []ScaleDownMember
+	for _, id := range ids {
+		if !chosen[id] {
+			continue
+		}
+		name := fmt.Sprintf("%s-%d", stsName, id)
+		m, ok := states[name]
+		if !ok {
+			m = ScaleDownMember{Pod: name}
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+// deprovisionMember runs the de-provision script of the pod from the configmap in its provisioner container,
+// so that objects of the node are deleted from Config API before the pod is deleted
+func deprovisionMember(pod *corev1.Pod) (bool, string, error) {
+	command := "python /etc/contrailconfigmaps/deprovision.py.${POD_IP}"
+	if _, stderr, err := ExecToContainer(pod, "provisioner", []string{"/usr/bin/bash", "-c", command}, nil); err != nil {
+		return false, "", fmt.Errorf("De-provision of %s failed: %v (stderr=%s)", pod.Name, err, stderr)
+	}
+	return true, "", nil
+}
+
+// RemoveMember de-provisions the config node of the pod out of replicas
+func (c *Config) RemoveMember(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+	return deprovisionMember(pod)
+}
+
+// RemoveMember de-provisions the control node of the pod out of replicas
+func (c *Control) RemoveMember(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+	return deprovisionMember(pod)
+}
@@ -0,0 +1,180 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func scaleDownTestNode(name string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role.kubernetes.io/master": ""}}}
+}
+
+func TestScaleDownSTS(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "config1-config-statefulset", Namespace: "tf"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"node-role.kubernetes.io/master": ""},
+			}},
+		},
+	}
+	instance := &Config{ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: "tf"}}
+	cl := fake.NewFakeClientWithScheme(scheme, sts, instance,
+		scaleDownTestNode("node1"), scaleDownTestNode("node2"), scaleDownTestNode("node3"),
+		restartTestPod(0, "rev1", true), restartTestPod(1, "rev1", true), restartTestPod(2, "rev1", true))
+
+	members := map[string]bool{
+		"config1-config-statefulset-0": true,
+		"config1-config-statefulset-1": true,
+		"config1-config-statefulset-2": true,
+	}
+	var calls []string
+	remove := func(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+		calls = append(calls, pod.Name)
+		for _, p := range remaining {
+			require.NotEqual(t, pod.Name, p.Name)
+		}
+		if !members[pod.Name] {
+			return true, "", nil
+		}
+		members[pod.Name] = false
+		return false, "member is being removed", nil
+	}
+	scaleDown := func() bool {
+		scaling, err := ScaleDownSTS(instance, "config", &instance.Spec.CommonConfiguration, remove, cl)
+		require.NoError(t, err)
+		return scaling
+	}
+	stsReplicas := func() int32 {
+		current := &appsv1.StatefulSet{}
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: sts.Name, Namespace: "tf"}, current))
+		return *current.Spec.Replicas
+	}
+
+	assert.False(t, scaleDown(), "replicas match nodes")
+	assert.Nil(t, instance.Status.ScaleDown)
+
+	require.NoError(t, cl.Delete(context.TODO(), scaleDownTestNode("node2")))
+	require.NoError(t, cl.Delete(context.TODO(), scaleDownTestNode("node3")))
+	disabled := false
+	instance.Spec.CommonConfiguration.ScaleDownOnNodeRemoval = &disabled
+	assert.False(t, scaleDown(), "replicas following nodes are kept if scale-down on node removal is disabled")
+	assert.Nil(t, instance.Status.ScaleDown)
+	assert.Empty(t, calls)
+
+	instance.Spec.CommonConfiguration.ScaleDownOnNodeRemoval = nil
+	assert.True(t, scaleDown())
+	require.NotNil(t, instance.Status.ScaleDown)
+	assert.Equal(t, int32(1), instance.Status.ScaleDown.Replicas)
+	assert.Equal(t, ScaleDownRemovingMembers, instance.Status.ScaleDown.Phase)
+	assert.Equal(t, []string{"config1-config-statefulset-2"}, calls, "one member at a time from the highest ordinal")
+	assert.Equal(t, []ScaleDownMember{
+		{Pod: "config1-config-statefulset-2", Message: "member is being removed"},
+		{Pod: "config1-config-statefulset-1"},
+	}, instance.Status.ScaleDown.Members)
+	assert.Equal(t, int32(3), stsReplicas())
+
+	assert.True(t, scaleDown())
+	assert.True(t, scaleDown())
+	assert.Equal(t, []string{"config1-config-statefulset-2", "config1-config-statefulset-2", "config1-config-statefulset-1", "config1-config-statefulset-1"}, calls)
+	assert.Equal(t, ScaleDownDeletingPods, instance.Status.ScaleDown.Phase)
+	assert.Equal(t, int32(1), stsReplicas(), "replicas are reduced when members are removed")
+
+	require.NoError(t, cl.Create(context.TODO(), scaleDownTestNode("node2")))
+	assert.True(t, scaleDown(), "scale-down waits for pods to be deleted")
+	assert.Equal(t, int32(1), stsReplicas(), "started scale-down is not rolled back")
+
+	for i := 1; i < 3; i++ {
+		require.NoError(t, cl.Delete(context.TODO(), restartTestPod(i, "rev1", true)))
+	}
+	assert.False(t, scaleDown())
+	assert.Nil(t, instance.Status.ScaleDown)
+	assert.Equal(t, 4, len(calls))
+}
+
+func TestScaleDownSTSPrefersDepartedNodes(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "config1-config-statefulset", Namespace: "tf"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"node-role.kubernetes.io/master": ""},
+			}},
+		},
+	}
+	two := int32(2)
+	instance := &Config{ObjectMeta: metav1.ObjectMeta{Name: "config1", Namespace: "tf"}}
+	instance.Spec.CommonConfiguration.Replicas = &two
+	objs := []runtime.Object{sts, instance, scaleDownTestNode("node1"), scaleDownTestNode("node3")}
+	for i := 0; i < 3; i++ {
+		pod := restartTestPod(i, "rev1", true)
+		pod.Spec.NodeName = fmt.Sprintf("node%d", i+1)
+		objs = append(objs, pod)
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	var calls []string
+	remove := func(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+		calls = append(calls, pod.Name)
+		assert.Len(t, remaining, 1, "pod of the lower ordinal on the departed node and the pod out of replicas leave")
+		return true, "", nil
+	}
+	scaling, err := ScaleDownSTS(instance, "config", &instance.Spec.CommonConfiguration, remove, cl)
+	require.NoError(t, err)
+	assert.True(t, scaling)
+	assert.Equal(t, []string{"config1-config-statefulset-1", "config1-config-statefulset-2"}, calls,
+		"the pod on the departed node leaves first")
+	assert.Equal(t, ScaleDownDeletingPods, instance.Status.ScaleDown.Phase)
+
+	current := &appsv1.StatefulSet{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: sts.Name, Namespace: "tf"}, current))
+	assert.Equal(t, int32(2), *current.Spec.Replicas)
+	assert.False(t, podExists(cl, 1), "pod on the departed node is deleted to be re-created on node3")
+	assert.True(t, podExists(cl, 0))
+}
+
+func TestScaleDownStatusString(t *testing.T) {
+	s := &ScaleDownStatus{Replicas: 1, Phase: ScaleDownRemovingMembers, Members: []ScaleDownMember{
+		{Pod: "zookeeper1-zookeeper-statefulset-2", Removed: true},
+		{Pod: "zookeeper1-zookeeper-statefulset-1", Message: "No quorum of remaining servers: 0 of 2"},
+	}}
+	assert.Equal(t, "Scale down to 1 replicas: RemovingMembers, zookeeper1-zookeeper-statefulset-1 is not removed yet (No quorum of remaining servers: 0 of 2)", s.String())
+}
+
+func TestParseRabbitmqClusterStatus(t *testing.T) {
+	out := `{"disk_nodes":["rabbit@node1","rabbit@node2"],"ram_nodes":[],"running_nodes":["rabbit@node1"],"alarms":[]}`
+	member, running, err := parseRabbitmqClusterStatus(out, "rabbit@node2")
+	require.NoError(t, err)
+	assert.True(t, member)
+	assert.False(t, running)
+
+	member, _, err = parseRabbitmqClusterStatus(out, "rabbit@node3")
+	require.NoError(t, err)
+	assert.False(t, member)
+
+	_, _, err = parseRabbitmqClusterStatus("Error: unable to perform an operation on node", "rabbit@node1")
+	assert.Error(t, err)
+}
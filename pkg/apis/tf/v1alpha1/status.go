@@ -1,5 +1,19 @@
 package v1alpha1
 
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CommonStatus is the common part of service status.
@@ -9,9 +23,143 @@ type CommonStatus struct {
 	Degraded      *bool               `json:"degraded,omitempty"`
 	Nodes         map[string]NodeInfo `json:"nodes,omitempty"`
 	ConfigChanged *bool               `json:"configChanged,omitempty"`
+	// RestartingPods are pods of OnDelete statefulset being restarted to apply its update
+	RestartingPods []string `json:"restartingPods,omitempty"`
+	// ScaleDown is the progress of the scale-down of the statefulset
+	ScaleDown *ScaleDownStatus `json:"scaleDown,omitempty"`
+	// CertificatesNotAfter is the earliest expiry of certificates of pods
+	CertificatesNotAfter *metav1.Time `json:"certificatesNotAfter,omitempty"`
+	// CertificatesRenewAt is the time the earliest of certificates of pods is renewed at
+	CertificatesRenewAt *metav1.Time `json:"certificatesRenewAt,omitempty"`
+	// ObservedGeneration is the generation of the spec the status is reported for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard conditions of the instance, e.g. Available
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 type NodeInfo struct {
 	IP       string `json:"ip,omitempty"`
 	Hostname string `json:"hostname,omitempty"`
+	// IPs are all addresses of the pod, e.g. IPv4 and IPv6 addresses in dual-stack cluster
+	IPs []string `json:"ips,omitempty"`
+}
+
+// These are valid condition types of TF resources.
+const (
+	// ConditionAvailable means enough pods are ready to serve requests
+	ConditionAvailable = "Available"
+	// ConditionProgressing means pods are being created or updated
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded means some pods or connections of services are down
+	ConditionDegraded = "Degraded"
+	// ConditionCertificatesReady means certificates of pods are issued and signed
+	ConditionCertificatesReady = "CertificatesReady"
+	// ConditionUpgrading means ZIU is in progress
+	ConditionUpgrading = "Upgrading"
+)
+
+// StatusObject is an instance reporting the common status
+type StatusObject interface {
+	runtime.Object
+	metav1.Object
+	GetCommonStatus() *CommonStatus
+}
+
+// SetCondition sets the condition of the type in conditions,
+// the transition time is changed only if the status of the condition is changed
+func SetCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status bool, reason, message string) {
+	conditionStatus := metav1.ConditionFalse
+	if status {
+		conditionStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// SetCondition sets the condition of the instance for the observed generation
+func (s *CommonStatus) SetCondition(conditionType string, status bool, reason, message string) {
+	SetCondition(&s.Conditions, s.ObservedGeneration, conditionType, status, reason, message)
+}
+
+// SetPodConditions sets Available, Progressing and Degraded conditions of the instance of generation
+// by the number of desired, ready and updated pods
+func SetPodConditions(conditions *[]metav1.Condition, generation int64, active, degraded, configChanged bool, desired, ready, updated int32) {
+	podsMessage := fmt.Sprintf("%d of %d pods are ready", ready, desired)
+	if active {
+		SetCondition(conditions, generation, ConditionAvailable, true, "MinimumPodsAvailable", podsMessage)
+	} else {
+		SetCondition(conditions, generation, ConditionAvailable, false, "MinimumPodsUnavailable", podsMessage)
+	}
+	switch {
+	case configChanged:
+		SetCondition(conditions, generation, ConditionProgressing, true, "ConfigChanged", "Pods are restarted to apply changed configuration")
+	case updated < desired:
+		SetCondition(conditions, generation, ConditionProgressing, true, "PodsUpdating", fmt.Sprintf("%d of %d pods are updated", updated, desired))
+	default:
+		SetCondition(conditions, generation, ConditionProgressing, false, "PodsUpdated", "All pods are updated")
+	}
+	switch {
+	case ready < desired:
+		SetCondition(conditions, generation, ConditionDegraded, true, "PodsNotReady", podsMessage)
+	case degraded:
+		SetCondition(conditions, generation, ConditionDegraded, true, "ServiceDegraded", "Services of some pods are degraded")
+	default:
+		SetCondition(conditions, generation, ConditionDegraded, false, "PodsReady", podsMessage)
+	}
+}
+
+// SetStatefulSetConditions sets the observed generation and Available, Progressing and Degraded
+// conditions of the instance of generation by Active, Degraded and ConfigChanged fields and the statefulset
+func (s *CommonStatus) SetStatefulSetConditions(generation int64, sts *appsv1.StatefulSet) {
+	s.ObservedGeneration = generation
+	var desired int32 = 1
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+	updated := sts.Status.UpdatedReplicas
+	if sts.Status.ObservedGeneration < sts.Generation {
+		updated = 0
+	}
+	SetPodConditions(&s.Conditions, generation,
+		s.Active != nil && *s.Active, s.Degraded != nil && *s.Degraded, s.ConfigChanged != nil && *s.ConfigChanged,
+		desired, sts.Status.ReadyReplicas, updated)
+	if len(s.RestartingPods) > 0 {
+		s.SetCondition(ConditionProgressing, true, "PodsRestarting", "Pods are restarted one by one: "+strings.Join(s.RestartingPods, ", "))
+	}
+	if s.ScaleDown != nil {
+		s.SetCondition(ConditionProgressing, true, "ScalingDown", s.ScaleDown.String())
+	}
+}
+
+// UpdateDependenciesConditions sets Progressing condition of the instance waiting for
+// the dependencies which are not active and updates the status if the condition is changed
+func UpdateDependenciesConditions(instance StatusObject, dependencies map[string]bool, clnt client.Client) error {
+	var notReady []string
+	for name, active := range dependencies {
+		if !active {
+			notReady = append(notReady, name)
+		}
+	}
+	if len(notReady) == 0 {
+		return nil
+	}
+	sort.Strings(notReady)
+	status := instance.GetCommonStatus()
+	generation, conditions := status.ObservedGeneration, append([]metav1.Condition(nil), status.Conditions...)
+	status.ObservedGeneration = instance.GetGeneration()
+	status.SetCondition(ConditionProgressing, true, "DependenciesNotReady", "Waiting for "+strings.Join(notReady, ", "))
+	if generation == status.ObservedGeneration && reflect.DeepEqual(conditions, status.Conditions) {
+		return nil
+	}
+	return clnt.Status().Update(context.TODO(), instance)
 }
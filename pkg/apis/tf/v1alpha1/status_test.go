@@ -0,0 +1,130 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tungstenfabric/tf-operator/pkg/certificates"
+)
+
+func TestStatefulSetConditions(t *testing.T) {
+	trueVal := true
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 2},
+	}
+	status := &CommonStatus{Active: &trueVal, Degraded: &trueVal}
+	status.SetStatefulSetConditions(5, sts)
+	assert.Equal(t, int64(5), status.ObservedGeneration)
+
+	available := meta.FindStatusCondition(status.Conditions, ConditionAvailable)
+	require.NotNil(t, available)
+	assert.Equal(t, metav1.ConditionTrue, available.Status)
+	assert.Equal(t, "2 of 3 pods are ready", available.Message)
+	assert.Equal(t, int64(5), available.ObservedGeneration)
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, ConditionProgressing))
+	degraded := meta.FindStatusCondition(status.Conditions, ConditionDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, "PodsNotReady", degraded.Reason)
+
+	transitionTime := metav1.NewTime(available.LastTransitionTime.Add(-60e9))
+	available.LastTransitionTime = transitionTime
+	falseVal := false
+	status.Degraded = &falseVal
+	sts.Status.ReadyReplicas, sts.Status.UpdatedReplicas = 3, 3
+	status.SetStatefulSetConditions(5, sts)
+	assert.True(t, meta.IsStatusConditionFalse(status.Conditions, ConditionProgressing))
+	assert.True(t, meta.IsStatusConditionFalse(status.Conditions, ConditionDegraded))
+	available = meta.FindStatusCondition(status.Conditions, ConditionAvailable)
+	assert.Equal(t, transitionTime, available.LastTransitionTime, "transition time is kept if status is not changed")
+
+	sts.Generation = 3
+	status.SetStatefulSetConditions(6, sts)
+	progressing := meta.FindStatusCondition(status.Conditions, ConditionProgressing)
+	assert.Equal(t, "PodsUpdating", progressing.Reason, "statefulset controller has not observed the new spec yet")
+}
+
+func TestUpdateDependenciesConditions(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	control := &Control{ObjectMeta: metav1.ObjectMeta{Name: "control1", Namespace: "tf", Generation: 1}}
+	cl := fake.NewFakeClientWithScheme(scheme, control)
+
+	require.NoError(t, UpdateDependenciesConditions(control, map[string]bool{"Config": true, "Cassandra": true}, cl))
+	assert.Empty(t, control.Status.Conditions, "all dependencies are active")
+
+	require.NoError(t, UpdateDependenciesConditions(control, map[string]bool{"Config": false, "Cassandra": false, "Rabbitmq": true}, cl))
+	stored := &Control{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "control1", Namespace: "tf"}, stored))
+	progressing := meta.FindStatusCondition(stored.Status.Conditions, ConditionProgressing)
+	require.NotNil(t, progressing)
+	assert.Equal(t, "DependenciesNotReady", progressing.Reason)
+	assert.Equal(t, "Waiting for Cassandra, Config", progressing.Message)
+	assert.Equal(t, int64(1), stored.Status.ObservedGeneration)
+}
+
+func TestManagerNotReadyServices(t *testing.T) {
+	trueVal := true
+	falseVal := false
+	name := "control1"
+	m := Manager{}
+	m.Spec.Services.Controls = []*ControlInput{{Metadata: Metadata{Name: name}}}
+	m.Spec.Services.Config = &ConfigInput{Metadata: Metadata{Name: "config1"}}
+	m.Status.Controls = []*ServiceStatus{{Name: &name, Active: &falseVal}}
+	m.Status.Config = &ServiceStatus{Active: &trueVal}
+	assert.Equal(t, []string{"Control/control1"}, m.NotReadyServices())
+	assert.False(t, m.IsClusterReady())
+
+	m.Status.ZiuStages = []ZiuStageStatus{{Kind: "Config"}}
+	m.Status.ZiuState = -1
+	assert.False(t, m.IsZiuInProgress())
+	m.Status.ZiuState = 0
+	assert.True(t, m.IsZiuInProgress())
+}
+
+func TestManagerDegradedServices(t *testing.T) {
+	trueVal := true
+	falseVal := false
+	control, config, zookeeper, kubemanager := "control1", "config1", "zookeeper1", "kubemanager1"
+	m := Manager{}
+	m.Status.Controls = []*ServiceStatus{{Name: &control, Degraded: &trueVal}}
+	m.Status.Config = &ServiceStatus{Name: &config, Degraded: &falseVal}
+	m.Status.Zookeeper = &ServiceStatus{Name: &zookeeper, Degraded: &trueVal}
+	m.Status.Kubemanagers = []*KubemanagerServiceStatus{{ServiceStatus: ServiceStatus{Name: &kubemanager, Degraded: &trueVal}}}
+	assert.Equal(t, []string{"Control/control1", "Kubemanager/kubemanager1", "Zookeeper/zookeeper1"}, m.DegradedServices())
+}
+
+func TestManagerConditionsCompatibility(t *testing.T) {
+	m := Manager{}
+	SetCondition(&m.Status.Conditions, 1, string(ManagerReady), true, "ServicesReady", "All services are ready")
+	assert.Equal(t, []ManagerCondition{{Type: ManagerReady, Status: ConditionTrue}}, m.ManagerConditions())
+}
+
+func TestEnsureCertificatesExistReturnsStatusError(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err)
+	defer func(s certificates.CertificateSigner) { signer = s }(signer)
+	signer = nil
+
+	// the instance doesn't exist, so its status is not updated
+	cl := fake.NewFakeClientWithScheme(scheme)
+	instance := &Control{ObjectMeta: metav1.ObjectMeta{Name: "control1", Namespace: "tf"}}
+	err = EnsureCertificatesExist(instance, nil, "control", cl, scheme)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CA Signer is not initilized")
+	assert.Contains(t, err.Error(), "failed to update status")
+	assert.True(t, meta.IsStatusConditionFalse(instance.Status.Conditions, ConditionCertificatesReady))
+}
@@ -1,18 +1,128 @@
 package v1alpha1
 
 import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// Storage defines data storage of the service.
+// If Size is set data is kept in persistent volumes claimed from StorageClass,
+// otherwise hostPath volume is used, Path overrides its default host directory.
 // +k8s:openapi-gen=true
 type Storage struct {
 	// +kubebuilder:validation:Pattern=^([0-9]+)([KMGTPE]i)?$
-	Size string `json:"size,omitempty"` // The only reason we don't use resource.Quantity directly is we can't have regexp for different type than string
-	Path string `json:"path,omitempty"`
+	Size         string `json:"size,omitempty"` // The only reason we don't use resource.Quantity directly is we can't have regexp for different type than string
+	Path         string `json:"path,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// StorageMode is a kind of volume used for the service data
+type StorageMode string
+
+const (
+	StorageModeHostPath              StorageMode = "HostPath"
+	StorageModePersistentVolumeClaim StorageMode = "PersistentVolumeClaim"
+)
+
+// StorageStatus describes data storage used by the service statefulset.
+// Message explains how to migrate data if the requested storage differs from the used one.
+// +k8s:openapi-gen=true
+type StorageStatus struct {
+	Mode    StorageMode `json:"mode,omitempty"`
+	Path    string      `json:"path,omitempty"`
+	Message string      `json:"message,omitempty"`
 }
 
 func (s Storage) SizeAsQuantity() (resource.Quantity, error) {
 	return resource.ParseQuantity(s.Size)
 }
+
+// IsPersistent returns true if data should be kept in persistent volumes
+func (s *Storage) IsPersistent() bool {
+	return s != nil && s.Size != ""
+}
+
+func hasVolumeClaim(sts *appsv1.StatefulSet, volumeName string) bool {
+	for _, c := range sts.Spec.VolumeClaimTemplates {
+		if c.Name == volumeName {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyStorageToSTS configures the data volume of the intended statefulset according to the storage.
+// volumeClaimTemplates of the existing statefulset are immutable, so it keeps its storage mode,
+// and the returned status describes the data migration needed to apply the requested one.
+func ApplyStorageToSTS(instance v1.Object, instanceType string, sts *appsv1.StatefulSet, volumeName string, storage *Storage, clnt client.Client) (*StorageStatus, error) {
+	spec := &sts.Spec.Template.Spec
+	idx := -1
+	for i, v := range spec.Volumes {
+		if v.Name == volumeName {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 || spec.Volumes[idx].HostPath == nil {
+		return nil, fmt.Errorf("No hostPath volume %s in statefulset template", volumeName)
+	}
+	if storage != nil && storage.Path != "" {
+		spec.Volumes[idx].HostPath.Path = storage.Path
+	}
+	hostPath := spec.Volumes[idx].HostPath.Path
+
+	stsName := instance.GetName() + "-" + instanceType + "-statefulset"
+	existing, err := QuerySTS(stsName, instance.GetNamespace(), clnt)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	persistent := storage.IsPersistent()
+	if existing != nil {
+		persistent = hasVolumeClaim(existing, volumeName)
+	}
+
+	status := &StorageStatus{Mode: StorageModeHostPath, Path: hostPath}
+	if persistent {
+		status.Mode = StorageModePersistentVolumeClaim
+		status.Path = ""
+		spec.Volumes = append(spec.Volumes[:idx], spec.Volumes[idx+1:]...)
+	}
+	if persistent && existing == nil {
+		size, err := storage.SizeAsQuantity()
+		if err != nil {
+			return nil, err
+		}
+		claim := corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{Name: volumeName},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+				},
+			},
+		}
+		if storage.StorageClass != "" {
+			claim.Spec.StorageClassName = &storage.StorageClass
+		}
+		sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, claim)
+	}
+
+	claims := fmt.Sprintf("%s-%s-<ordinal>", volumeName, stsName)
+	switch {
+	case persistent && !storage.IsPersistent():
+		status.Message = fmt.Sprintf("Data is kept in persistent volume claims %s. To move it to hostPath %s "+
+			"copy the data to the nodes and delete statefulset %s with --cascade=orphan to let it be recreated", claims, hostPath, stsName)
+	case !persistent && storage.IsPersistent():
+		status.Message = fmt.Sprintf("Data is kept in hostPath %s. To move it to persistent volumes "+
+			"copy the data into claims %s and delete statefulset %s with --cascade=orphan to let it be recreated", hostPath, claims, stsName)
+	}
+	return status, nil
+}
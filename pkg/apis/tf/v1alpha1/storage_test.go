@@ -0,0 +1,74 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func storageTestSTS() *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "zookeeper-data", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/lib/contrail/zookeeper"}}},
+						{Name: "zookeeper-logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log/contrail/zookeeper"}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyStorageToSTS(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+	instance := &Zookeeper{ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1", Namespace: "tf"}}
+
+	// hostPath is kept by default
+	cl := fake.NewFakeClientWithScheme(scheme)
+	sts := storageTestSTS()
+	status, err := ApplyStorageToSTS(instance, "zookeeper", sts, "zookeeper-data", nil, cl)
+	require.NoError(t, err)
+	require.Equal(t, StorageModeHostPath, status.Mode)
+	require.Equal(t, "/var/lib/contrail/zookeeper", status.Path)
+	require.Empty(t, status.Message)
+	require.Len(t, sts.Spec.Template.Spec.Volumes, 2)
+	require.Empty(t, sts.Spec.VolumeClaimTemplates)
+
+	// new statefulset gets volume claim
+	storage := &Storage{Size: "10Gi", StorageClass: "fast"}
+	sts = storageTestSTS()
+	status, err = ApplyStorageToSTS(instance, "zookeeper", sts, "zookeeper-data", storage, cl)
+	require.NoError(t, err)
+	require.Equal(t, StorageModePersistentVolumeClaim, status.Mode)
+	require.Empty(t, status.Message)
+	require.Len(t, sts.Spec.Template.Spec.Volumes, 1)
+	require.Equal(t, "zookeeper-logs", sts.Spec.Template.Spec.Volumes[0].Name)
+	require.Len(t, sts.Spec.VolumeClaimTemplates, 1)
+	claim := sts.Spec.VolumeClaimTemplates[0]
+	require.Equal(t, "zookeeper-data", claim.Name)
+	require.Equal(t, "fast", *claim.Spec.StorageClassName)
+	size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	require.Equal(t, "10Gi", size.String())
+
+	// existing hostPath statefulset is not changed
+	existing := storageTestSTS()
+	existing.Name = "zookeeper1-zookeeper-statefulset"
+	existing.Namespace = "tf"
+	cl = fake.NewFakeClientWithScheme(scheme, existing)
+	sts = storageTestSTS()
+	status, err = ApplyStorageToSTS(instance, "zookeeper", sts, "zookeeper-data", storage, cl)
+	require.NoError(t, err)
+	require.Equal(t, StorageModeHostPath, status.Mode)
+	require.Contains(t, status.Message, "zookeeper-data-zookeeper1-zookeeper-statefulset-<ordinal>")
+	require.Len(t, sts.Spec.Template.Spec.Volumes, 2)
+	require.Empty(t, sts.Spec.VolumeClaimTemplates)
+}
@@ -241,6 +241,29 @@ export LOCAL_JMX=no
 export CASSANDRA_JMX_LOCAL_PORT={{ .JmxLocalPort }}
 export CASSANDRA_LISTEN_ADDRESS=${POD_IP}
 
+# operator stages data of the backup to be restored before the start,
+# files of table snapshots are moved into table folders
+if [ -f /var/lib/cassandra/restore/.ready ] ; then
+  rm -rf /var/lib/cassandra/data /var/lib/cassandra/commitlog /var/lib/cassandra/saved_caches /var/lib/cassandra/hints
+  cd /var/lib/cassandra/restore
+  for snapshot in $(find . -type d -path '*/snapshots/*' -prune) ; do
+    table=/var/lib/cassandra/data/${snapshot%%/snapshots/*}
+    mkdir -p $table
+    rm -f $snapshot/manifest.json $snapshot/schema.cql
+    mv $snapshot/* $table/
+  done
+  cd /
+  rm -rf /var/lib/cassandra/restore
+fi
+
+# operator sets address of the dead node if this node replaces it,
+# the file is empty for a new node or a member of the ring
+replace_address=$(cat /etc/contrailconfigmaps/replace-address.${POD_IP//:/-})
+replace_address_opts=""
+if [ -n "$replace_address" ] ; then
+  replace_address_opts="-Dcassandra.replace_address_first_boot=$replace_address"
+fi
+
 {{ if .ReaperEnabled }}
 /run-reaper.sh &
 
@@ -255,9 +278,9 @@ exec /docker-entrypoint.sh -f -Dcassandra.jmx.local.port={{ .JmxLocalPort }} \
   -Djavax.net.ssl.keyStorePassword={{ .KeystorePassword }} \
   -Djavax.net.ssl.trustStore=/etc/keystore/server-truststore.jks \
   -Djavax.net.ssl.trustStorePassword={{ .TruststorePassword }} \
-  -Dcassandra.config=file:///etc/contrailconfigmaps/cassandra.${POD_IP}.yaml
+  -Dcassandra.config=file:///etc/contrailconfigmaps/cassandra.${POD_IP}.yaml $replace_address_opts
 {{ else }}
 # start service
-exec /docker-entrypoint.sh -f -Dcassandra.jmx.local.port={{ .JmxLocalPort }} -Dcassandra.config=file:///etc/contrailconfigmaps/cassandra.${POD_IP}.yaml
+exec /docker-entrypoint.sh -f -Dcassandra.jmx.local.port={{ .JmxLocalPort }} -Dcassandra.config=file:///etc/contrailconfigmaps/cassandra.${POD_IP}.yaml $replace_address_opts
 {{ end }}
 `))
@@ -283,3 +283,24 @@ sandesh_server_keyfile=/etc/certificates/server-key-{{ .PodIP }}.pem
 sandesh_server_certfile=/etc/certificates/server-{{ .PodIP }}.crt
 sandesh_ca_cert={{ .CAFilePath }}
 `))
+
+// ConfigDeProvisionConfig is the template of the Config de-provision script.
+// TODO:
+//   - support keystone
+//   - certs to disable insecure
+var ConfigDeProvisionConfig = template.Must(template.New("").Parse(`#!/usr/bin/python
+from vnc_api import vnc_api
+vncServerList = [{{ .APIServerList }}]
+vnc_client = vnc_api.VncApi(
+    api_server_use_ssl=True,
+    apiinsecure=True,
+    username='{{ .AdminUsername }}',
+    password='{{ .AdminPassword }}',
+    tenant_name='{{ .AdminTenant }}',
+    api_server_host=vncServerList,
+    api_server_port={{ .APIServerPort }})
+try:
+    vnc_client.config_node_delete(fq_name=['default-global-system-config', '{{ .Hostname }}'])
+except vnc_api.NoIdError:
+    pass
+`))
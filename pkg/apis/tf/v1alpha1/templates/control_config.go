@@ -12,7 +12,7 @@ collectors={{ .CollectorServerList }}
 hostname={{ .Hostname }}
 hostip={{ .ListenAddress }}
 http_server_ip={{ .InstrospectListenAddress }}
-http_server_port=8083
+http_server_port={{ .IntrospectPort }}
 log_file=/var/log/contrail/contrail-control.log
 log_level={{ .LogLevel }}
 log_local=1
@@ -154,16 +154,19 @@ sandesh_ca_cert={{ .CAFilePath }}
 var ControlDeProvisionConfig = template.Must(template.New("").Parse(`#!/usr/bin/python
 from vnc_api import vnc_api
 import socket
-vncServerList = {{ .APIServerList }}
+vncServerList = [{{ .APIServerList }}]
 vnc_client = vnc_api.VncApi(
     api_server_use_ssl=True,
     apiinsecure=True,
     username='{{ .AdminUsername }}',
     password='{{ .AdminPassword }}',
     tenant_name='{{ .AdminTenant }}',
-    api_server_host=vncServerList.split(','),
+    api_server_host=vncServerList,
     api_server_port={{ .APIServerPort }})
-vnc_client.bgp_router_delete(fq_name=['default-domain','default-project','ip-fabric','__default__', '{{ .Hostname }}' ])
+try:
+    vnc_client.bgp_router_delete(fq_name=['default-domain','default-project','ip-fabric','__default__', '{{ .Hostname }}' ])
+except vnc_api.NoIdError:
+    pass
 `))
 
 var ControlRNDCConfig = template.Must(template.New("").Parse(`
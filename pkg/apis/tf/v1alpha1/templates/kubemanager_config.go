@@ -18,6 +18,13 @@ kubernetes_api_server={{ .KubernetesAPIServer }}
 kubernetes_api_port={{ .KubernetesAPIPort }}
 kubernetes_api_secure_port={{ .KubernetesAPISSLPort }}
 cluster_name={{ .KubernetesClusterName }}
+{{- if .KubernetesCAFile }}
+kubernetes_ca_file={{ .KubernetesCAFile }}
+{{- end }}
+{{- if .KubernetesCertFile }}
+kubernetes_cert_file={{ .KubernetesCertFile }}
+kubernetes_key_file={{ .KubernetesKeyFile }}
+{{- end }}
 cluster_project={}
 cluster_network={}
 pod_subnets={{ .PodSubnet }}
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"net"
 	"strconv"
 	"strings"
 )
@@ -22,12 +23,24 @@ func JoinListWithSeparatorAndSingleQuotes(items []string, separator string) stri
 }
 
 // EndpointList creates a new slice in which each item is an ip and port joined
-// with a colon.
+// with a colon. IPv6 addresses are enclosed in square brackets.
 func EndpointList(ips []string, port int) []string {
 	portStr := strconv.Itoa(port)
 	endpoints := []string{}
 	for _, ip := range ips {
-		endpoints = append(endpoints, ip+":"+portStr)
+		endpoints = append(endpoints, net.JoinHostPort(ip, portStr))
 	}
 	return endpoints
 }
+
+// SubnetList converts comma separated list of subnets (dual-stack notation of kubeadm)
+// into space separated list.
+func SubnetList(subnets string) string {
+	var res []string
+	for _, s := range strings.Split(subnets, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			res = append(res, s)
+		}
+	}
+	return strings.Join(res, " ")
+}
@@ -2,6 +2,7 @@ package templates
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 
@@ -40,8 +41,8 @@ func DynamicZookeeperConfig(pods []core.Pod, electionPort, serverPort, clientPor
 			return nil, err
 		}
 		n := pod2node(pod)
-		serverDef = serverDef + fmt.Sprintf("server.%d=%s:%s:participant;%s:%s\n",
-			myidInt+1, n, electionPort+":"+serverPort, n, clientPort)
+		serverDef = serverDef + fmt.Sprintf("server.%d=%s:%s:participant;%s\n",
+			myidInt+1, net.JoinHostPort(n, electionPort), serverPort, net.JoinHostPort(n, clientPort))
 	}
 	for _, pod := range pods {
 		myidString := pod.Name[len(pod.Name)-1:]
@@ -0,0 +1,563 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tungstenfabric/tf-operator/pkg/certificates"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// imageReferenceRegexp matches [registry[:port]/]path[:tag][@digest]
+var imageReferenceRegexp = regexp.MustCompile(
+	`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?/)?` +
+		`[a-z0-9]+([._-]+[a-z0-9]+)*(/[a-z0-9]+([._-]+[a-z0-9]+)*)*` +
+		`(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// clientPorts are ports of remote services, not the ports the service listens
+var clientPorts = map[string]bool{"kubernetesAPIPort": true, "kubernetesAPISSLPort": true, "analyticsdbPort": true}
+
+var validLogLevels = []string{"info", "debug", "warning", "error", "critical", "none"}
+
+var validAuthModes = []string{string(AuthenticationModeNoAuth), string(AuthenticationModeKeystone)}
+
+var validAAAModes = []string{string(AAAModeNoAuth), string(AAAModeRBAC), string(AAAModeCloudAdmin)}
+
+var validWhenUnsatisfiable = []string{string(corev1.DoNotSchedule), string(corev1.ScheduleAnyway)}
+
+// quorumServices are available while the majority of their pods is, so odd replicas are required
+var quorumServices = map[string]bool{"cassandra": true, "rabbitmq": true, "zookeeper": true}
+
+func validateEnum(value string, valid []string, path *field.Path) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	for _, v := range valid {
+		if v == value {
+			return nil
+		}
+	}
+	return field.ErrorList{field.NotSupported(path, value, valid)}
+}
+
+func validateCIDR(value string, path *field.Path) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return field.ErrorList{field.Invalid(path, value, "must be a CIDR, e.g. 10.0.0.0/24")}
+	}
+	return nil
+}
+
+// validateCIDRList checks list of CIDRs separated by commas or spaces
+func validateCIDRList(value string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, cidr := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		errs = append(errs, validateCIDR(cidr, path)...)
+	}
+	return errs
+}
+
+func validateCredentialsSource(s *CredentialsSource, path *field.Path) field.ErrorList {
+	if s == nil {
+		return nil
+	}
+	if _, err := s.Provider(); err != nil {
+		return field.ErrorList{field.Invalid(path, "", err.Error())}
+	}
+	var errs field.ErrorList
+	switch {
+	case s.Secret != nil:
+		if s.Secret.Name == "" {
+			errs = append(errs, field.Required(path.Child("secret", "name"), ""))
+		}
+	case s.Vault != nil:
+		vaultPath := path.Child("vault")
+		if u, err := url.Parse(s.Vault.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(vaultPath.Child("address"), s.Vault.Address, "must be http(s) URL, e.g. http://127.0.0.1:8200"))
+		}
+		if s.Vault.Path == "" {
+			errs = append(errs, field.Required(vaultPath.Child("path"), ""))
+		}
+		if s.Vault.TokenSecretName == "" {
+			errs = append(errs, field.Required(vaultPath.Child("tokenSecretName"), ""))
+		}
+	case s.File != nil:
+		if !filepath.IsAbs(s.File.Path) {
+			errs = append(errs, field.Invalid(path.Child("file", "path"), s.File.Path, "must be an absolute path"))
+		}
+	}
+	return errs
+}
+
+func validateContainers(containers []*Container, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, c := range containers {
+		if c == nil {
+			continue
+		}
+		if c.Name == "" {
+			errs = append(errs, field.Required(path.Index(i).Child("name"), ""))
+		}
+		if c.Image == "" {
+			errs = append(errs, field.Required(path.Index(i).Child("image"), ""))
+		} else if !imageReferenceRegexp.MatchString(c.Image) {
+			errs = append(errs, field.Invalid(path.Index(i).Child("image"), c.Image, "must be an image reference"))
+		}
+		if c.Resources != nil {
+			for name, request := range c.Resources.Requests {
+				if limit, ok := c.Resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+					errs = append(errs, field.Invalid(path.Index(i).Child("resources", "requests").Key(string(name)),
+						request.String(), "must be less than or equal to the limit "+limit.String()))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// servicePorts returns set listen ports of service configuration by JSON field names.
+// Ports of disabled features are skipped.
+func servicePorts(serviceConfiguration interface{}) map[string]int {
+	ports := map[string]int{}
+	v := reflect.Indirect(reflect.ValueOf(serviceConfiguration))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !strings.HasSuffix(f.Name, "Port") || f.Type != reflect.TypeOf((*int)(nil)) || v.Field(i).IsNil() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if !clientPorts[name] {
+			ports[name] = int(v.Field(i).Elem().Int())
+		}
+	}
+	switch c := v.Addr().Interface().(type) {
+	case *CassandraConfiguration:
+		if c.ReaperEnabled == nil || !*c.ReaperEnabled {
+			delete(ports, "reaperAppPort")
+			delete(ports, "reaperAdmPort")
+		}
+	case *ZookeeperConfiguration:
+		if c.AdminEnableServer != nil && !*c.AdminEnableServer {
+			delete(ports, "adminPort")
+		}
+	}
+	return ports
+}
+
+func validatePorts(serviceConfiguration interface{}, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	ports := servicePorts(serviceConfiguration)
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if port := ports[name]; port < 1 || port > 65535 {
+			errs = append(errs, field.Invalid(path.Child(name), port, "must be a port number from 1 to 65535"))
+		}
+	}
+	return errs
+}
+
+func validatePodConfiguration(instanceType string, c *PodConfiguration, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, validateEnum(c.LogLevel, validLogLevels, path.Child("logLevel"))...)
+	errs = append(errs, validateEnum(string(c.AuthParameters.AuthMode), validAuthModes, path.Child("authParameters", "authMode"))...)
+	errs = append(errs, validateCredentialsSource(c.AuthParameters.KeystoneCredentialsSource, path.Child("authParameters", "keystoneCredentialsSource"))...)
+	if err := ValidateConfigOverrides(instanceType, c.ConfigOverrides); err != nil {
+		errs = append(errs, field.Invalid(path.Child("configOverrides"), "", err.Error()))
+	}
+	if r := c.Replicas; r != nil {
+		if *r < 1 {
+			errs = append(errs, field.Invalid(path.Child("replicas"), *r, "must be positive"))
+		} else if quorumServices[instanceType] && *r%2 == 0 {
+			errs = append(errs, field.Invalid(path.Child("replicas"), *r, "must be odd to keep the quorum"))
+		}
+	}
+	for i, tsc := range c.TopologySpreadConstraints {
+		tscPath := path.Child("topologySpreadConstraints").Index(i)
+		if tsc.MaxSkew < 1 {
+			errs = append(errs, field.Invalid(tscPath.Child("maxSkew"), tsc.MaxSkew, "must be positive"))
+		}
+		if tsc.TopologyKey == "" {
+			errs = append(errs, field.Required(tscPath.Child("topologyKey"), ""))
+		}
+		errs = append(errs, validateEnum(string(tsc.WhenUnsatisfiable), validWhenUnsatisfiable, tscPath.Child("whenUnsatisfiable"))...)
+	}
+	return errs
+}
+
+func validateServiceSpec(instanceType string, common *PodConfiguration, serviceConfiguration interface{}, containers []*Container, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, validatePodConfiguration(instanceType, common, path.Child("commonConfiguration"))...)
+	errs = append(errs, validateContainers(containers, path.Child("serviceConfiguration", "containers"))...)
+	errs = append(errs, validatePorts(serviceConfiguration, path.Child("serviceConfiguration"))...)
+	return errs
+}
+
+func (s *AnalyticsSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("analytics", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	return append(errs, validateEnum(string(s.ServiceConfiguration.AAAMode), validAAAModes, path.Child("serviceConfiguration", "aaaMode"))...)
+}
+
+func (s *AnalyticsAlarmSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("analyticsalarm", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+func (s *AnalyticsSnmpSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("analyticssnmp", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+func (s *CassandraSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("cassandra", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	scPath := path.Child("serviceConfiguration")
+	heapSizes := []struct{ name, size string }{
+		{"maxHeapSize", s.ServiceConfiguration.MaxHeapSize},
+		{"minHeapSize", s.ServiceConfiguration.MinHeapSize},
+	}
+	for _, h := range heapSizes {
+		if _, err := ParseJVMSize(h.size); h.size != "" && err != nil {
+			errs = append(errs, field.Invalid(scPath.Child(h.name), h.size, "must be a JVM memory size, e.g. 1024m or 4g"))
+		}
+	}
+	return errs
+}
+
+func (s *ConfigSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("config", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	return append(errs, validateEnum(string(s.ServiceConfiguration.AAAMode), validAAAModes, path.Child("serviceConfiguration", "aaaMode"))...)
+}
+
+func (s *ControlSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("control", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	errs = append(errs, validateCIDR(s.ServiceConfiguration.DataSubnet, path.Child("serviceConfiguration", "dataSubnet"))...)
+	if asn := s.ServiceConfiguration.ASNNumber; asn != nil && (*asn < 1 || *asn > 4294967295) {
+		errs = append(errs, field.Invalid(path.Child("serviceConfiguration", "asnNumber"), *asn, "must be from 1 to 4294967295"))
+	}
+	return errs
+}
+
+func (s *KubemanagerSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("kubemanager", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	scPath := path.Child("serviceConfiguration")
+	errs = append(errs, validateCIDRList(s.ServiceConfiguration.PodSubnet, scPath.Child("podSubnet"))...)
+	errs = append(errs, validateCIDRList(s.ServiceConfiguration.ServiceSubnet, scPath.Child("serviceSubnet"))...)
+	errs = append(errs, validateCIDRList(s.ServiceConfiguration.IPFabricSubnets, scPath.Child("ipFabricSubnets"))...)
+	if s.ServiceConfiguration.KubeconfigSecretName != "" {
+		if s.ServiceConfiguration.PodSubnet == "" {
+			errs = append(errs, field.Required(scPath.Child("podSubnet"), "required for the remote cluster"))
+		}
+		if s.ServiceConfiguration.ServiceSubnet == "" {
+			errs = append(errs, field.Required(scPath.Child("serviceSubnet"), "required for the remote cluster"))
+		}
+	}
+	return errs
+}
+
+func (s *QueryEngineSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("queryengine", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+func (s *RabbitmqSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("rabbitmq", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	scPath := path.Child("serviceConfiguration")
+	errs = append(errs, validateCredentialsSource(s.ServiceConfiguration.CredentialsSource, scPath.Child("credentialsSource"))...)
+	if s.ServiceConfiguration.CredentialsSource != nil && s.ServiceConfiguration.CredentialsRotationIntervalHours != nil {
+		errs = append(errs, field.Forbidden(scPath.Child("credentialsRotationIntervalHours"),
+			"credentials of credentialsSource are rotated in the source"))
+	}
+	return errs
+}
+
+func (s *RedisSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("redis", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+func (s *VrouterSpec) validate(path *field.Path) field.ErrorList {
+	errs := validateServiceSpec("vrouter", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+	scPath := path.Child("serviceConfiguration")
+	errs = append(errs, validateCIDR(s.ServiceConfiguration.DataSubnet, scPath.Child("dataSubnet"))...)
+	errs = append(errs, validateCIDRList(s.ServiceConfiguration.KubernetesPodSubnet, scPath.Child("kubernetesPodSubnet"))...)
+	if s.CommonConfiguration.Replicas != nil {
+		errs = append(errs, field.Forbidden(path.Child("commonConfiguration", "replicas"), "vrouter runs on every node matching the nodeSelector"))
+	}
+	return errs
+}
+
+func (s *WebuiSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("webui", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+func (s *ZookeeperSpec) validate(path *field.Path) field.ErrorList {
+	return validateServiceSpec("zookeeper", &s.CommonConfiguration, &s.ServiceConfiguration, s.ServiceConfiguration.Containers, path)
+}
+
+// validateCassandraUpdate denies change of ports of running cassandra cluster,
+// nodes can't join the ring on the other ports
+func validateCassandraUpdate(spec, old *CassandraSpec, path *field.Path) field.ErrorList {
+	newPorts := (&Cassandra{Spec: *spec}).ConfigurationParameters()
+	oldPorts := (&Cassandra{Spec: *old}).ConfigurationParameters()
+	var errs field.ErrorList
+	for _, p := range []struct {
+		name     string
+		new, old *int
+	}{
+		{"port", newPorts.Port, oldPorts.Port},
+		{"cqlPort", newPorts.CqlPort, oldPorts.CqlPort},
+		{"storagePort", newPorts.StoragePort, oldPorts.StoragePort},
+		{"sslStoragePort", newPorts.SslStoragePort, oldPorts.SslStoragePort},
+		{"jmxLocalPort", newPorts.JmxLocalPort, oldPorts.JmxLocalPort},
+	} {
+		if *p.new != *p.old {
+			errs = append(errs, field.Forbidden(path.Child("serviceConfiguration", p.name), "port can't be changed after creation"))
+		}
+	}
+	return errs
+}
+
+// validateKubemanagerUpdate denies change of the served cluster,
+// the objects of the cluster in the config database are named after the cluster
+func validateKubemanagerUpdate(spec, old *KubemanagerSpec, path *field.Path) field.ErrorList {
+	if spec.ServiceConfiguration.ClusterName != old.ServiceConfiguration.ClusterName {
+		return field.ErrorList{field.Forbidden(path.Child("serviceConfiguration", "clusterName"), "cluster name can't be changed after creation")}
+	}
+	return nil
+}
+
+// managerService is a service of Manager spec to cross check services
+type managerService struct {
+	kind                 string
+	name                 string
+	inputPath            *field.Path
+	path                 *field.Path
+	nodeSelector         map[string]string
+	serviceConfiguration interface{}
+}
+
+func (m *Manager) services() []managerService {
+	var services []managerService
+	path := field.NewPath("spec", "services")
+	add := func(kind, name string, p *field.Path, common *PodConfiguration, serviceConfiguration interface{}) {
+		nodeSelector := common.NodeSelector
+		if len(nodeSelector) == 0 {
+			nodeSelector = m.Spec.CommonConfiguration.NodeSelector
+		}
+		services = append(services, managerService{kind, name, p, p.Child("spec"), nodeSelector, serviceConfiguration})
+	}
+	s := &m.Spec.Services
+	if s.Analytics != nil {
+		add("Analytics", s.Analytics.Metadata.Name, path.Child("analytics"), &s.Analytics.Spec.CommonConfiguration, &s.Analytics.Spec.ServiceConfiguration)
+	}
+	if s.AnalyticsAlarm != nil {
+		add("AnalyticsAlarm", s.AnalyticsAlarm.Metadata.Name, path.Child("analyticsAlarm"), &s.AnalyticsAlarm.Spec.CommonConfiguration, &s.AnalyticsAlarm.Spec.ServiceConfiguration)
+	}
+	if s.AnalyticsSnmp != nil {
+		add("AnalyticsSnmp", s.AnalyticsSnmp.Metadata.Name, path.Child("analyticsSnmp"), &s.AnalyticsSnmp.Spec.CommonConfiguration, &s.AnalyticsSnmp.Spec.ServiceConfiguration)
+	}
+	for i, c := range s.Cassandras {
+		add("Cassandra", c.Metadata.Name, path.Child("cassandras").Index(i), &c.Spec.CommonConfiguration, &c.Spec.ServiceConfiguration)
+	}
+	if s.Config != nil {
+		add("Config", s.Config.Metadata.Name, path.Child("config"), &s.Config.Spec.CommonConfiguration, &s.Config.Spec.ServiceConfiguration)
+	}
+	for i, c := range s.Controls {
+		add("Control", c.Metadata.Name, path.Child("controls").Index(i), &c.Spec.CommonConfiguration, &c.Spec.ServiceConfiguration)
+	}
+	for i, c := range s.Kubemanagers {
+		add("Kubemanager", c.Metadata.Name, path.Child("kubemanagers").Index(i), &c.Spec.CommonConfiguration, &c.Spec.ServiceConfiguration)
+	}
+	if s.Kubemanager != nil {
+		add("Kubemanager", s.Kubemanager.Metadata.Name, path.Child("kubemanager"), &s.Kubemanager.Spec.CommonConfiguration, &s.Kubemanager.Spec.ServiceConfiguration)
+	}
+	if s.QueryEngine != nil {
+		add("QueryEngine", s.QueryEngine.Metadata.Name, path.Child("queryengine"), &s.QueryEngine.Spec.CommonConfiguration, &s.QueryEngine.Spec.ServiceConfiguration)
+	}
+	if s.Rabbitmq != nil {
+		add("Rabbitmq", s.Rabbitmq.Metadata.Name, path.Child("rabbitmq"), &s.Rabbitmq.Spec.CommonConfiguration, &s.Rabbitmq.Spec.ServiceConfiguration)
+	}
+	for i, c := range s.Redis {
+		add("Redis", c.Metadata.Name, path.Child("redis").Index(i), &c.Spec.CommonConfiguration, &c.Spec.ServiceConfiguration)
+	}
+	for i, c := range s.Vrouters {
+		add("Vrouter", c.Metadata.Name, path.Child("vrouters").Index(i), &c.Spec.CommonConfiguration, &c.Spec.ServiceConfiguration)
+	}
+	if s.Webui != nil {
+		add("Webui", s.Webui.Metadata.Name, path.Child("webui"), &s.Webui.Spec.CommonConfiguration, &s.Webui.Spec.ServiceConfiguration)
+	}
+	if s.Zookeeper != nil {
+		add("Zookeeper", s.Zookeeper.Metadata.Name, path.Child("zookeeper"), &s.Zookeeper.Spec.CommonConfiguration, &s.Zookeeper.Spec.ServiceConfiguration)
+	}
+	return services
+}
+
+// validateCertificatesLifetime checks certificates of services don't outlive the CA
+func validateCertificatesLifetime(c *ManagerConfiguration, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	defaultDays := int(certificates.DefaultCertValidityPeriod / (24 * time.Hour))
+	certDays, caDays := defaultDays, defaultDays
+	if c.CertValidityDays != nil {
+		certDays = *c.CertValidityDays
+		if certDays < 1 {
+			errs = append(errs, field.Invalid(path.Child("certValidityDays"), certDays, "must be positive"))
+		}
+	}
+	if c.CACertValidityDays != nil {
+		caDays = *c.CACertValidityDays
+		if caDays < 1 {
+			errs = append(errs, field.Invalid(path.Child("caCertValidityDays"), caDays, "must be positive"))
+		}
+	}
+	if certDays > caDays {
+		errs = append(errs, field.Invalid(path.Child("certValidityDays"), certDays, fmt.Sprintf("must not exceed caCertValidityDays %d", caDays)))
+	}
+	if p := c.CertRenewalPercent; p != nil && (*p < 1 || *p > 99) {
+		errs = append(errs, field.Invalid(path.Child("certRenewalPercent"), *p, "must be between 1 and 99"))
+	}
+	return errs
+}
+
+func (m *Manager) validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	errs = append(errs, validateEnum(m.Spec.CommonConfiguration.LogLevel, validLogLevels, specPath.Child("commonConfiguration", "logLevel"))...)
+	errs = append(errs, validateEnum(string(m.Spec.CommonConfiguration.AuthParameters.AuthMode), validAuthModes, specPath.Child("commonConfiguration", "authParameters", "authMode"))...)
+	errs = append(errs, validateCredentialsSource(m.Spec.CommonConfiguration.AuthParameters.KeystoneCredentialsSource,
+		specPath.Child("commonConfiguration", "authParameters", "keystoneCredentialsSource"))...)
+	errs = append(errs, validateCertificatesLifetime(&m.Spec.CommonConfiguration, specPath.Child("commonConfiguration"))...)
+	if cc := m.Spec.CommonConfiguration.ClusterConfig; cc != nil {
+		ccPath := specPath.Child("commonConfiguration", "clusterConfig")
+		errs = append(errs, validateCIDRList(cc.Networking.PodSubnet, ccPath.Child("networking", "podSubnet"))...)
+		errs = append(errs, validateCIDRList(cc.Networking.ServiceSubnet, ccPath.Child("networking", "serviceSubnet"))...)
+		if cc.ControlPlaneEndpoint != "" {
+			if _, _, err := net.SplitHostPort(cc.ControlPlaneEndpoint); err != nil {
+				errs = append(errs, field.Invalid(ccPath.Child("controlPlaneEndpoint"), cc.ControlPlaneEndpoint, "must be host:port"))
+			}
+		}
+	}
+	if m.Spec.ZiuPlan != nil {
+		for i, stage := range m.Spec.ZiuPlan.Stages {
+			errs = append(errs, validateEnum(stage.Kind, ZiuKindsAll, specPath.Child("ziuPlan", "stages").Index(i).Child("kind"))...)
+		}
+	}
+
+	services := m.services()
+	names := map[string]*field.Path{}
+	for _, s := range services {
+		key := s.kind + "/" + s.name
+		if s.name == "" {
+			errs = append(errs, field.Required(s.inputPath.Child("metadata", "name"), ""))
+		} else if _, ok := names[key]; ok {
+			errs = append(errs, field.Duplicate(s.inputPath.Child("metadata", "name"), s.name))
+		}
+		names[key] = s.path
+	}
+
+	s := &m.Spec.Services
+	if s.Analytics != nil {
+		errs = append(errs, s.Analytics.Spec.validate(specPath.Child("services", "analytics", "spec"))...)
+	}
+	if s.AnalyticsAlarm != nil {
+		errs = append(errs, s.AnalyticsAlarm.Spec.validate(specPath.Child("services", "analyticsAlarm", "spec"))...)
+	}
+	if s.AnalyticsSnmp != nil {
+		errs = append(errs, s.AnalyticsSnmp.Spec.validate(specPath.Child("services", "analyticsSnmp", "spec"))...)
+	}
+	for i, c := range s.Cassandras {
+		errs = append(errs, c.Spec.validate(specPath.Child("services", "cassandras").Index(i).Child("spec"))...)
+	}
+	if s.Config != nil {
+		errs = append(errs, s.Config.Spec.validate(specPath.Child("services", "config", "spec"))...)
+	}
+	for i, c := range s.Controls {
+		errs = append(errs, c.Spec.validate(specPath.Child("services", "controls").Index(i).Child("spec"))...)
+	}
+	for i, c := range s.Kubemanagers {
+		errs = append(errs, c.Spec.validate(specPath.Child("services", "kubemanagers").Index(i).Child("spec"))...)
+	}
+	if s.Kubemanager != nil {
+		errs = append(errs, s.Kubemanager.Spec.validate(specPath.Child("services", "kubemanager", "spec"))...)
+	}
+	if s.QueryEngine != nil {
+		errs = append(errs, s.QueryEngine.Spec.validate(specPath.Child("services", "queryengine", "spec"))...)
+	}
+	if s.Rabbitmq != nil {
+		errs = append(errs, s.Rabbitmq.Spec.validate(specPath.Child("services", "rabbitmq", "spec"))...)
+	}
+	for i, c := range s.Redis {
+		errs = append(errs, c.Spec.validate(specPath.Child("services", "redis").Index(i).Child("spec"))...)
+	}
+	for i, c := range s.Vrouters {
+		errs = append(errs, c.Spec.validate(specPath.Child("services", "vrouters").Index(i).Child("spec"))...)
+	}
+	if s.Webui != nil {
+		errs = append(errs, s.Webui.Spec.validate(specPath.Child("services", "webui", "spec"))...)
+	}
+	if s.Zookeeper != nil {
+		errs = append(errs, s.Zookeeper.Spec.validate(specPath.Child("services", "zookeeper", "spec"))...)
+	}
+
+	// references to control instances
+	controlRef := func(name string, path *field.Path) {
+		if name == "" {
+			return
+		}
+		if _, ok := names["Control/"+name]; !ok {
+			errs = append(errs, field.NotFound(path, name))
+		}
+	}
+	for i, v := range s.Vrouters {
+		controlRef(v.Spec.ServiceConfiguration.ControlInstance,
+			specPath.Child("services", "vrouters").Index(i).Child("spec", "serviceConfiguration", "controlInstance"))
+	}
+	if s.Webui != nil {
+		controlRef(s.Webui.Spec.ServiceConfiguration.ControlInstance,
+			specPath.Child("services", "webui", "spec", "serviceConfiguration", "controlInstance"))
+	}
+
+	return append(errs, validatePortCollisions(services)...)
+}
+
+// validatePortCollisions checks that services running on the same nodes
+// with host network don't listen the same ports
+func validatePortCollisions(services []managerService) field.ErrorList {
+	var errs field.ErrorList
+	type portUser struct {
+		service managerService
+		name    string
+	}
+	groups := map[string]map[int]portUser{}
+	for _, s := range services {
+		group := fmt.Sprint(s.nodeSelector)
+		if groups[group] == nil {
+			groups[group] = map[int]portUser{}
+		}
+		ports := servicePorts(s.serviceConfiguration)
+		portNames := make([]string, 0, len(ports))
+		for name := range ports {
+			portNames = append(portNames, name)
+		}
+		sort.Strings(portNames)
+		for _, name := range portNames {
+			port := ports[name]
+			if user, ok := groups[group][port]; ok {
+				errs = append(errs, field.Invalid(s.path.Child("serviceConfiguration", name), port,
+					fmt.Sprintf("port is already used by %s %s (%s) on the same nodes", user.service.kind, user.service.name, user.name)))
+				continue
+			}
+			groups[group][port] = portUser{s, name}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,187 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newValidationManager() *Manager {
+	return &Manager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "tf"},
+		Spec: ManagerSpec{
+			CommonConfiguration: ManagerConfiguration{
+				NodeSelector: map[string]string{"node-role.kubernetes.io/master": ""},
+			},
+			Services: Services{
+				Cassandras: []*CassandraInput{{Metadata: Metadata{Name: "configdb1"}}},
+				Controls:   []*ControlInput{{Metadata: Metadata{Name: "control1"}}},
+				Vrouters: []*VrouterInput{{
+					Metadata: Metadata{Name: "vrouter1"},
+					Spec: VrouterSpec{ServiceConfiguration: VrouterConfiguration{
+						ControlInstance: "control1",
+					}},
+				}},
+			},
+		},
+	}
+}
+
+func TestManagerValidation(t *testing.T) {
+	m := newValidationManager()
+	m.Default()
+	require.NoError(t, m.ValidateCreate())
+	require.NotNil(t, m.Spec.Services.Cassandras[0].Spec.ServiceConfiguration.CqlPort, "defaults are stored in the spec")
+	assert.Equal(t, CassandraCqlPort, *m.Spec.Services.Cassandras[0].Spec.ServiceConfiguration.CqlPort)
+
+	m = newValidationManager()
+	m.Spec.Services.Vrouters[0].Spec.ServiceConfiguration.ControlInstance = "control2"
+	err := m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.services.vrouters[0].spec.serviceConfiguration.controlInstance")
+
+	m = newValidationManager()
+	m.Spec.Services.Controls = append(m.Spec.Services.Controls, &ControlInput{Metadata: Metadata{Name: "control1"}})
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Duplicate value")
+
+	m = newValidationManager()
+	m.Spec.CommonConfiguration.ClusterConfig = &KubernetesClusterConfig{
+		Networking: KubernetesClusterNetworking{PodSubnet: "10.32.0.0/12,fd00::/1280"},
+	}
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.clusterConfig.networking.podSubnet")
+
+	m = newValidationManager()
+	m.Spec.CommonConfiguration.LogLevel = "verbose"
+	m.Spec.Services.Controls[0].Spec.ServiceConfiguration.Containers = []*Container{{Name: "control", Image: "Bad Image"}}
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.logLevel")
+	assert.Contains(t, err.Error(), "spec.services.controls[0].spec.serviceConfiguration.containers[0].image")
+
+	m = newValidationManager()
+	m.Spec.Services.Controls[0].Spec.ServiceConfiguration.Containers = []*Container{{
+		Name:  "control",
+		Image: "tungstenfabric/contrail-controller-control-control:latest",
+		Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+	}}
+	m.Spec.Services.Cassandras[0].Spec.ServiceConfiguration.MaxHeapSize = "1.5g"
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.services.controls[0].spec.serviceConfiguration.containers[0].resources.requests[memory]")
+	assert.Contains(t, err.Error(), "spec.services.cassandras[0].spec.serviceConfiguration.maxHeapSize")
+
+	even, odd := int32(2), int32(3)
+	m = newValidationManager()
+	m.Spec.Services.Cassandras[0].Spec.CommonConfiguration.Replicas = &even
+	m.Spec.Services.Controls[0].Spec.CommonConfiguration.Replicas = &even
+	m.Spec.Services.Vrouters[0].Spec.CommonConfiguration.Replicas = &odd
+	m.Spec.Services.Controls[0].Spec.CommonConfiguration.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+		MaxSkew: 1, WhenUnsatisfiable: corev1.DoNotSchedule,
+	}}
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.services.cassandras[0].spec.commonConfiguration.replicas: Invalid value: 2: must be odd")
+	assert.NotContains(t, err.Error(), "spec.services.controls[0].spec.commonConfiguration.replicas")
+	assert.Contains(t, err.Error(), "spec.services.controls[0].spec.commonConfiguration.topologySpreadConstraints[0].topologyKey")
+	assert.Contains(t, err.Error(), "spec.services.vrouters[0].spec.commonConfiguration.replicas: Forbidden")
+
+	m.Spec.Services.Cassandras[0].Spec.CommonConfiguration.Replicas = &odd
+	m.Spec.Services.Vrouters[0].Spec.CommonConfiguration.Replicas = nil
+	m.Spec.Services.Controls[0].Spec.CommonConfiguration.TopologySpreadConstraints[0].TopologyKey = "topology.kubernetes.io/zone"
+	assert.NoError(t, m.ValidateCreate())
+
+	certDays, caDays, percent := 400, 365, 100
+	m = newValidationManager()
+	m.Spec.CommonConfiguration.CertValidityDays = &certDays
+	m.Spec.CommonConfiguration.CACertValidityDays = &caDays
+	m.Spec.CommonConfiguration.CertRenewalPercent = &percent
+	err = m.ValidateCreate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.certValidityDays: Invalid value: 400: must not exceed caCertValidityDays 365")
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.certRenewalPercent")
+
+	certDays, percent = 90, 80
+	assert.NoError(t, m.ValidateCreate())
+}
+
+func TestManagerDefaultAllServices(t *testing.T) {
+	m := newValidationManager()
+	m.Spec.Services.AnalyticsAlarm = &AnalyticsAlarmInput{Metadata: Metadata{Name: "analyticsalarm1"}}
+	m.Spec.Services.AnalyticsSnmp = &AnalyticsSnmpInput{Metadata: Metadata{Name: "analyticssnmp1"}}
+	m.Spec.Services.Kubemanagers = []*KubemanagerInput{{Metadata: Metadata{Name: "kubemanager1"}}}
+	m.Spec.Services.Kubemanager = &KubemanagerInput{Metadata: Metadata{Name: "kubemanager"}}
+	m.Default()
+	require.NoError(t, m.ValidateCreate())
+
+	s := m.Spec.Services
+	require.NotNil(t, s.AnalyticsAlarm.Spec.ServiceConfiguration.AlarmgenPartitions)
+	assert.Equal(t, AlarmgenPartitions, *s.AnalyticsAlarm.Spec.ServiceConfiguration.AlarmgenPartitions)
+	require.NotNil(t, s.AnalyticsSnmp.Spec.ServiceConfiguration.TopologyIntrospectListenPort)
+	assert.Equal(t, TopologyIntrospectPort, *s.AnalyticsSnmp.Spec.ServiceConfiguration.TopologyIntrospectListenPort)
+	for _, k := range []*KubemanagerInput{s.Kubemanagers[0], s.Kubemanager} {
+		require.NotNil(t, k.Spec.ServiceConfiguration.IPFabricSnat, k.Metadata.Name)
+		assert.Equal(t, KubernetesIPFabricSnat, *k.Spec.ServiceConfiguration.IPFabricSnat)
+		assert.Empty(t, k.Spec.ServiceConfiguration.PodSubnet, "cluster parameters are resolved at runtime")
+	}
+	vrouter := s.Vrouters[0].Spec.ServiceConfiguration
+	require.NotNil(t, vrouter.SslEnable)
+	assert.True(t, *vrouter.SslEnable)
+	assert.Empty(t, vrouter.KubernetesApiSecurePort, "cluster parameters are resolved at runtime")
+
+	snat := false
+	m.Spec.Services.Kubemanagers[0].Spec.ServiceConfiguration.IPFabricSnat = &snat
+	m.Default()
+	assert.False(t, *m.Spec.Services.Kubemanagers[0].Spec.ServiceConfiguration.IPFabricSnat, "set values are kept")
+}
+
+func TestManagerPortCollisions(t *testing.T) {
+	m := newValidationManager()
+	m.Spec.Services.Cassandras = append(m.Spec.Services.Cassandras, &CassandraInput{Metadata: Metadata{Name: "analyticsdb1"}})
+	m.Default()
+	err := m.ValidateCreate()
+	require.Error(t, err, "two databases with default ports on the same nodes")
+	assert.Contains(t, err.Error(), "spec.services.cassandras[1].spec.serviceConfiguration.cqlPort")
+
+	m.Spec.Services.Cassandras[1].Spec.CommonConfiguration.NodeSelector = map[string]string{"analytics": ""}
+	assert.NoError(t, m.ValidateCreate(), "databases run on different nodes")
+}
+
+func TestImmutableFields(t *testing.T) {
+	port := 9141
+	old := &Cassandra{ObjectMeta: metav1.ObjectMeta{Name: "configdb1", Namespace: "tf"}}
+	old.Default()
+	cassandra := old.DeepCopy()
+	require.NoError(t, cassandra.ValidateUpdate(old))
+	cassandra.Spec.ServiceConfiguration.CqlPort = &port
+	err := cassandra.ValidateUpdate(old)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.serviceConfiguration.cqlPort")
+
+	oldKubemanager := &Kubemanager{ObjectMeta: metav1.ObjectMeta{Name: "kubemanager1", Namespace: "tf"}}
+	oldKubemanager.Spec.ServiceConfiguration.ClusterName = "k8s"
+	kubemanager := oldKubemanager.DeepCopy()
+	kubemanager.Spec.ServiceConfiguration.ClusterName = "east"
+	err = kubemanager.ValidateUpdate(oldKubemanager)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.serviceConfiguration.clusterName")
+
+	oldManager := newValidationManager()
+	oldManager.Spec.CommonConfiguration.ClusterConfig = &KubernetesClusterConfig{ClusterName: "k8s"}
+	m := oldManager.DeepCopy()
+	require.NoError(t, m.ValidateUpdate(oldManager))
+	m.Spec.CommonConfiguration.ClusterConfig.ClusterName = "east"
+	err = m.ValidateUpdate(oldManager)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.commonConfiguration.clusterConfig.clusterName")
+}
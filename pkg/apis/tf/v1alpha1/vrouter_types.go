@@ -55,6 +55,15 @@ type VrouterStatus struct {
 	Active              *bool               `json:"active,omitempty"`
 	ActiveOnControllers *bool               `json:"activeOnControllers,omitempty"`
 	Agents              []*AgentStatus      `json:"agents,omitempty"`
+	// ObservedGeneration is the generation of the spec the status is reported for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard conditions of the instance, e.g. Available
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // AgentStatus is the Status of the agent.
@@ -66,12 +75,29 @@ type AgentStatus struct {
 	ConfigNodes     string             `json:"configNodes,omitempty"`
 	AnalyticsNodes  string             `json:"analyticsNodes,omitempty"`
 	EncryptedParams string             `json:"encryptedParams,omitempty"`
+	// UpgradeState is the state of the agent in the rolling upgrade
+	UpgradeState AgentUpgradeState `json:"upgradeState,omitempty"`
+	// UpgradeStartTime is the time the rolling upgrade of the agent node is started
+	UpgradeStartTime *metav1.Time `json:"upgradeStartTime,omitempty"`
 }
 
 // AgentServiceStatus is the status value: Starting, Ready, Updating
 // +k8s:openapi-gen=true
 type AgentServiceStatus string
 
+// AgentUpgradeState is the rolling upgrade state value: Pending, Draining, Restarting, Upgraded, Failed
+// +k8s:openapi-gen=true
+type AgentUpgradeState string
+
+// Rolling upgrade states of an agent
+const (
+	AgentUpgradePending    AgentUpgradeState = "Pending"
+	AgentUpgradeDraining   AgentUpgradeState = "Draining"
+	AgentUpgradeRestarting AgentUpgradeState = "Restarting"
+	AgentUpgradeUpgraded   AgentUpgradeState = "Upgraded"
+	AgentUpgradeFailed     AgentUpgradeState = "Failed"
+)
+
 // VrouterSpec is the Spec for the vrouter API.
 // +k8s:openapi-gen=true
 type VrouterSpec struct {
@@ -210,6 +236,27 @@ type VrouterConfiguration struct {
 
 	// CniMTU - mtu for virtual tap devices
 	CniMTU *int `json:"cniMTU,omitempty"`
+
+	// UpgradePolicy enables rolling upgrade of agents by operator,
+	// if not set pods with old images have to be deleted manually
+	UpgradePolicy *VrouterUpgradePolicy `json:"upgradePolicy,omitempty"`
+}
+
+// VrouterUpgradePolicy is the policy of the rolling upgrade of agents.
+// +k8s:openapi-gen=true
+type VrouterUpgradePolicy struct {
+	// MaxUnavailable is the number of nodes upgraded at the same time (default 1)
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailable *int `json:"maxUnavailable,omitempty"`
+	// Drain evicts workload pods from a node before agent restart
+	Drain *bool `json:"drain,omitempty"`
+	// ReadyTimeout is the time in seconds for a node to get agent Ready (default 600),
+	// the upgrade is stopped if the time is exceeded
+	ReadyTimeout *int `json:"readyTimeout,omitempty"`
+	// Paused stops upgrade of new nodes, nodes in progress are completed
+	Paused *bool `json:"paused,omitempty"`
+	// Abort stops upgrade and uncordons nodes in progress
+	Abort *bool `json:"abort,omitempty"`
 }
 
 // VrouterList contains a list of Vrouter.
@@ -349,13 +396,7 @@ func (c *Vrouter) AddVolumesToIntendedDS(ds *appsv1.DaemonSet, volumeConfigMapMa
 	for configMapName, volumeName := range volumeConfigMapMap {
 		volume := corev1.Volume{
 			Name: volumeName,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: configMapName,
-					},
-				},
-			},
+			VolumeSource: configVolumeSource(configMapName),
 		}
 		volumeList = append(volumeList, volume)
 	}
@@ -378,6 +419,7 @@ func (c *Vrouter) CreateDS(ds *appsv1.DaemonSet,
 			if err != nil {
 				return err
 			}
+			RecordEvent(c, corev1.EventTypeNormal, EventReasonDaemonSetCreated, "DaemonSet %s is created", ds.Name)
 		}
 	}
 	return nil
@@ -424,6 +466,9 @@ func (c *Vrouter) SetInstanceActive(client client.Client, activeStatus *bool, ds
 	}
 
 	*activeStatus = active
+	c.Status.ObservedGeneration = c.Generation
+	SetPodConditions(&c.Status.Conditions, c.Generation, active, false, false,
+		ds.Status.DesiredNumberScheduled, ds.Status.NumberReady, ds.Status.UpdatedNumberScheduled)
 	if err := client.Status().Update(context.TODO(), object); err != nil {
 		return err
 	}
@@ -456,7 +501,7 @@ func (c *Vrouter) CreateCNIConfigMap(client client.Client, scheme *runtime.Schem
 		return nil, err
 	}
 	configMap.Data["10-tf-cni.conf"] = config
-	return configMap, client.Update(context.TODO(), configMap)
+	return configMap, saveConfigMap(configMap, false, client)
 }
 
 // ManageNodeStatus updates nodes map
@@ -485,6 +530,25 @@ func (c *Vrouter) VrouterConfigurationParameters(client client.Client) (*Vrouter
 		return nil, err
 	}
 
+	vrouterConfiguration := c.staticConfigurationParameters()
+
+	if vrouterConfiguration.KubernetesApiSecurePort == "" {
+		p, err := cinfo.KubernetesAPISSLPort()
+		if err != nil {
+			return nil, err
+		}
+		vrouterConfiguration.KubernetesApiSecurePort = strconv.Itoa(p)
+	}
+	if vrouterConfiguration.KubernetesPodSubnet == "" {
+		vrouterConfiguration.KubernetesPodSubnet = cinfo.Networking.PodSubnet
+	}
+
+	return vrouterConfiguration, nil
+}
+
+// staticConfigurationParameters returns the vRouter configuration with the defaults
+// that don't depend on the cluster parameters
+func (c *Vrouter) staticConfigurationParameters() *VrouterConfiguration {
 	vrouterConfiguration := c.Spec.ServiceConfiguration.DeepCopy()
 
 	trueVal := true
@@ -567,18 +631,7 @@ func (c *Vrouter) VrouterConfigurationParameters(client client.Client) (*Vrouter
 		vrouterConfiguration.IntrospectSslEnable = vrouterConfiguration.SslEnable
 	}
 
-	if vrouterConfiguration.KubernetesApiSecurePort == "" {
-		p, err := cinfo.KubernetesAPISSLPort()
-		if err != nil {
-			return nil, err
-		}
-		vrouterConfiguration.KubernetesApiSecurePort = strconv.Itoa(p)
-	}
-	if vrouterConfiguration.KubernetesPodSubnet == "" {
-		vrouterConfiguration.KubernetesPodSubnet = cinfo.Networking.PodSubnet
-	}
-
-	return vrouterConfiguration, nil
+	return vrouterConfiguration
 }
 
 // GetNodeDSPod returns daemonset pod by name
@@ -816,7 +869,7 @@ func (c *Vrouter) UpdateAgentParams(vrouterPod *VrouterPod,
 ) error {
 
 	configMap.Data["params.env."+vrouterPod.Pod.Status.PodIP] = params
-	return client.Update(context.Background(), configMap)
+	return saveConfigMap(configMap, false, client)
 }
 
 // UpdateAgentConfigMapForPod recalculates files `/etc/contrailconfigmaps/config_name.{$pod_ip}` in the agent configMap
@@ -850,7 +903,10 @@ func (c *Vrouter) UpdateAgentConfigMapForPod(vrouterPod *VrouterPod,
 		c.Spec.CommonConfiguration.AuthParameters,
 		srvCfg.PhysicalInterface, srvCfg.VrouterGateway, srvCfg.L3MHCidr)
 
-	return client.Update(context.Background(), configMap)
+	if err = ApplyConfigOverrides("vrouter", c.Spec.CommonConfiguration.ConfigOverrides, configMap.Data); err != nil {
+		return err
+	}
+	return saveConfigMap(configMap, false, client)
 }
 
 // RemoveAgentConfigMapForPod removes data
@@ -867,7 +923,7 @@ func (c *Vrouter) RemoveAgentConfigMapForPod(vrouterPod *VrouterPod,
 	delete(configMap.Data, "vrouter-nodemgr.env."+podIP)
 	delete(configMap.Data, "vrouter-provisioner.env."+podIP)
 
-	return client.Update(context.Background(), configMap)
+	return saveConfigMap(configMap, false, client)
 }
 
 // UpdateAgent waits for config updates and reload containers
@@ -941,6 +997,9 @@ func (c *Vrouter) UpdateAgent(nodeName string, agentStatus *AgentStatus, vrouter
 				}
 				if err = clnt.Delete(context.Background(), vrouterPod.Pod); err != nil {
 					ll.Error(err, "Remove pod failed", "pod", vrouterPod.Pod.Name)
+				} else {
+					RecordEvent(c, corev1.EventTypeNormal, EventReasonVhostReload,
+						"Pod %s is deleted to reinitialize vhost0 interface", vrouterPod.Pod.Name)
 				}
 			}
 			return true, err
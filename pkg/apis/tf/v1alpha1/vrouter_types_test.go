@@ -1,13 +1,19 @@
 package v1alpha1
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -108,3 +114,157 @@ func TestVrouterParamsTest(t *testing.T) {
 	require.Contains(t, paramsStr, "PHYSICAL_INTERFACE=\"ens3,ens4\"")
 	require.Contains(t, paramsStr, "VROUTER_HOSTNAME=test.k8s")
 }
+
+func vrouterUpgradeTestPod(nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vrouter1-vrouter-daemonset-" + nodeName,
+			Namespace: "tf",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "DaemonSet", Name: "vrouter1-vrouter-daemonset"},
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func requireNodeCordoned(t *testing.T, cl client.Client, nodeName string, cordoned bool) {
+	node := &corev1.Node{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node))
+	require.Equal(t, cordoned, node.Spec.Unschedulable, nodeName)
+	_, ok := node.Annotations[vrouterUpgradeCordonAnnotation]
+	require.Equal(t, cordoned, ok, nodeName)
+}
+
+func TestVrouterRollingUpgrade(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+
+	vrouter := &Vrouter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vrouter1",
+			Namespace: "tf",
+		},
+		Spec: VrouterSpec{
+			ServiceConfiguration: VrouterConfiguration{
+				UpgradePolicy: &VrouterUpgradePolicy{},
+			},
+		},
+		Status: VrouterStatus{
+			Agents: []*AgentStatus{
+				{Name: "node2", Status: "Upgrading", UpgradeState: AgentUpgradePending},
+				{Name: "node1", Status: "Upgrading", UpgradeState: AgentUpgradePending},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "vrouter1-vrouter-daemonset", Namespace: "tf"}}
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	pod1 := vrouterUpgradeTestPod("node1")
+	pod2 := vrouterUpgradeTestPod("node2")
+	cl := fake.NewFakeClientWithScheme(scheme, vrouter, ds, node1, node2, pod1, pod2)
+
+	// first node in the order is upgraded, the second one waits
+	inProgress, err := vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.True(t, inProgress)
+	node1Status, node2Status := vrouter.LookupAgentStatus("node1"), vrouter.LookupAgentStatus("node2")
+	require.Equal(t, AgentUpgradeRestarting, node1Status.UpgradeState)
+	require.NotNil(t, node1Status.UpgradeStartTime)
+	require.Equal(t, AgentUpgradePending, node2Status.UpgradeState)
+	requireNodeCordoned(t, cl, "node1", true)
+	requireNodeCordoned(t, cl, "node2", false)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: pod1.Name, Namespace: pod1.Namespace}, &corev1.Pod{})
+	require.True(t, errors.IsNotFound(err), "agent pod must be deleted")
+
+	// nothing is started while the agent is not Ready
+	inProgress, err = vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.True(t, inProgress)
+	require.Equal(t, AgentUpgradePending, node2Status.UpgradeState)
+
+	// paused upgrade does not start new nodes
+	paused := true
+	vrouter.Spec.ServiceConfiguration.UpgradePolicy.Paused = &paused
+	node1Status.Status = "Ready"
+	inProgress, err = vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.True(t, inProgress)
+	require.Equal(t, AgentUpgradeUpgraded, node1Status.UpgradeState)
+	require.Equal(t, AgentUpgradePending, node2Status.UpgradeState)
+	requireNodeCordoned(t, cl, "node1", false)
+
+	vrouter.Spec.ServiceConfiguration.UpgradePolicy.Paused = nil
+	inProgress, err = vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.True(t, inProgress)
+	require.Equal(t, AgentUpgradeRestarting, node2Status.UpgradeState)
+	requireNodeCordoned(t, cl, "node2", true)
+
+	// rollout finished, states are cleaned up
+	node2Status.Status = "Ready"
+	inProgress, err = vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.False(t, inProgress)
+	require.Empty(t, node1Status.UpgradeState)
+	require.Empty(t, node2Status.UpgradeState)
+	requireNodeCordoned(t, cl, "node2", false)
+}
+
+func TestVrouterRollingUpgradeAbort(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+
+	timeout := 10
+	started := metav1.NewTime(metav1.Now().Add(-time.Minute))
+	vrouter := &Vrouter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vrouter1",
+			Namespace: "tf",
+		},
+		Spec: VrouterSpec{
+			ServiceConfiguration: VrouterConfiguration{
+				UpgradePolicy: &VrouterUpgradePolicy{ReadyTimeout: &timeout},
+			},
+		},
+		Status: VrouterStatus{
+			Agents: []*AgentStatus{
+				{Name: "node1", Status: "Starting", UpgradeState: AgentUpgradeRestarting, UpgradeStartTime: &started},
+				{Name: "node2", Status: "Upgrading", UpgradeState: AgentUpgradePending},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "vrouter1-vrouter-daemonset", Namespace: "tf"}}
+	node1 := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node1",
+			Annotations: map[string]string{vrouterUpgradeCordonAnnotation: "true"},
+		},
+		Spec: corev1.NodeSpec{Unschedulable: true},
+	}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	cl := fake.NewFakeClientWithScheme(scheme, vrouter, ds, node1, node2)
+
+	// agent is not Ready in time - the upgrade is stopped
+	inProgress, err := vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.True(t, inProgress)
+	require.Equal(t, AgentUpgradeFailed, vrouter.LookupAgentStatus("node1").UpgradeState)
+	require.Equal(t, AgentUpgradePending, vrouter.LookupAgentStatus("node2").UpgradeState)
+	requireNodeCordoned(t, cl, "node1", true)
+	requireNodeCordoned(t, cl, "node2", false)
+
+	abort := true
+	vrouter.Spec.ServiceConfiguration.UpgradePolicy.Abort = &abort
+	require.False(t, vrouter.IsRollingUpgradeEnabled())
+	inProgress, err = vrouter.RollingUpgradeAgents(ds, cl)
+	require.NoError(t, err)
+	require.False(t, inProgress)
+	require.Empty(t, vrouter.LookupAgentStatus("node1").UpgradeState)
+	require.Empty(t, vrouter.LookupAgentStatus("node2").UpgradeState)
+	requireNodeCordoned(t, cl, "node1", false)
+}
@@ -0,0 +1,230 @@
+package v1alpha1
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tungstenfabric/tf-operator/pkg/k8s"
+)
+
+// annotation of nodes cordoned by operator for agent upgrade,
+// nodes cordoned by an user are not uncordoned after upgrade
+const vrouterUpgradeCordonAnnotation = "tf-vrouter-upgrade-cordon"
+
+const defaultVrouterUpgradeReadyTimeout = 600
+
+// IsRollingUpgradeEnabled returns true if agents are upgraded by operator
+func (c *Vrouter) IsRollingUpgradeEnabled() bool {
+	policy := c.Spec.ServiceConfiguration.UpgradePolicy
+	return policy != nil && (policy.Abort == nil || !*policy.Abort)
+}
+
+// RollingUpgradeAgents moves agents through the rolling upgrade according to UpgradePolicy:
+// not more than MaxUnavailable nodes are cordoned, drained and get agent pod recreated,
+// next nodes are started when agents of the previous ones are Ready.
+// Returns true if the upgrade is in progress.
+func (c *Vrouter) RollingUpgradeAgents(ds *appsv1.DaemonSet, clnt client.Client) (bool, error) {
+	policy := c.Spec.ServiceConfiguration.UpgradePolicy
+	if policy == nil {
+		return false, nil
+	}
+	ll := vrouter_log.WithName("RollingUpgradeAgents").WithName(c.Name)
+
+	if !c.IsRollingUpgradeEnabled() {
+		for _, s := range c.Status.Agents {
+			if s.UpgradeState == "" {
+				continue
+			}
+			ll.Info("Abort upgrade", "node", s.Name, "state", s.UpgradeState)
+			if err := uncordonNode(s.Name, clnt); err != nil {
+				return true, err
+			}
+			s.UpgradeState = ""
+			s.UpgradeStartTime = nil
+		}
+		return false, nil
+	}
+
+	maxUnavailable := 1
+	if policy.MaxUnavailable != nil && *policy.MaxUnavailable > 1 {
+		maxUnavailable = *policy.MaxUnavailable
+	}
+	readyTimeout := defaultVrouterUpgradeReadyTimeout
+	if policy.ReadyTimeout != nil && *policy.ReadyTimeout > 0 {
+		readyTimeout = *policy.ReadyTimeout
+	}
+	drain := policy.Drain != nil && *policy.Drain
+	paused := policy.Paused != nil && *policy.Paused
+
+	inProgress, failed := 0, 0
+	var pending []*AgentStatus
+	for _, s := range c.Status.Agents {
+		switch s.UpgradeState {
+		case AgentUpgradePending:
+			if s.Status != "Upgrading" {
+				// pod has been recreated with new images outside of the rolling upgrade
+				s.UpgradeState = AgentUpgradeUpgraded
+				continue
+			}
+			pending = append(pending, s)
+		case AgentUpgradeFailed:
+			failed++
+		case AgentUpgradeDraining, AgentUpgradeRestarting:
+			if s.UpgradeState == AgentUpgradeRestarting && s.Status == "Ready" {
+				if err := uncordonNode(s.Name, clnt); err != nil {
+					return true, err
+				}
+				ll.Info("Agent upgraded", "node", s.Name)
+				s.UpgradeState = AgentUpgradeUpgraded
+				continue
+			}
+			if s.UpgradeStartTime != nil && time.Since(s.UpgradeStartTime.Time) > time.Duration(readyTimeout)*time.Second {
+				// node is left cordoned for investigation
+				ll.Info("Agent is not Ready in time, upgrade is stopped", "node", s.Name, "timeout", readyTimeout)
+				s.UpgradeState = AgentUpgradeFailed
+				failed++
+				continue
+			}
+			inProgress++
+			if s.UpgradeState == AgentUpgradeDraining {
+				if err := c.restartAgentNode(s, ds, drain, clnt); err != nil {
+					return true, err
+				}
+			}
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+	for _, s := range pending {
+		if paused || failed > 0 || inProgress >= maxUnavailable {
+			break
+		}
+		ll.Info("Start agent upgrade", "node", s.Name)
+		if err := cordonNode(s.Name, clnt); err != nil {
+			return true, err
+		}
+		now := metav1.Now()
+		s.UpgradeStartTime = &now
+		s.UpgradeState = AgentUpgradeDraining
+		inProgress++
+		if err := c.restartAgentNode(s, ds, drain, clnt); err != nil {
+			return true, err
+		}
+	}
+
+	if len(pending) > 0 || inProgress > 0 || failed > 0 {
+		ll.Info("Upgrade in progress", "pending", len(pending), "inProgress", inProgress, "failed", failed, "paused", paused)
+		return true, nil
+	}
+	for _, s := range c.Status.Agents {
+		if s.UpgradeState == AgentUpgradeUpgraded {
+			s.UpgradeState = ""
+			s.UpgradeStartTime = nil
+		}
+	}
+	return false, nil
+}
+
+// restartAgentNode evicts workload pods if drain is requested
+// and deletes agent pod to let DaemonSet to recreate it with new images
+func (c *Vrouter) restartAgentNode(s *AgentStatus, ds *appsv1.DaemonSet, drain bool, clnt client.Client) error {
+	if drain {
+		drained, err := c.drainNode(s.Name)
+		if err != nil || !drained {
+			return err
+		}
+	}
+	if pod := c.GetNodeDSPod(s.Name, ds, clnt); pod != nil {
+		vrouter_log.Info("Delete agent pod for upgrade", "node", s.Name, "pod", pod.Name)
+		if err := clnt.Delete(context.Background(), pod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	s.UpgradeState = AgentUpgradeRestarting
+	return nil
+}
+
+// drainNode evicts workload pods from the node, DaemonSet pods, mirror pods
+// and pods of the TF namespace are kept.
+// Returns true if there are no more pods to wait for.
+func (c *Vrouter) drainNode(nodeName string) (bool, error) {
+	// client is limited by the watch namespace, so list pods of all namespaces directly
+	allPods, err := k8s.GetCoreV1().Pods("").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return false, err
+	}
+	drained := true
+	for _, pod := range allPods.Items {
+		if pod.Spec.NodeName != nodeName || pod.Namespace == c.Namespace ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		drained = false
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		eviction := &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+		if err := k8s.GetCoreV1().Pods(pod.Namespace).Evict(context.Background(), eviction); err != nil {
+			if errors.IsTooManyRequests(err) {
+				// disruption budget does not allow eviction now
+				vrouter_log.Info("Pod eviction is postponed", "node", nodeName, "pod", pod.Name)
+				continue
+			}
+			if !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+	}
+	return drained, nil
+}
+
+func cordonNode(nodeName string, clnt client.Client) error {
+	node := &corev1.Node{}
+	if err := clnt.Get(context.Background(), types.NamespacedName{Name: nodeName}, node); err != nil {
+		return err
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = true
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[vrouterUpgradeCordonAnnotation] = "true"
+	return clnt.Update(context.Background(), node)
+}
+
+func uncordonNode(nodeName string, clnt client.Client) error {
+	node := &corev1.Node{}
+	if err := clnt.Get(context.Background(), types.NamespacedName{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := node.Annotations[vrouterUpgradeCordonAnnotation]; !ok {
+		return nil
+	}
+	node.Spec.Unschedulable = false
+	delete(node.Annotations, vrouterUpgradeCordonAnnotation)
+	return clnt.Update(context.Background(), node)
+}
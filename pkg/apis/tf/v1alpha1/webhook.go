@@ -0,0 +1,486 @@
+package v1alpha1
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// WebhookObjects are the kinds checked by admission webhooks before they are stored
+var WebhookObjects = []runtime.Object{
+	&Manager{},
+	&Analytics{},
+	&AnalyticsAlarm{},
+	&AnalyticsSnmp{},
+	&Cassandra{},
+	&Config{},
+	&Control{},
+	&Kubemanager{},
+	&QueryEngine{},
+	&Rabbitmq{},
+	&Redis{},
+	&Vrouter{},
+	&Webui{},
+	&Zookeeper{},
+}
+
+// AddWebhooksToManager registers defaulting and validating webhooks of WebhookObjects in the webhook server
+func AddWebhooksToManager(mgr manager.Manager) error {
+	for _, obj := range WebhookObjects {
+		if err := builder.WebhookManagedBy(mgr).For(obj).Complete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ webhook.Defaulter = &Manager{}
+var _ webhook.Validator = &Manager{}
+var _ webhook.Defaulter = &Analytics{}
+var _ webhook.Validator = &Analytics{}
+var _ webhook.Defaulter = &AnalyticsAlarm{}
+var _ webhook.Validator = &AnalyticsAlarm{}
+var _ webhook.Defaulter = &AnalyticsSnmp{}
+var _ webhook.Validator = &AnalyticsSnmp{}
+var _ webhook.Defaulter = &Cassandra{}
+var _ webhook.Validator = &Cassandra{}
+var _ webhook.Defaulter = &Config{}
+var _ webhook.Validator = &Config{}
+var _ webhook.Defaulter = &Control{}
+var _ webhook.Validator = &Control{}
+var _ webhook.Defaulter = &Kubemanager{}
+var _ webhook.Validator = &Kubemanager{}
+var _ webhook.Defaulter = &QueryEngine{}
+var _ webhook.Validator = &QueryEngine{}
+var _ webhook.Defaulter = &Rabbitmq{}
+var _ webhook.Validator = &Rabbitmq{}
+var _ webhook.Defaulter = &Redis{}
+var _ webhook.Validator = &Redis{}
+var _ webhook.Defaulter = &Vrouter{}
+var _ webhook.Validator = &Vrouter{}
+var _ webhook.Validator = &Webui{}
+var _ webhook.Defaulter = &Zookeeper{}
+var _ webhook.Validator = &Zookeeper{}
+
+// setDefaults sets unset optional values of serviceConfiguration to the values of defaults,
+// that are the configuration parameters computed by the service.
+// Only scalar pointer fields are set, the other fields may depend on the merged
+// common configuration and are computed by the service at runtime.
+func setDefaults(serviceConfiguration interface{}, defaults interface{}) {
+	dst := reflect.ValueOf(serviceConfiguration).Elem()
+	src := reflect.Indirect(reflect.ValueOf(defaults))
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Field(i)
+		if f.Kind() != reflect.Ptr || !f.IsNil() || f.Type().Elem().Kind() == reflect.Struct {
+			continue
+		}
+		if v := src.Field(i); !v.IsNil() {
+			value := reflect.New(v.Type().Elem())
+			value.Elem().Set(v.Elem())
+			f.Set(value)
+		}
+	}
+}
+
+func invalidError(obj runtime.Object, name string, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	kind := reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	return apierrors.NewInvalid(SchemeGroupVersion.WithKind(kind).GroupKind(), name, errs)
+}
+
+// Default implements webhook.Defaulter
+func (c *Manager) Default() {
+	s := &c.Spec.Services
+	if s.Analytics != nil {
+		obj := &Analytics{Spec: s.Analytics.Spec}
+		obj.Default()
+		s.Analytics.Spec = obj.Spec
+	}
+	if s.AnalyticsAlarm != nil {
+		obj := &AnalyticsAlarm{Spec: s.AnalyticsAlarm.Spec}
+		obj.Default()
+		s.AnalyticsAlarm.Spec = obj.Spec
+	}
+	if s.AnalyticsSnmp != nil {
+		obj := &AnalyticsSnmp{Spec: s.AnalyticsSnmp.Spec}
+		obj.Default()
+		s.AnalyticsSnmp.Spec = obj.Spec
+	}
+	for _, i := range s.Cassandras {
+		obj := &Cassandra{Spec: i.Spec}
+		obj.Default()
+		i.Spec = obj.Spec
+	}
+	if s.Config != nil {
+		obj := &Config{Spec: s.Config.Spec}
+		obj.Default()
+		s.Config.Spec = obj.Spec
+	}
+	for _, i := range s.Controls {
+		obj := &Control{Spec: i.Spec}
+		obj.Default()
+		i.Spec = obj.Spec
+	}
+	for _, i := range s.KubemanagerInputs() {
+		obj := &Kubemanager{Spec: i.Spec}
+		obj.Default()
+		i.Spec = obj.Spec
+	}
+	if s.QueryEngine != nil {
+		obj := &QueryEngine{Spec: s.QueryEngine.Spec}
+		obj.Default()
+		s.QueryEngine.Spec = obj.Spec
+	}
+	if s.Rabbitmq != nil {
+		obj := &Rabbitmq{Spec: s.Rabbitmq.Spec}
+		obj.Default()
+		s.Rabbitmq.Spec = obj.Spec
+	}
+	for _, i := range s.Redis {
+		obj := &Redis{Spec: i.Spec}
+		obj.Default()
+		i.Spec = obj.Spec
+	}
+	for _, i := range s.Vrouters {
+		obj := &Vrouter{Spec: i.Spec}
+		obj.Default()
+		i.Spec = obj.Spec
+	}
+	// Webui has no optional parameters to be defaulted
+	if s.Zookeeper != nil {
+		obj := &Zookeeper{Spec: s.Zookeeper.Spec}
+		obj.Default()
+		s.Zookeeper.Spec = obj.Spec
+	}
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Manager) ValidateCreate() error {
+	return invalidError(c, c.Name, c.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Manager) ValidateUpdate(old runtime.Object) error {
+	errs := c.validate()
+	if oldManager, ok := old.(*Manager); ok {
+		path := field.NewPath("spec", "services")
+		for i, cassandra := range c.Spec.Services.Cassandras {
+			for _, oldCassandra := range oldManager.Spec.Services.Cassandras {
+				if cassandra.Metadata.Name == oldCassandra.Metadata.Name {
+					errs = append(errs, validateCassandraUpdate(&cassandra.Spec, &oldCassandra.Spec, path.Child("cassandras").Index(i).Child("spec"))...)
+				}
+			}
+		}
+		for i, kubemanager := range c.Spec.Services.Kubemanagers {
+			for _, oldKubemanager := range oldManager.Spec.Services.KubemanagerInputs() {
+				if kubemanager.Metadata.Name == oldKubemanager.Metadata.Name {
+					errs = append(errs, validateKubemanagerUpdate(&kubemanager.Spec, &oldKubemanager.Spec, path.Child("kubemanagers").Index(i).Child("spec"))...)
+				}
+			}
+		}
+		if kubemanager := c.Spec.Services.Kubemanager; kubemanager != nil {
+			for _, oldKubemanager := range oldManager.Spec.Services.KubemanagerInputs() {
+				if kubemanager.Metadata.Name == oldKubemanager.Metadata.Name {
+					errs = append(errs, validateKubemanagerUpdate(&kubemanager.Spec, &oldKubemanager.Spec, path.Child("kubemanager", "spec"))...)
+				}
+			}
+		}
+		if oldConfig := oldManager.Spec.CommonConfiguration.ClusterConfig; oldConfig != nil && oldConfig.ClusterName != "" {
+			if newConfig := c.Spec.CommonConfiguration.ClusterConfig; newConfig == nil || newConfig.ClusterName != oldConfig.ClusterName {
+				errs = append(errs, field.Forbidden(field.NewPath("spec", "commonConfiguration", "clusterConfig", "clusterName"),
+					"cluster name can't be changed after creation"))
+			}
+		}
+	}
+	return invalidError(c, c.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Manager) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Analytics) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Analytics) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Analytics) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Analytics) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *AnalyticsAlarm) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *AnalyticsAlarm) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *AnalyticsAlarm) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *AnalyticsAlarm) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *AnalyticsSnmp) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *AnalyticsSnmp) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *AnalyticsSnmp) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *AnalyticsSnmp) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Cassandra) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Cassandra) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Cassandra) ValidateUpdate(old runtime.Object) error {
+	path := field.NewPath("spec")
+	errs := c.Spec.validate(path)
+	if oldCassandra, ok := old.(*Cassandra); ok {
+		errs = append(errs, validateCassandraUpdate(&c.Spec, &oldCassandra.Spec, path)...)
+	}
+	return invalidError(c, c.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Cassandra) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Config) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Config) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Config) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Config) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Control) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Control) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Control) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Control) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter.
+// The ports and subnets of the kubernetes cluster are resolved at runtime.
+func (c *Kubemanager) Default() {
+	kubernetesAPIPort := KubernetesApiPort
+	ipFabricForwarding := KubernetesIPFabricForwarding
+	ipFabricSnat := KubernetesIPFabricSnat
+	hostNetworkService := KubernetesHostNetworkService
+	setDefaults(&c.Spec.ServiceConfiguration, &KubemanagerConfiguration{
+		KubernetesAPIPort:  &kubernetesAPIPort,
+		IPFabricForwarding: &ipFabricForwarding,
+		IPFabricSnat:       &ipFabricSnat,
+		HostNetworkService: &hostNetworkService,
+	})
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Kubemanager) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Kubemanager) ValidateUpdate(old runtime.Object) error {
+	path := field.NewPath("spec")
+	errs := c.Spec.validate(path)
+	if oldKubemanager, ok := old.(*Kubemanager); ok {
+		errs = append(errs, validateKubemanagerUpdate(&c.Spec, &oldKubemanager.Spec, path)...)
+	}
+	return invalidError(c, c.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Kubemanager) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *QueryEngine) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *QueryEngine) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *QueryEngine) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *QueryEngine) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Rabbitmq) Default() {
+	// ConfigurationParameters fills the spec in place, credentials must not be stored in the spec
+	defaults := c.DeepCopy()
+	defaults.ConfigurationParameters()
+	setDefaults(&c.Spec.ServiceConfiguration, &defaults.Spec.ServiceConfiguration)
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Rabbitmq) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Rabbitmq) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Rabbitmq) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Redis) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Redis) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Redis) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Redis) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Vrouter) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.staticConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Vrouter) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Vrouter) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Vrouter) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Webui) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Webui) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Webui) ValidateDelete() error {
+	return nil
+}
+
+// Default implements webhook.Defaulter
+func (c *Zookeeper) Default() {
+	setDefaults(&c.Spec.ServiceConfiguration, c.ConfigurationParameters())
+}
+
+// ValidateCreate implements webhook.Validator
+func (c *Zookeeper) ValidateCreate() error {
+	return invalidError(c, c.Name, c.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator
+func (c *Zookeeper) ValidateUpdate(old runtime.Object) error {
+	return c.ValidateCreate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (c *Zookeeper) ValidateDelete() error {
+	return nil
+}
@@ -261,6 +261,11 @@ func (c *Webui) SetInstanceActive(client client.Client, activeStatus *bool, degr
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Webui) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // ManageNodeStatus updates nodes map
 func (c *Webui) ManageNodeStatus(nodes map[string]NodeInfo,
 	client client.Client) (updated bool, err error) {
@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ZiuVersionsConfigMap is the configmap in the namespace of the Manager with the table of ZIU versions
+	ZiuVersionsConfigMap = "tf-operator-ziu-versions"
+	// ZiuVersionsKey is the key of the table in ZiuVersionsConfigMap
+	ZiuVersionsKey = "versions.yaml"
+)
+
+// ZiuVersionHooks are hooks of the default ZIU plan for upgrade from deployed versions matching FromVersion
+type ZiuVersionHooks struct {
+	// FromVersion is the regular expression of the image tag of deployed services
+	FromVersion string `json:"fromVersion"`
+	// PreHooks are run once when ZIU is started
+	PreHooks []string `json:"preHooks,omitempty"`
+	// StagePreHooks are run before services of every stage are updated
+	StagePreHooks []string `json:"stagePreHooks,omitempty"`
+	// KindPreHooks are run before services of the kind are updated, after StagePreHooks
+	KindPreHooks map[string][]string `json:"kindPreHooks,omitempty"`
+}
+
+// defaultZiuVersions is used if there is no ZiuVersionsConfigMap,
+// services of any version are upgraded by hooks of upgrade from 20.11.
+// Kinds depending on redis patch it one more time to ensure it is active.
+const defaultZiuVersions = `
+- fromVersion: ".*"
+  preHooks: [` + ZiuHookPrepare2011 + `]
+  stagePreHooks: [` + ZiuHookResetStatusNodes2011 + `]
+  kindPreHooks:
+    QueryEngine: [` + ZiuHookResetRedisStatusNodes2011 + `]
+    Analytics: [` + ZiuHookResetRedisStatusNodes2011 + `]
+    AnalyticsAlarm: [` + ZiuHookResetRedisStatusNodes2011 + `]
+`
+
+// ParseZiuVersions parses the table of ZIU versions
+func ParseZiuVersions(data string) ([]ZiuVersionHooks, error) {
+	var versions []ZiuVersionHooks
+	if err := yaml.Unmarshal([]byte(data), &versions); err != nil {
+		return nil, fmt.Errorf("Failed to parse ZIU versions: %w", err)
+	}
+	for _, v := range versions {
+		if _, err := ziuVersionRegexp(v.FromVersion); err != nil {
+			return nil, fmt.Errorf("Invalid fromVersion %q of ZIU versions: %w", v.FromVersion, err)
+		}
+	}
+	return versions, nil
+}
+
+// GetZiuVersions returns the table of ZIU versions from ZiuVersionsConfigMap
+// or the default one if there is no configmap
+func GetZiuVersions(namespace string, clnt client.Client) ([]ZiuVersionHooks, error) {
+	cm := &corev1.ConfigMap{}
+	err := clnt.Get(context.TODO(), types.NamespacedName{Name: ZiuVersionsConfigMap, Namespace: namespace}, cm)
+	if k8serrors.IsNotFound(err) {
+		return ParseZiuVersions(defaultZiuVersions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseZiuVersions(cm.Data[ZiuVersionsKey])
+}
+
+// MatchZiuVersion returns the first hooks of the table matching the deployed version,
+// nil if there is no such hooks and the version is not upgraded by ZIU
+func MatchZiuVersion(versions []ZiuVersionHooks, version string) *ZiuVersionHooks {
+	for i := range versions {
+		if re, err := ziuVersionRegexp(versions[i].FromVersion); err == nil && re.MatchString(version) {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// ziuVersionRegexp compiles fromVersion to match the whole version
+func ziuVersionRegexp(fromVersion string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + fromVersion + ")$")
+}
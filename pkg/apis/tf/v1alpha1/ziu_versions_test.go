@@ -0,0 +1,102 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func ziuVersionsTestClient(t *testing.T, deployedImage string, objs ...runtime.Object) client.Client {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme))
+	manager := &Manager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "tf"},
+		Spec: ManagerSpec{Services: Services{Webui: &WebuiInput{
+			Metadata: Metadata{Name: "webui1"},
+			Spec: WebuiSpec{ServiceConfiguration: WebuiConfiguration{
+				Containers: []*Container{{Name: "webuiweb", Image: "tf-webui:22.1"}},
+			}},
+		}}},
+		Status: ManagerStatus{ZiuState: -1},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "webui1-webui-statefulset", Namespace: "tf"},
+		Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "webuiweb", Image: deployedImage}},
+		}}},
+	}
+	return fake.NewFakeClientWithScheme(scheme, append(objs, manager, sts)...)
+}
+
+func TestZiuVersionsDefault(t *testing.T) {
+	versions, err := ParseZiuVersions(defaultZiuVersions)
+	require.NoError(t, err)
+	hooks := MatchZiuVersion(versions, "2011.L1")
+	require.NotNil(t, hooks, "services of any version are upgraded by default")
+	assert.Equal(t, []string{ZiuHookPrepare2011}, hooks.PreHooks)
+
+	m := Manager{Status: ManagerStatus{ZiuFromVersion: "2011.L1"}}
+	plan := m.GetZiuPlan(versions)
+	assert.Equal(t, []string{ZiuHookPrepare2011}, plan.PreHooks)
+	for _, stage := range plan.Stages {
+		expected := []string{ZiuHookResetStatusNodes2011}
+		if stage.Kind == "QueryEngine" || stage.Kind == "Analytics" || stage.Kind == "AnalyticsAlarm" {
+			expected = append(expected, ZiuHookResetRedisStatusNodes2011)
+		}
+		assert.Equal(t, expected, stage.PreHooks, stage.Kind)
+	}
+
+	_, err = ParseZiuVersions(`[{fromVersion: "21.4("}]`)
+	assert.Error(t, err, "invalid regular expression")
+}
+
+func TestZiuVersionsFromConfigMap(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ZiuVersionsConfigMap, Namespace: "tf"},
+		Data: map[string]string{ZiuVersionsKey: `
+- fromVersion: "21\\.4.*"
+  preHooks: [Prepare214]
+  kindPreHooks:
+    Config: [ResetConfig214]
+`},
+	}
+
+	clnt := ziuVersionsTestClient(t, "tf-webui:2011.L1", cm)
+	required, err := IsZiuRequired(clnt)
+	require.NoError(t, err)
+	assert.False(t, required, "the version is not in the table")
+
+	clnt = ziuVersionsTestClient(t, "tf-webui:22.1", cm)
+	required, err = IsZiuRequired(clnt)
+	require.NoError(t, err)
+	assert.False(t, required, "the version is deployed already")
+
+	clnt = ziuVersionsTestClient(t, "tf-webui:21.4.1", cm)
+	required, err = IsZiuRequired(clnt)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	require.NoError(t, InitZiu(clnt))
+	manager, err := GetManagerObject(clnt)
+	require.NoError(t, err)
+	assert.Equal(t, "21.4.1", manager.Status.ZiuFromVersion)
+	plan, err := manager.ZiuPlan(clnt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Prepare214"}, plan.PreHooks)
+	for _, stage := range plan.Stages {
+		if stage.Kind == "Config" {
+			assert.Equal(t, []string{"ResetConfig214"}, stage.PreHooks)
+		} else {
+			assert.Empty(t, stage.PreHooks, stage.Kind)
+		}
+	}
+}
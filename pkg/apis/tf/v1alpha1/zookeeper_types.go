@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
 	"sort"
@@ -43,6 +44,7 @@ type ZookeeperConfiguration struct {
 	ServerPort        *int         `json:"serverPort,omitempty"`
 	AdminEnableServer *bool        `json:"adminEnabled,omitempty"`
 	AdminPort         *int         `json:"adminPort,omitempty"`
+	Storage           *Storage     `json:"storage,omitempty"`
 }
 
 // ZookeeperStatus defines the status of the zookeeper object.
@@ -50,6 +52,17 @@ type ZookeeperConfiguration struct {
 type ZookeeperStatus struct {
 	CommonStatus `json:",inline"`
 	Ports        ZookeeperStatusPorts `json:"ports,omitempty"`
+	Storage      *StorageStatus       `json:"storage,omitempty"`
+	// Ensemble is the dynamic ensemble configuration as seen by Zookeeper
+	Ensemble []ZookeeperMember `json:"ensemble,omitempty"`
+}
+
+// ZookeeperMember is a server of the Zookeeper dynamic ensemble.
+// +k8s:openapi-gen=true
+type ZookeeperMember struct {
+	ID      int    `json:"id"`
+	Address string `json:"address,omitempty"`
+	Role    string `json:"role,omitempty"`
 }
 
 // ZookeeperStatusPorts defines the status of the ports of the zookeeper object.
@@ -139,7 +152,10 @@ func (c *Zookeeper) CreateConfigMap(configMapName string,
 	request reconcile.Request) (*corev1.ConfigMap, error) {
 	data := make(map[string]string)
 	data["run-zookeeper.sh"] = c.CommonStartupScriptZK(
-		"exec zkServer.sh --config /var/lib/zookeeper start-foreground",
+		// data of the backup staged by operator is moved into place before the start
+		"if [ -f /var/lib/zookeeper/restore/.ready ] ; then "+
+			"rm -rf /var/lib/zookeeper/version-2 && mv /var/lib/zookeeper/restore/version-2 /var/lib/zookeeper/ && rm -rf /var/lib/zookeeper/restore ; fi ; "+
+			"exec zkServer.sh --config /var/lib/zookeeper start-foreground",
 		map[string]string{
 			"log4j.properties":        "log4j.properties",
 			"configuration.xsl":       "configuration.xsl",
@@ -216,14 +232,22 @@ func (c *Zookeeper) SetInstanceActive(client client.Client, activeStatus *bool,
 	return SetInstanceActive(client, activeStatus, degradedStatus, sts, request, c)
 }
 
+// GetCommonStatus returns the common status of the instance
+func (c *Zookeeper) GetCommonStatus() *CommonStatus {
+	return &c.Status.CommonStatus
+}
+
 // ZookeeperPod is a pod with zookeper service. It is an inheritor of corev1.Pod.
 type zookeeperPod struct {
 	Pod *corev1.Pod
 }
 
+// zookeeperExec runs commands in containers, it is replaced in unit tests
+var zookeeperExec = ExecToContainer
+
 // ExecToZookeeperContainer execute command on zookeeper container.
 func (zp *zookeeperPod) execToZookeeperContainer(command []string) (stdout, stderr string, err error) {
-	stdout, stderr, err = ExecToContainer(zp.Pod, "zookeeper", command, nil)
+	stdout, stderr, err = zookeeperExec(zp.Pod, "zookeeper", command, nil)
 	return
 }
 
@@ -245,18 +269,190 @@ func (zp *zookeeperPod) registrate(zConfig ZookeeperConfiguration) error {
 		return err
 	}
 
-	command := fmt.Sprintf("zkCli.sh -server %s reconfig -add \"server.%d=%s:%d:%d;%s:2181\"",
-		addr,
+	command := fmt.Sprintf("zkCli.sh -server %s reconfig -add \"server.%d=%s:%d;%s\"",
+		zookeeperHost(addr),
 		id+1,
-		addr,
-		electionPort,
+		net.JoinHostPort(addr, strconv.Itoa(electionPort)),
 		serverPort,
-		addr,
+		net.JoinHostPort(addr, "2181"),
 	)
 	_, _, err = zp.execToZookeeperContainer([]string{"bash", "-c", command})
 	return err
 }
 
+// zookeeperHost returns address of the client endpoint of the server for zkCli
+func zookeeperHost(addr string) string {
+	return net.JoinHostPort(addr, "2181")
+}
+
+// unregistrate removes server from the cluster.
+func (zp *zookeeperPod) unregistrate(id int) error {
+	command := fmt.Sprintf("zkCli.sh -server %s reconfig -remove %d", zookeeperHost(pod2node(*zp.Pod)), id)
+	_, _, err := zp.execToZookeeperContainer([]string{"bash", "-c", command})
+	return err
+}
+
+// getEnsemble reads the dynamic ensemble configuration from the cluster.
+func (zp *zookeeperPod) getEnsemble() ([]ZookeeperMember, error) {
+	command := fmt.Sprintf("zkCli.sh -server %s config", zookeeperHost(pod2node(*zp.Pod)))
+	stdout, _, err := zp.execToZookeeperContainer([]string{"bash", "-c", command})
+	if err != nil {
+		return nil, err
+	}
+	return parseZookeeperEnsemble(stdout), nil
+}
+
+var zookeeperServerRe = regexp.MustCompile(`(?m)^server\.(\d+)=(?:\[([^\]\s]+)\]|([^:;\s]+)):\d+:\d+(?::(\w+))?`)
+
+// parseZookeeperEnsemble parses servers from output of zkCli config command, e.g.
+// server.1=10.0.0.1:2888:3888:participant;0.0.0.0:2181
+// server.2=[fd00::2]:2888:3888:participant;[::]:2181
+func parseZookeeperEnsemble(config string) []ZookeeperMember {
+	var members []ZookeeperMember
+	for _, m := range zookeeperServerRe.FindAllStringSubmatch(config, -1) {
+		id, _ := strconv.Atoi(m[1])
+		address := m[2]
+		if address == "" {
+			address = m[3]
+		}
+		role := m[4]
+		if role == "" {
+			role = "participant"
+		}
+		members = append(members, ZookeeperMember{ID: id, Address: address, Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members
+}
+
+// ReconcileEnsemble removes servers of departed pods from the dynamic ensemble.
+// Server is departed if its pod is not in the list and its number is out of replicas,
+// the candidates are taken from Status.Nodes and from the ensemble itself.
+// Servers are removed one by one and only while the live servers keep the quorum.
+// Status is updated if the ensemble is changed.
+func (c *Zookeeper) ReconcileEnsemble(podIPList []corev1.Pod, replicas int32, clnt client.Client) (updated bool, err error) {
+	if len(podIPList) == 0 {
+		return false, nil
+	}
+	ll := zookeeperLog.WithName("ReconcileEnsemble").WithName(c.Name)
+
+	live := make(map[int]bool)
+	var zpod *zookeeperPod
+	for idx := range podIPList {
+		pod := &podIPList[idx]
+		id, err := getPodId(pod)
+		if err != nil {
+			return false, err
+		}
+		live[id+1] = true
+		if _, ok := c.Status.Nodes[pod.Name]; ok || zpod == nil {
+			// prefer already registered pod to query the cluster
+			zpod = &zookeeperPod{pod}
+		}
+	}
+	isDeparted := func(id int) bool {
+		return !live[id] && id > int(replicas)
+	}
+
+	refresh := len(c.Status.Ensemble) == 0
+	for name := range c.Status.Nodes {
+		if id, err := getPodId(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}); err == nil && isDeparted(id+1) {
+			refresh = true
+		}
+	}
+	members := make(map[int]bool)
+	for _, m := range c.Status.Ensemble {
+		members[m.ID] = true
+		refresh = refresh || isDeparted(m.ID)
+	}
+	for id := range live {
+		refresh = refresh || !members[id]
+	}
+	if !refresh {
+		return false, nil
+	}
+
+	ensemble, err := zpod.getEnsemble()
+	if err != nil {
+		return false, err
+	}
+	liveMembers := 0
+	for _, m := range ensemble {
+		if live[m.ID] {
+			liveMembers++
+		}
+	}
+	for _, m := range ensemble {
+		if !isDeparted(m.ID) {
+			continue
+		}
+		if len(ensemble) < 2 || liveMembers <= len(ensemble)/2 {
+			ll.Info("Server is not removed: no quorum of live servers", "id", m.ID,
+				"ensemble", len(ensemble), "live", liveMembers)
+			break
+		}
+		ll.Info("Remove server from ensemble", "id", m.ID, "address", m.Address)
+		if err = zpod.unregistrate(m.ID); err != nil {
+			return false, err
+		}
+		if ensemble, err = zpod.getEnsemble(); err != nil {
+			return false, err
+		}
+		// one server at a time, the rest on the next reconcile
+		break
+	}
+
+	if reflect.DeepEqual(c.Status.Ensemble, ensemble) {
+		return false, nil
+	}
+	c.Status.Ensemble = ensemble
+	if err = clnt.Status().Update(context.TODO(), c); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveMember removes the server of the pod out of replicas from the dynamic ensemble,
+// the server is removed only while the servers of the remaining pods keep the quorum.
+func (c *Zookeeper) RemoveMember(pod *corev1.Pod, remaining []corev1.Pod) (bool, string, error) {
+	id, err := getPodId(pod)
+	if err != nil {
+		return false, "", err
+	}
+	if len(remaining) == 0 {
+		return false, "No pod to query the ensemble", nil
+	}
+	zpod := &zookeeperPod{&remaining[0]}
+	ensemble, err := zpod.getEnsemble()
+	if err != nil {
+		return false, "", err
+	}
+	live := make(map[int]bool)
+	for idx := range remaining {
+		if rid, err := getPodId(&remaining[idx]); err == nil {
+			live[rid+1] = true
+		}
+	}
+	member, liveMembers := false, 0
+	for _, m := range ensemble {
+		member = member || m.ID == id+1
+		if live[m.ID] {
+			liveMembers++
+		}
+	}
+	if !member {
+		return true, "", nil
+	}
+	if liveMembers <= (len(ensemble)-1)/2 {
+		return false, fmt.Sprintf("No quorum of remaining servers: %d of %d", liveMembers, len(ensemble)-1), nil
+	}
+	zookeeperLog.Info("Remove server from ensemble", "id", id+1, "pod", pod.Name)
+	if err = zpod.unregistrate(id + 1); err != nil {
+		return false, "", err
+	}
+	return false, fmt.Sprintf("server.%d is being removed from ensemble", id+1), nil
+}
+
 func (c *Zookeeper) AddZKNode(podIPList []corev1.Pod) (nodes map[string]NodeInfo, err error) {
 	config := c.ConfigurationParameters()
 
@@ -269,7 +465,7 @@ func (c *Zookeeper) AddZKNode(podIPList []corev1.Pod) (nodes map[string]NodeInfo
 				return nodes, _err
 			}
 		}
-		nodes[name] = NodeInfo{IP: pod.Status.PodIP, Hostname: pod.Annotations["hostname"]}
+		nodes[name] = NodeInfo{IP: pod.Status.PodIP, Hostname: pod.Annotations["hostname"], IPs: PodIPs(&pod)}
 		c.Status.Nodes = nodes
 	}
 	return nodes, nil
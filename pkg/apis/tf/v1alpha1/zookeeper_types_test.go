@@ -1,6 +1,9 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,3 +86,194 @@ func TestZookeeperConfigMapWithCustomValues(t *testing.T) {
 	assert.Equal(t, "DEBUG", zookeeperConfig.Section("").Key("zookeeper.console.threshold").String())
 	assert.Equal(t, "DEBUG", zookeeperConfig.Section("").Key("zookeeper.log.threshold").String())
 }
+
+func TestZookeeperParseEnsemble(t *testing.T) {
+	config := `Connecting to node1:2181
+server.2=node2:2888:3888:participant;0.0.0.0:2181
+server.1=node1:2888:3888:participant;0.0.0.0:2181
+server.3=10.0.0.3:2888:3888:observer;0.0.0.0:2181
+server.4=[fd00::4]:2888:3888:participant;[::]:2181
+version=100000003
+`
+	members := parseZookeeperEnsemble(config)
+	assert.Equal(t, []ZookeeperMember{
+		{ID: 1, Address: "node1", Role: "participant"},
+		{ID: 2, Address: "node2", Role: "participant"},
+		{ID: 3, Address: "10.0.0.3", Role: "observer"},
+		{ID: 4, Address: "fd00::4", Role: "participant"},
+	}, members)
+	assert.Empty(t, parseZookeeperEnsemble("version=100000003\n"))
+}
+
+func TestZookeeperReconcileEnsembleUnchanged(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	zookeeper := &Zookeeper{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zookeeper1",
+			Namespace: "test-ns",
+		},
+		Status: ZookeeperStatus{
+			CommonStatus: CommonStatus{
+				Nodes: map[string]NodeInfo{
+					"zookeeper1-zookeeper-statefulset-0": {IP: "1.1.1.1", Hostname: "node1"},
+					"zookeeper1-zookeeper-statefulset-1": {IP: "2.2.2.2", Hostname: "node2"},
+				},
+			},
+			Ensemble: []ZookeeperMember{
+				{ID: 1, Address: "node1", Role: "participant"},
+				{ID: 2, Address: "node2", Role: "participant"},
+			},
+		},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, zookeeper)
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1-zookeeper-statefulset-0"}, Status: corev1.PodStatus{PodIP: "1.1.1.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1-zookeeper-statefulset-1"}, Status: corev1.PodStatus{PodIP: "2.2.2.2"}},
+	}
+
+	// the cluster is not queried if all members are alive
+	updated, err := zookeeper.ReconcileEnsemble(pods, 2, cl)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	// pod of the replica is restarted, it is not departed
+	updated, err = zookeeper.ReconcileEnsemble(pods[:1], 2, cl)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Len(t, zookeeper.Status.Ensemble, 2)
+}
+
+// zookeeperEnsembleStub serves zkCli commands by the ensemble of servers with the ids,
+// removed servers are dropped from the ensemble
+type zookeeperEnsembleStub struct {
+	ids      []int
+	commands []string
+}
+
+func (z *zookeeperEnsembleStub) exec(pod *corev1.Pod, container string, command []string, stdin io.Reader) (string, string, error) {
+	cmd := command[len(command)-1]
+	z.commands = append(z.commands, cmd)
+	if strings.HasSuffix(cmd, " config") {
+		var config string
+		for _, id := range z.ids {
+			config += fmt.Sprintf("server.%d=10.0.0.%d:2888:3888:participant;0.0.0.0:2181\n", id, id)
+		}
+		return config + "version=100000003\n", "", nil
+	}
+	var id int
+	if _, err := fmt.Sscanf(cmd[strings.Index(cmd, "reconfig -remove "):], "reconfig -remove %d", &id); err != nil {
+		return "", "", err
+	}
+	for i := range z.ids {
+		if z.ids[i] == id {
+			z.ids = append(z.ids[:i], z.ids[i+1:]...)
+			break
+		}
+	}
+	return "", "", nil
+}
+
+// newEnsemblePod returns the pod of the zookeeper1 sts with the ordinal,
+// its hostname and IP are the same to not depend on the deployer type
+func newEnsemblePod(ordinal int) corev1.Pod {
+	ip := fmt.Sprintf("10.0.0.%d", ordinal+1)
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("zookeeper1-zookeeper-statefulset-%d", ordinal),
+			Annotations: map[string]string{"hostname": ip},
+		},
+		Status: corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func newEnsembleZookeeper(ids ...int) *Zookeeper {
+	zookeeper := &Zookeeper{
+		ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1", Namespace: "test-ns"},
+		Status:     ZookeeperStatus{CommonStatus: CommonStatus{Nodes: map[string]NodeInfo{}}},
+	}
+	for _, id := range ids {
+		pod := newEnsemblePod(id - 1)
+		zookeeper.Status.Nodes[pod.Name] = NodeInfo{IP: pod.Status.PodIP, Hostname: pod.Status.PodIP}
+		zookeeper.Status.Ensemble = append(zookeeper.Status.Ensemble,
+			ZookeeperMember{ID: id, Address: pod.Status.PodIP, Role: "participant"})
+	}
+	return zookeeper
+}
+
+func TestZookeeperReconcileEnsembleRemovesDeparted(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	ensemble := &zookeeperEnsembleStub{ids: []int{1, 2, 3}}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		zookeeperExec = exec
+	}(zookeeperExec)
+	zookeeperExec = ensemble.exec
+
+	zookeeper := newEnsembleZookeeper(1, 2, 3)
+	cl := fake.NewFakeClientWithScheme(scheme, zookeeper)
+	pods := []corev1.Pod{newEnsemblePod(0), newEnsemblePod(1)}
+
+	updated, err := zookeeper.ReconcileEnsemble(pods, 2, cl)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{
+		"zkCli.sh -server 10.0.0.2:2181 config",
+		"zkCli.sh -server 10.0.0.2:2181 reconfig -remove 3",
+		"zkCli.sh -server 10.0.0.2:2181 config",
+	}, ensemble.commands, "the departed server is removed and the ensemble is re-read")
+	assert.Equal(t, []ZookeeperMember{
+		{ID: 1, Address: "10.0.0.1", Role: "participant"},
+		{ID: 2, Address: "10.0.0.2", Role: "participant"},
+	}, zookeeper.Status.Ensemble)
+
+	// the server out of replicas is kept while the live servers have no quorum
+	ensemble = &zookeeperEnsembleStub{ids: []int{1, 2, 3}}
+	zookeeperExec = ensemble.exec
+	zookeeper = newEnsembleZookeeper(1, 2, 3)
+	cl = fake.NewFakeClientWithScheme(scheme, zookeeper)
+	updated, err = zookeeper.ReconcileEnsemble(pods[:1], 1, cl)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	for _, cmd := range ensemble.commands {
+		assert.NotContains(t, cmd, "reconfig -remove")
+	}
+}
+
+func TestZookeeperRemoveMember(t *testing.T) {
+	ensemble := &zookeeperEnsembleStub{ids: []int{1, 2, 3}}
+	defer func(exec func(*corev1.Pod, string, []string, io.Reader) (string, string, error)) {
+		zookeeperExec = exec
+	}(zookeeperExec)
+	zookeeperExec = ensemble.exec
+
+	zookeeper := newEnsembleZookeeper(1, 2, 3)
+	leaving := newEnsemblePod(2)
+	remaining := []corev1.Pod{newEnsemblePod(0), newEnsemblePod(1)}
+
+	removed, message, err := zookeeper.RemoveMember(&leaving, remaining)
+	require.NoError(t, err)
+	assert.False(t, removed, "removal is confirmed by the ensemble on the next call")
+	assert.Equal(t, "server.3 is being removed from ensemble", message)
+	require.Len(t, ensemble.commands, 2)
+	assert.Equal(t, "zkCli.sh -server 10.0.0.1:2181 config", ensemble.commands[0])
+	assert.Equal(t, "zkCli.sh -server 10.0.0.1:2181 reconfig -remove 3", ensemble.commands[1])
+
+	removed, _, err = zookeeper.RemoveMember(&leaving, remaining)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Len(t, ensemble.commands, 3, "the server is not removed twice")
+
+	// the remaining server alone is not a quorum of the ensemble of 3
+	ensemble.ids = []int{1, 2, 3}
+	ensemble.commands = nil
+	removed, message, err = zookeeper.RemoveMember(&leaving, remaining[:1])
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, "No quorum of remaining servers: 1 of 2", message)
+	assert.Equal(t, []string{"zkCli.sh -server 10.0.0.1:2181 config"}, ensemble.commands)
+}
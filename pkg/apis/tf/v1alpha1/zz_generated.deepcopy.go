@@ -7,9 +7,30 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentStatus) DeepCopyInto(out *AgentStatus) {
+	*out = *in
+	if in.UpgradeStartTime != nil {
+		in, out := &in.UpgradeStartTime, &out.UpgradeStartTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentStatus.
+func (in *AgentStatus) DeepCopy() *AgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Analytics) DeepCopyInto(out *Analytics) {
 	*out = *in
@@ -199,7 +220,7 @@ func (in *AnalyticsStatus) DeepCopyInto(out *AnalyticsStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.ConfigChanged != nil {
@@ -364,7 +385,7 @@ func (in *QueryEngineStatus) DeepCopyInto(out *QueryEngineStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.ConfigChanged != nil {
@@ -505,7 +526,7 @@ func (in *AnalyticsSnmpStatus) DeepCopyInto(out *AnalyticsSnmpStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	return
@@ -664,7 +685,7 @@ func (in *AnalyticsAlarmStatus) DeepCopyInto(out *AnalyticsAlarmStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	return
@@ -842,6 +863,12 @@ func (in *CassandraConfiguration) DeepCopyInto(out *CassandraConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	out.CassandraParameters = in.CassandraParameters
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
 	return
 }
 
@@ -927,7 +954,32 @@ func (in *CommonStatus) DeepCopyInto(out *CommonStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RestartingPods != nil {
+		in, out := &in.RestartingPods, &out.RestartingPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(ScaleDownStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertificatesNotAfter != nil {
+		in, out := &in.CertificatesNotAfter, &out.CertificatesNotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.CertificatesRenewAt != nil {
+		in, out := &in.CertificatesRenewAt, &out.CertificatesRenewAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	return
@@ -936,29 +988,23 @@ func (in *CommonStatus) DeepCopyInto(out *CommonStatus) {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraStatus) DeepCopyInto(out *CassandraStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	out.Ports = in.Ports
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageStatus)
 		**out = **in
 	}
-	if in.ConfigChanged != nil {
-		in, out := &in.ConfigChanged, &out.ConfigChanged
-		*out = new(bool)
-		**out = **in
+	if in.Ring != nil {
+		in, out := &in.Ring, &out.Ring
+		*out = make([]CassandraRingNode, len(*in))
+		copy(*out, *in)
 	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.RingOperation != nil {
+		in, out := &in.RingOperation, &out.RingOperation
+		*out = new(CassandraRingOperation)
+		(*in).DeepCopyInto(*out)
 	}
-	out.Ports = in.Ports
 	return
 }
 
@@ -1025,6 +1071,11 @@ func (in *AuthParameters) DeepCopyInto(out *AuthParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.KeystoneCredentialsSource != nil {
+		in, out := &in.KeystoneCredentialsSource, &out.KeystoneCredentialsSource
+		*out = new(CredentialsSource)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1099,6 +1150,11 @@ func (in *ConfigConfiguration) DeepCopyInto(out *ConfigConfiguration) {
 			}
 		}
 	}
+	if in.APIAdminPort != nil {
+		in, out := &in.APIAdminPort, &out.APIAdminPort
+		*out = new(int)
+		**out = **in
+	}
 	if in.APIPort != nil {
 		in, out := &in.APIPort, &out.APIPort
 		*out = new(int)
@@ -1109,11 +1165,6 @@ func (in *ConfigConfiguration) DeepCopyInto(out *ConfigConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
-	if in.APIAdminPort != nil {
-		in, out := &in.APIAdminPort, &out.APIAdminPort
-		*out = new(int)
-		**out = **in
-	}
 	if in.APIWorkerCount != nil {
 		in, out := &in.APIWorkerCount, &out.APIWorkerCount
 		*out = new(int)
@@ -1159,6 +1210,11 @@ func (in *ConfigConfiguration) DeepCopyInto(out *ConfigConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
 	return
 }
 
@@ -1251,26 +1307,10 @@ func (in *ConfigSpec) DeepCopy() *ConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigStatus) DeepCopyInto(out *ConfigStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.ConfigChanged != nil {
-		in, out := &in.ConfigChanged, &out.ConfigChanged
-		*out = new(bool)
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageStatus)
 		**out = **in
 	}
 	return
@@ -1315,6 +1355,11 @@ func (in *Container) DeepCopyInto(out *Container) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1416,6 +1461,11 @@ func (in *ControlConfiguration) DeepCopyInto(out *ControlConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.ControlIntrospectPort != nil {
+		in, out := &in.ControlIntrospectPort, &out.ControlIntrospectPort
+		*out = new(int)
+		**out = **in
+	}
 	return
 }
 
@@ -1483,29 +1533,15 @@ func (in *ControlSpec) DeepCopy() *ControlSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlStatus) DeepCopyInto(out *ControlStatus) {
 	*out = *in
-	if in.ConfigChanged != nil {
-		in, out := &in.ConfigChanged, &out.ConfigChanged
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	out.Ports = in.Ports
+	if in.ServiceStatus != nil {
+		in, out := &in.ServiceStatus, &out.ServiceStatus
+		*out = make(map[string]ControlServiceStatus, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
-	out.Ports = in.Ports
 	return
 }
 
@@ -1690,28 +1726,7 @@ func (in *KubemanagerSpec) DeepCopy() *KubemanagerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubemanagerStatus) DeepCopyInto(out *KubemanagerStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.ConfigChanged != nil {
-		in, out := &in.ConfigChanged, &out.ConfigChanged
-		*out = new(bool)
-		**out = **in
-	}
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
 	return
 }
 
@@ -1753,22 +1768,6 @@ func (in *Manager) DeepCopyObject() runtime.Object {
 	return nil
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagerCondition) DeepCopyInto(out *ManagerCondition) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerCondition.
-func (in *ManagerCondition) DeepCopy() *ManagerCondition {
-	if in == nil {
-		return nil
-	}
-	out := new(ManagerCondition)
-	in.DeepCopyInto(out)
-	return out
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagerConfiguration) DeepCopyInto(out *ManagerConfiguration) {
 	*out = *in
@@ -1802,6 +1801,26 @@ func (in *ManagerConfiguration) DeepCopyInto(out *ManagerConfiguration) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.CertValidityDays != nil {
+		in, out := &in.CertValidityDays, &out.CertValidityDays
+		*out = new(int)
+		**out = **in
+	}
+	if in.CACertValidityDays != nil {
+		in, out := &in.CACertValidityDays, &out.CACertValidityDays
+		*out = new(int)
+		**out = **in
+	}
+	if in.CertRenewalPercent != nil {
+		in, out := &in.CertRenewalPercent, &out.CertRenewalPercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.ClusterConfig != nil {
+		in, out := &in.ClusterConfig, &out.ClusterConfig
+		*out = new(KubernetesClusterConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -1815,6 +1834,22 @@ func (in *ManagerConfiguration) DeepCopy() *ManagerConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagerCondition) DeepCopyInto(out *ManagerCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerCondition.
+func (in *ManagerCondition) DeepCopy() *ManagerCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagerCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagerList) DeepCopyInto(out *ManagerList) {
 	*out = *in
@@ -1853,6 +1888,21 @@ func (in *ManagerSpec) DeepCopyInto(out *ManagerSpec) {
 	*out = *in
 	in.CommonConfiguration.DeepCopyInto(&out.CommonConfiguration)
 	in.Services.DeepCopyInto(&out.Services)
+	if in.ZiuPlan != nil {
+		in, out := &in.ZiuPlan, &out.ZiuPlan
+		*out = new(ZiuPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1874,13 +1924,16 @@ func (in *ManagerStatus) DeepCopyInto(out *ManagerStatus) {
 		*out = new(ServiceStatus)
 		(*in).DeepCopyInto(*out)
 	}
-
 	if in.AnalyticsAlarm != nil {
 		in, out := &in.AnalyticsAlarm, &out.AnalyticsAlarm
 		*out = new(ServiceStatus)
 		(*in).DeepCopyInto(*out)
 	}
-
+	if in.Analytics != nil {
+		in, out := &in.Analytics, &out.Analytics
+		*out = new(ServiceStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = new(ServiceStatus)
@@ -1897,8 +1950,19 @@ func (in *ManagerStatus) DeepCopyInto(out *ManagerStatus) {
 			}
 		}
 	}
-	if in.Kubemanager != nil {
-		in, out := &in.Kubemanager, &out.Kubemanager
+	if in.Kubemanagers != nil {
+		in, out := &in.Kubemanagers, &out.Kubemanagers
+		*out = make([]*KubemanagerServiceStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(KubemanagerServiceStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.QueryEngine != nil {
+		in, out := &in.QueryEngine, &out.QueryEngine
 		*out = new(ServiceStatus)
 		(*in).DeepCopyInto(*out)
 	}
@@ -1929,6 +1993,16 @@ func (in *ManagerStatus) DeepCopyInto(out *ManagerStatus) {
 			}
 		}
 	}
+	if in.Zookeeper != nil {
+		in, out := &in.Zookeeper, &out.Zookeeper
+		*out = new(ServiceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rabbitmq != nil {
+		in, out := &in.Rabbitmq, &out.Rabbitmq
+		*out = new(ServiceStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Redis != nil {
 		in, out := &in.Redis, &out.Redis
 		*out = make([]*ServiceStatus, len(*in))
@@ -1940,27 +2014,61 @@ func (in *ManagerStatus) DeepCopyInto(out *ManagerStatus) {
 			}
 		}
 	}
-	if in.Zookeeper != nil {
-		in, out := &in.Zookeeper, &out.Zookeeper
-		*out = new(ServiceStatus)
-		(*in).DeepCopyInto(*out)
+	if in.CrdStatus != nil {
+		in, out := &in.CrdStatus, &out.CrdStatus
+		*out = make([]CrdStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.Rabbitmq != nil {
-		in, out := &in.Rabbitmq, &out.Rabbitmq
+	if in.ZiuStages != nil {
+		in, out := &in.ZiuStages, &out.ZiuStages
+		*out = make([]ZiuStageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Kubemanager != nil {
+		in, out := &in.Kubemanager, &out.Kubemanager
 		*out = new(ServiceStatus)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.CrdStatus != nil {
-		in, out := &in.CrdStatus, &out.CrdStatus
-		*out = make([]CrdStatus, len(*in))
+	if in.LastBackupTime != nil {
+		in, out := &in.LastBackupTime, &out.LastBackupTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Backups != nil {
+		in, out := &in.Backups, &out.Backups
+		*out = make([]BackupStatus, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterConfig != nil {
+		in, out := &in.ClusterConfig, &out.ClusterConfig
+		*out = new(KubernetesClusterConfig)
+		**out = **in
+	}
+	if in.ClusterConfigSources != nil {
+		in, out := &in.ClusterConfigSources, &out.ClusterConfigSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CACertificateNotAfter != nil {
+		in, out := &in.CACertificateNotAfter, &out.CACertificateNotAfter
+		*out = (*in).DeepCopy()
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]ManagerCondition, len(*in))
-		copy(*out, *in)
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -2003,16 +2111,70 @@ func (in *PodConfiguration) DeepCopyInto(out *PodConfiguration) {
 		*out = new(string)
 		**out = **in
 	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodConfiguration.
-func (in *PodConfiguration) DeepCopy() *PodConfiguration {
-	if in == nil {
-		return nil
-	}
-	out := new(PodConfiguration)
-	in.DeepCopyInto(out)
+	if in.ConfigOverrides != nil {
+		in, out := &in.ConfigOverrides, &out.ConfigOverrides
+		*out = make(map[string]ConfigFileOverrides, len(*in))
+		for key, val := range *in {
+			var outVal map[string]ConfigSectionOverrides
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(ConfigFileOverrides, len(*in))
+				for key, val := range *in {
+					var outVal map[string]string
+					if val == nil {
+						(*out)[key] = nil
+					} else {
+						in, out := &val, &outVal
+						*out = make(ConfigSectionOverrides, len(*in))
+						for key, val := range *in {
+							(*out)[key] = val
+						}
+					}
+					(*out)[key] = outVal
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleDownOnNodeRemoval != nil {
+		in, out := &in.ScaleDownOnNodeRemoval, &out.ScaleDownOnNodeRemoval
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodConfiguration.
+func (in *PodConfiguration) DeepCopy() *PodConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PodConfiguration)
+	in.DeepCopyInto(out)
 	return out
 }
 
@@ -2089,6 +2251,16 @@ func (in *RabbitmqConfiguration) DeepCopyInto(out *RabbitmqConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.CredentialsSource != nil {
+		in, out := &in.CredentialsSource, &out.CredentialsSource
+		*out = new(CredentialsSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsRotationIntervalHours != nil {
+		in, out := &in.CredentialsRotationIntervalHours, &out.CredentialsRotationIntervalHours
+		*out = new(int)
+		**out = **in
+	}
 	if in.MirroredQueueMode != nil {
 		in, out := &in.MirroredQueueMode, &out.MirroredQueueMode
 		*out = new(string)
@@ -2190,22 +2362,11 @@ func (in *RabbitmqSpec) DeepCopy() *RabbitmqSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitmqStatus) DeepCopyInto(out *RabbitmqStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	if in.CredentialsRotation != nil {
+		in, out := &in.CredentialsRotation, &out.CredentialsRotation
+		*out = new(RabbitmqCredentialsRotationStatus)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -2288,6 +2449,11 @@ func (in *RedisConfiguration) DeepCopyInto(out *RedisConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
 	return
 }
 
@@ -2355,26 +2521,10 @@ func (in *RedisSpec) DeepCopy() *RedisSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedisStatus) DeepCopyInto(out *RedisStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.ConfigChanged != nil {
-		in, out := &in.ConfigChanged, &out.ConfigChanged
-		*out = new(bool)
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageStatus)
 		**out = **in
 	}
 	return
@@ -2408,6 +2558,11 @@ func (in *ServiceStatus) DeepCopyInto(out *ServiceStatus) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Degraded != nil {
+		in, out := &in.Degraded, &out.Degraded
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -2483,10 +2638,16 @@ func (in *Services) DeepCopyInto(out *Services) {
 			}
 		}
 	}
-	if in.Kubemanager != nil {
-		in, out := &in.Kubemanager, &out.Kubemanager
-		*out = new(KubemanagerInput)
-		(*in).DeepCopyInto(*out)
+	if in.Kubemanagers != nil {
+		in, out := &in.Kubemanagers, &out.Kubemanagers
+		*out = make([]*KubemanagerInput, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(KubemanagerInput)
+				(*in).DeepCopyInto(*out)
+			}
+		}
 	}
 	if in.Webui != nil {
 		in, out := &in.Webui, &out.Webui
@@ -2525,6 +2686,11 @@ func (in *Services) DeepCopyInto(out *Services) {
 		*out = new(RabbitmqInput)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Kubemanager != nil {
+		in, out := &in.Kubemanager, &out.Kubemanager
+		*out = new(KubemanagerInput)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2619,11 +2785,81 @@ func (in *VrouterConfiguration) DeepCopyInto(out *VrouterConfiguration) {
 			(*out)[key] = val
 		}
 	}
+	if in.ConfigApiSslEnable != nil {
+		in, out := &in.ConfigApiSslEnable, &out.ConfigApiSslEnable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IntrospectSslEnable != nil {
+		in, out := &in.IntrospectSslEnable, &out.IntrospectSslEnable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KeystoneAuthInsecure != nil {
+		in, out := &in.KeystoneAuthInsecure, &out.KeystoneAuthInsecure
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LogLocal != nil {
+		in, out := &in.LogLocal, &out.LogLocal
+		*out = new(int)
+		**out = **in
+	}
+	if in.SandeshSslEnable != nil {
+		in, out := &in.SandeshSslEnable, &out.SandeshSslEnable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SslEnable != nil {
+		in, out := &in.SslEnable, &out.SslEnable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SslInsecure != nil {
+		in, out := &in.SslInsecure, &out.SslInsecure
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PriorityTagging != nil {
+		in, out := &in.PriorityTagging, &out.PriorityTagging
+		*out = new(bool)
+		**out = **in
+	}
+	if in.QosDefHwQueue != nil {
+		in, out := &in.QosDefHwQueue, &out.QosDefHwQueue
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VrouterEncryption != nil {
+		in, out := &in.VrouterEncryption, &out.VrouterEncryption
+		*out = new(bool)
+		**out = **in
+	}
+	if in.XmppSslEnable != nil {
+		in, out := &in.XmppSslEnable, &out.XmppSslEnable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HugePages2M != nil {
+		in, out := &in.HugePages2M, &out.HugePages2M
+		*out = new(int)
+		**out = **in
+	}
+	if in.HugePages1G != nil {
+		in, out := &in.HugePages1G, &out.HugePages1G
+		*out = new(int)
+		**out = **in
+	}
 	if in.CniMTU != nil {
 		in, out := &in.CniMTU, &out.CniMTU
 		*out = new(int)
 		**out = **in
 	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(VrouterUpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2695,7 +2931,7 @@ func (in *VrouterStatus) DeepCopyInto(out *VrouterStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.Active != nil {
@@ -2703,6 +2939,29 @@ func (in *VrouterStatus) DeepCopyInto(out *VrouterStatus) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ActiveOnControllers != nil {
+		in, out := &in.ActiveOnControllers, &out.ActiveOnControllers
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Agents != nil {
+		in, out := &in.Agents, &out.Agents
+		*out = make([]*AgentStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(AgentStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2716,6 +2975,47 @@ func (in *VrouterStatus) DeepCopy() *VrouterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VrouterUpgradePolicy) DeepCopyInto(out *VrouterUpgradePolicy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int)
+		**out = **in
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReadyTimeout != nil {
+		in, out := &in.ReadyTimeout, &out.ReadyTimeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.Paused != nil {
+		in, out := &in.Paused, &out.Paused
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Abort != nil {
+		in, out := &in.Abort, &out.Abort
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VrouterUpgradePolicy.
+func (in *VrouterUpgradePolicy) DeepCopy() *VrouterUpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VrouterUpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebUIServiceStatus) DeepCopyInto(out *WebUIServiceStatus) {
 	*out = *in
@@ -2888,7 +3188,7 @@ func (in *WebuiStatus) DeepCopyInto(out *WebuiStatus) {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make(map[string]NodeInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.Degraded != nil {
@@ -3015,6 +3315,11 @@ func (in *ZookeeperConfiguration) DeepCopyInto(out *ZookeeperConfiguration) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
 	return
 }
 
@@ -3082,24 +3387,18 @@ func (in *ZookeeperSpec) DeepCopy() *ZookeeperSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZookeeperStatus) DeepCopyInto(out *ZookeeperStatus) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Degraded != nil {
-		in, out := &in.Degraded, &out.Degraded
-		*out = new(bool)
+	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
+	out.Ports = in.Ports
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageStatus)
 		**out = **in
 	}
-	if in.Nodes != nil {
-		in, out := &in.Nodes, &out.Nodes
-		*out = make(map[string]NodeInfo, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Ensemble != nil {
+		in, out := &in.Ensemble, &out.Ensemble
+		*out = make([]ZookeeperMember, len(*in))
+		copy(*out, *in)
 	}
-	out.Ports = in.Ports
 	return
 }
 
@@ -3128,3 +3427,564 @@ func (in *ZookeeperStatusPorts) DeepCopy() *ZookeeperStatusPorts {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupConfiguration) DeepCopyInto(out *BackupConfiguration) {
+	*out = *in
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(int)
+		**out = **in
+	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	out.S3 = in.S3
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConfiguration.
+func (in *BackupConfiguration) DeepCopy() *BackupConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupS3) DeepCopyInto(out *BackupS3) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupS3.
+func (in *BackupS3) DeepCopy() *BackupS3 {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupS3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
+	*out = *in
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailedTime != nil {
+		in, out := &in.LastFailedTime, &out.LastFailedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
+func (in *BackupStatus) DeepCopy() *BackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRingNode) DeepCopyInto(out *CassandraRingNode) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRingNode.
+func (in *CassandraRingNode) DeepCopy() *CassandraRingNode {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRingNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRingOperation) DeepCopyInto(out *CassandraRingOperation) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRingOperation.
+func (in *CassandraRingOperation) DeepCopy() *CassandraRingOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRingOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ConfigFileOverrides) DeepCopyInto(out *ConfigFileOverrides) {
+	{
+		in := &in
+		*out = make(ConfigFileOverrides, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(ConfigSectionOverrides, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigFileOverrides.
+func (in ConfigFileOverrides) DeepCopy() ConfigFileOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigFileOverrides)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ConfigSectionOverrides) DeepCopyInto(out *ConfigSectionOverrides) {
+	{
+		in := &in
+		*out = make(ConfigSectionOverrides, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigSectionOverrides.
+func (in ConfigSectionOverrides) DeepCopy() ConfigSectionOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigSectionOverrides)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlServiceStatus) DeepCopyInto(out *ControlServiceStatus) {
+	*out = *in
+	out.BGPPeer = in.BGPPeer
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = make([]Connection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlServiceStatus.
+func (in *ControlServiceStatus) DeepCopy() *ControlServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSource) DeepCopyInto(out *CredentialsSource) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(SecretCredentialsSource)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultCredentialsSource)
+		**out = **in
+	}
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileCredentialsSource)
+		**out = **in
+	}
+	if in.RefreshPeriod != nil {
+		in, out := &in.RefreshPeriod, &out.RefreshPeriod
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSource.
+func (in *CredentialsSource) DeepCopy() *CredentialsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileCredentialsSource) DeepCopyInto(out *FileCredentialsSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileCredentialsSource.
+func (in *FileCredentialsSource) DeepCopy() *FileCredentialsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileCredentialsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubemanagerServiceStatus) DeepCopyInto(out *KubemanagerServiceStatus) {
+	*out = *in
+	in.ServiceStatus.DeepCopyInto(&out.ServiceStatus)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubemanagerServiceStatus.
+func (in *KubemanagerServiceStatus) DeepCopy() *KubemanagerServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubemanagerServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeInfo) DeepCopyInto(out *NodeInfo) {
+	*out = *in
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeInfo.
+func (in *NodeInfo) DeepCopy() *NodeInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqCredentialsRotationStatus) DeepCopyInto(out *RabbitmqCredentialsRotationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqCredentialsRotationStatus.
+func (in *RabbitmqCredentialsRotationStatus) DeepCopy() *RabbitmqCredentialsRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqCredentialsRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreConfiguration) DeepCopyInto(out *RestoreConfiguration) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(BackupS3)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreConfiguration.
+func (in *RestoreConfiguration) DeepCopy() *RestoreConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreServiceStatus) DeepCopyInto(out *RestoreServiceStatus) {
+	*out = *in
+	if in.StagedTime != nil {
+		in, out := &in.StagedTime, &out.StagedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreServiceStatus.
+func (in *RestoreServiceStatus) DeepCopy() *RestoreServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]RestoreServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
+func (in *RestoreStatus) DeepCopy() *RestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownMember) DeepCopyInto(out *ScaleDownMember) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownMember.
+func (in *ScaleDownMember) DeepCopy() *ScaleDownMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownStatus) DeepCopyInto(out *ScaleDownStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]ScaleDownMember, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownStatus.
+func (in *ScaleDownStatus) DeepCopy() *ScaleDownStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretCredentialsSource) DeepCopyInto(out *SecretCredentialsSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretCredentialsSource.
+func (in *SecretCredentialsSource) DeepCopy() *SecretCredentialsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretCredentialsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageStatus) DeepCopyInto(out *StorageStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageStatus.
+func (in *StorageStatus) DeepCopy() *StorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultCredentialsSource) DeepCopyInto(out *VaultCredentialsSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultCredentialsSource.
+func (in *VaultCredentialsSource) DeepCopy() *VaultCredentialsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultCredentialsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZiuPlan) DeepCopyInto(out *ZiuPlan) {
+	*out = *in
+	if in.PreHooks != nil {
+		in, out := &in.PreHooks, &out.PreHooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]ZiuPlanStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZiuPlan.
+func (in *ZiuPlan) DeepCopy() *ZiuPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(ZiuPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZiuPlanStage) DeepCopyInto(out *ZiuPlanStage) {
+	*out = *in
+	if in.ReadinessChecks != nil {
+		in, out := &in.ReadinessChecks, &out.ReadinessChecks
+		*out = make([]ZiuReadinessCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreHooks != nil {
+		in, out := &in.PreHooks, &out.PreHooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PostHooks != nil {
+		in, out := &in.PostHooks, &out.PostHooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZiuPlanStage.
+func (in *ZiuPlanStage) DeepCopy() *ZiuPlanStage {
+	if in == nil {
+		return nil
+	}
+	out := new(ZiuPlanStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZiuStageStatus) DeepCopyInto(out *ZiuStageStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZiuStageStatus.
+func (in *ZiuStageStatus) DeepCopy() *ZiuStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZiuStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZookeeperMember) DeepCopyInto(out *ZookeeperMember) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZookeeperMember.
+func (in *ZookeeperMember) DeepCopy() *ZookeeperMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ZookeeperMember)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,317 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/spf13/pflag"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis"
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/s3"
+)
+
+var log = logf.Log.WithName("backup")
+
+// Commands of the operator binary which run in backup and restore jobs
+const (
+	BackupCommand  = "backup"
+	RestoreCommand = "restore"
+)
+
+// Annotations of the job with the service and the folder of the backup in the store
+const (
+	ServiceAnnotation = "tf.tungsten.io/backup-service"
+	FolderAnnotation  = "tf.tungsten.io/backup-folder"
+)
+
+// JobLabels returns labels of jobs of the manager with the command
+func JobLabels(command, manager string) map[string]string {
+	return map[string]string{"tf_manager": command, command: manager}
+}
+
+const (
+	namespaceEnv = "NAMESPACE"
+	accessKeyEnv = "S3_ACCESS_KEY"
+	secretKeyEnv = "S3_SECRET_KEY"
+)
+
+// Kinds of the services with backups
+const (
+	CassandraKind = "cassandra"
+	ZookeeperKind = "zookeeper"
+)
+
+// IsJobCommand returns true if the operator binary is started by a job with the command
+func IsJobCommand(command string) bool {
+	return command == BackupCommand || command == RestoreCommand
+}
+
+// NewBackupJob returns the job which streams archives of the service pods into the folder of the store,
+// the job is interrupted after the deadline.
+func NewBackupJob(name string, manager *v1alpha1.Manager, operatorPod *corev1.Pod, kind, service, folder string, retention int,
+	cfg *v1alpha1.BackupS3, deadlineSeconds int64) *batchv1.Job {
+
+	job := newJob(BackupCommand, name, manager, operatorPod, kind, service, folder, cfg)
+	c := &job.Spec.Template.Spec.Containers[0]
+	c.Args = append(c.Args, fmt.Sprintf("--retention=%d", retention))
+	job.Spec.ActiveDeadlineSeconds = &deadlineSeconds
+	return job
+}
+
+// NewRestoreJob returns the job which streams archives of the backup folder into restore folders of the service pods
+func NewRestoreJob(name string, manager *v1alpha1.Manager, operatorPod *corev1.Pod, kind, service, folder string,
+	cfg *v1alpha1.BackupS3) *batchv1.Job {
+
+	return newJob(RestoreCommand, name, manager, operatorPod, kind, service, folder, cfg)
+}
+
+// newJob returns the job which runs the operator image with the command on behalf of the operator service account,
+// the job is not restarted on failure and its result is read from the job status.
+func newJob(command, name string, manager *v1alpha1.Manager, operatorPod *corev1.Pod, kind, service, folder string,
+	cfg *v1alpha1.BackupS3) *batchv1.Job {
+
+	args := []string{
+		command,
+		"--kind=" + kind,
+		"--name=" + service,
+		"--folder=" + folder,
+		"--s3-endpoint=" + cfg.Endpoint,
+		"--s3-region=" + cfg.Region,
+		"--s3-bucket=" + cfg.Bucket,
+	}
+	var backoffLimit int32
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: manager.Namespace,
+			Labels:    JobLabels(command, manager.Name),
+			Annotations: map[string]string{
+				ServiceAnnotation: kind + "/" + service,
+				FolderAnnotation:  folder,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: operatorPod.Spec.ServiceAccountName,
+					NodeSelector:       operatorPod.Spec.NodeSelector,
+					Tolerations:        operatorPod.Spec.Tolerations,
+					HostNetwork:        operatorPod.Spec.HostNetwork,
+					DNSPolicy:          operatorPod.Spec.DNSPolicy,
+					Containers: []corev1.Container{{
+						Name:  command,
+						Image: operatorPod.Spec.Containers[0].Image,
+						Args:  args,
+						Env: []corev1.EnvVar{
+							{
+								Name: namespaceEnv,
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+								},
+							},
+							credentialsEnv(accessKeyEnv, cfg.CredentialsSecret, AccessKey),
+							credentialsEnv(secretKeyEnv, cfg.CredentialsSecret, SecretKey),
+						},
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					}},
+				},
+			},
+		},
+	}
+}
+
+func credentialsEnv(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// RunJob runs the job command with the args in the job pod
+func RunJob(command string, args []string) error {
+	flags := pflag.NewFlagSet(command, pflag.ContinueOnError)
+	kind := flags.String("kind", "", "Kind of the service: cassandra or zookeeper")
+	name := flags.String("name", "", "Name of the service")
+	folder := flags.String("folder", "", "Folder of the backup in the bucket")
+	retention := flags.Int("retention", 0, "Number of backups of the service to keep, 0 keeps all (backup only)")
+	endpoint := flags.String("s3-endpoint", "", "URL of the S3 compatible store")
+	region := flags.String("s3-region", "", "Region of the store")
+	bucket := flags.String("s3-bucket", "", "Bucket of the store")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	scheme := clientgoscheme.Scheme
+	if err := apis.AddToScheme(scheme); err != nil {
+		return err
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	clnt, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	store := &Store{
+		Client: s3.NewClient(*endpoint, *region, os.Getenv(accessKeyEnv), os.Getenv(secretKeyEnv)),
+		Bucket: *bucket,
+	}
+	if command == RestoreCommand {
+		return runRestore(clnt, store, os.Getenv(namespaceEnv), *kind, *name, *folder)
+	}
+	return runBackup(clnt, store, os.Getenv(namespaceEnv), *kind, *name, *folder, *retention)
+}
+
+// WriteTerminationMessage writes the error of the job for the controller to put it into the status
+func WriteTerminationMessage(err error) {
+	_ = ioutil.WriteFile(corev1.TerminationMessagePathDefault, []byte(err.Error()), 0644)
+}
+
+// cassandraBackup, zookeeperBackup and stageRestore are replaced by tests
+var cassandraBackup = func(c *v1alpha1.Cassandra, pod *corev1.Pod, tag string, w io.Writer) error {
+	return c.Backup(pod, tag, w)
+}
+
+var zookeeperBackup = func(c *v1alpha1.Zookeeper, pod *corev1.Pod, w io.Writer) error {
+	return c.Backup(pod, w)
+}
+
+var stageRestore = func(kind string, pod *corev1.Pod, r io.Reader) error {
+	if kind == CassandraKind {
+		return (&v1alpha1.Cassandra{}).StageRestore(pod, r)
+	}
+	return (&v1alpha1.Zookeeper{}).StageRestore(pod, r)
+}
+
+// runBackup streams archives of pods of the service into the folder
+// and deletes the oldest backups of the service out of retention
+func runBackup(clnt client.Client, store *Store, namespace, kind, name, folder string, retention int) error {
+	nsName := types.NamespacedName{Name: name, Namespace: namespace}
+	switch kind {
+	case CassandraKind:
+		cassandra := &v1alpha1.Cassandra{}
+		if err := clnt.Get(context.TODO(), nsName, cassandra); err != nil {
+			return err
+		}
+		pods, err := RunningPods(name, v1alpha1.CassandraInstanceType, namespace, clnt)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("No running pods of %s/%s", kind, name)
+		}
+		tag := "backup-" + path.Base(folder)
+		for idx := range pods {
+			pod := &pods[idx]
+			err := store.Upload(path.Join(folder, pod.Name+".tar.gz"), func(w io.Writer) error {
+				return cassandraBackup(cassandra, pod, tag, w)
+			})
+			if err != nil {
+				return fmt.Errorf("Backup of pod %s failed: %v", pod.Name, err)
+			}
+			log.Info("Pod backed up", "pod", pod.Name, "folder", folder)
+		}
+	case ZookeeperKind:
+		zookeeper := &v1alpha1.Zookeeper{}
+		if err := clnt.Get(context.TODO(), nsName, zookeeper); err != nil {
+			return err
+		}
+		pods, err := RunningPods(name, "zookeeper", namespace, clnt)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("No running pods of %s/%s", kind, name)
+		}
+		// data of zookeeper is replicated, so one pod is enough
+		pod := &pods[0]
+		err = store.Upload(path.Join(folder, pod.Name+".tar.gz"), func(w io.Writer) error {
+			return zookeeperBackup(zookeeper, pod, w)
+		})
+		if err != nil {
+			return fmt.Errorf("Backup of pod %s failed: %v", pod.Name, err)
+		}
+		log.Info("Pod backed up", "pod", pod.Name, "folder", folder)
+	default:
+		return fmt.Errorf("Unknown kind of backup %s", kind)
+	}
+	if retention <= 0 {
+		return nil
+	}
+	return store.ApplyRetention(path.Dir(folder), retention)
+}
+
+// runRestore streams archives of the backup folder into restore folders of pods of the service,
+// every cassandra pod has own archive, one archive is used for all zookeeper pods
+func runRestore(clnt client.Client, store *Store, namespace, kind, name, folder string) error {
+	var instanceType string
+	switch kind {
+	case CassandraKind:
+		instanceType = v1alpha1.CassandraInstanceType
+	case ZookeeperKind:
+		instanceType = "zookeeper"
+	default:
+		return fmt.Errorf("Unknown kind of restore %s", kind)
+	}
+	pods, err := RunningPods(name, instanceType, namespace, clnt)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("No running pods of %s/%s", kind, name)
+	}
+	objects, err := store.ListObjects(store.Bucket, folder+"/")
+	if err != nil {
+		return err
+	}
+	archives := make(map[string]string)
+	var shared string
+	for _, o := range objects {
+		archives[path.Base(o.Key)] = o.Key
+		if shared == "" || o.Key < shared {
+			shared = o.Key
+		}
+	}
+	for idx := range pods {
+		pod := &pods[idx]
+		key := archives[pod.Name+".tar.gz"]
+		if kind == ZookeeperKind {
+			key = shared
+		}
+		if key == "" {
+			return fmt.Errorf("No backup of pod %s in %s", pod.Name, folder)
+		}
+		body, err := store.GetObjectStream(store.Bucket, key)
+		if err != nil {
+			return err
+		}
+		err = stageRestore(kind, pod, body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		log.Info("Backup staged", "pod", pod.Name, "key", key)
+	}
+	return nil
+}
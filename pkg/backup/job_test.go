@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/s3"
+)
+
+// fakeStore is in-memory bucket of S3 store
+type fakeStore struct {
+	sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch r.Method {
+	case "PUT":
+		data, _ := ioutil.ReadAll(r.Body)
+		f.objects[key] = string(data)
+	case "DELETE":
+		delete(f.objects, key)
+	case "GET":
+		if r.URL.Query().Get("list-type") == "" {
+			data, ok := f.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(data))
+			return
+		}
+		prefix := r.URL.Query().Get("prefix")
+		result := "<ListBucketResult>"
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				result += "<Contents><Key>" + k + "</Key></Contents>"
+			}
+		}
+		w.Write([]byte(result + "</ListBucketResult>"))
+	}
+}
+
+func newStore(objects map[string]string) (*Store, *fakeStore, func()) {
+	f := &fakeStore{objects: objects}
+	srv := httptest.NewServer(f)
+	return &Store{Client: s3.NewClient(srv.URL, "", "access", "secret"), Bucket: "bucket"}, f, srv.Close
+}
+
+func newPod(name, instanceType, instanceName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "tf",
+			Labels:    map[string]string{"tf_manager": instanceType, instanceType: instanceName},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+	}
+}
+
+func TestRunCassandraBackup(t *testing.T) {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.Cassandra{ObjectMeta: metav1.ObjectMeta{Name: "configdb1", Namespace: "tf"}},
+		newPod("configdb1-cassandra-statefulset-0", v1alpha1.CassandraInstanceType, "configdb1"),
+		newPod("configdb1-cassandra-statefulset-1", v1alpha1.CassandraInstanceType, "configdb1"),
+	)
+
+	defer func(f func(*v1alpha1.Cassandra, *corev1.Pod, string, io.Writer) error) { cassandraBackup = f }(cassandraBackup)
+	cassandraBackup = func(c *v1alpha1.Cassandra, pod *corev1.Pod, tag string, w io.Writer) error {
+		assert.Equal(t, "backup-20210102T000000Z", tag)
+		_, err := w.Write([]byte("archive of " + pod.Name))
+		return err
+	}
+
+	store, f, stop := newStore(map[string]string{
+		"backups/cassandra/configdb1/20201231T000000Z/configdb1-cassandra-statefulset-0.tar.gz": "old",
+		"backups/cassandra/configdb1/20210101T000000Z/configdb1-cassandra-statefulset-0.tar.gz": "previous",
+	})
+	defer stop()
+
+	folder := "backups/cassandra/configdb1/20210102T000000Z"
+	require.NoError(t, runBackup(cl, store, "tf", CassandraKind, "configdb1", folder, 2))
+	assert.Equal(t, map[string]string{
+		"backups/cassandra/configdb1/20210101T000000Z/configdb1-cassandra-statefulset-0.tar.gz": "previous",
+		folder + "/configdb1-cassandra-statefulset-0.tar.gz":                                    "archive of configdb1-cassandra-statefulset-0",
+		folder + "/configdb1-cassandra-statefulset-1.tar.gz":                                    "archive of configdb1-cassandra-statefulset-1",
+	}, f.objects, "the oldest backup is out of retention")
+}
+
+func TestRunZookeeperBackupFailure(t *testing.T) {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.Zookeeper{ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1", Namespace: "tf"}},
+		newPod("zookeeper1-zookeeper-statefulset-0", "zookeeper", "zookeeper1"),
+	)
+
+	defer func(f func(*v1alpha1.Zookeeper, *corev1.Pod, io.Writer) error) { zookeeperBackup = f }(zookeeperBackup)
+	zookeeperBackup = func(c *v1alpha1.Zookeeper, pod *corev1.Pod, w io.Writer) error {
+		w.Write([]byte("partial"))
+		return errors.New("tar failed")
+	}
+
+	store, f, stop := newStore(map[string]string{})
+	defer stop()
+
+	err = runBackup(cl, store, "tf", ZookeeperKind, "zookeeper1", "backups/zookeeper/zookeeper1/20210102T000000Z", 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tar failed")
+	assert.Empty(t, f.objects, "failed archive must not be stored")
+
+	err = runBackup(cl, store, "tf", CassandraKind, "configdb1", "backups/cassandra/configdb1/20210102T000000Z", 2)
+	require.Error(t, err, "no cassandra")
+}
+
+func TestRunRestore(t *testing.T) {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme,
+		newPod("configdb1-cassandra-statefulset-0", v1alpha1.CassandraInstanceType, "configdb1"),
+		newPod("configdb1-cassandra-statefulset-1", v1alpha1.CassandraInstanceType, "configdb1"),
+		newPod("zookeeper1-zookeeper-statefulset-0", "zookeeper", "zookeeper1"),
+		newPod("zookeeper1-zookeeper-statefulset-1", "zookeeper", "zookeeper1"),
+	)
+
+	staged := make(map[string]string)
+	defer func(f func(string, *corev1.Pod, io.Reader) error) { stageRestore = f }(stageRestore)
+	stageRestore = func(kind string, pod *corev1.Pod, r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		staged[kind+"/"+pod.Name] = string(data)
+		return err
+	}
+
+	store, _, stop := newStore(map[string]string{
+		"backups/cassandra/configdb1/20210102T000000Z/configdb1-cassandra-statefulset-0.tar.gz":   "archive 0",
+		"backups/cassandra/configdb1/20210102T000000Z/configdb1-cassandra-statefulset-1.tar.gz":   "archive 1",
+		"backups/zookeeper/zookeeper1/20210102T000000Z/zookeeper1-zookeeper-statefulset-0.tar.gz": "zookeeper archive",
+	})
+	defer stop()
+
+	require.NoError(t, runRestore(cl, store, "tf", CassandraKind, "configdb1", "backups/cassandra/configdb1/20210102T000000Z"))
+	require.NoError(t, runRestore(cl, store, "tf", ZookeeperKind, "zookeeper1", "backups/zookeeper/zookeeper1/20210102T000000Z"))
+	assert.Equal(t, map[string]string{
+		"cassandra/configdb1-cassandra-statefulset-0":  "archive 0",
+		"cassandra/configdb1-cassandra-statefulset-1":  "archive 1",
+		"zookeeper/zookeeper1-zookeeper-statefulset-0": "zookeeper archive",
+		"zookeeper/zookeeper1-zookeeper-statefulset-1": "zookeeper archive",
+	}, staged, "one zookeeper archive is staged into all pods")
+
+	err = runRestore(cl, store, "tf", CassandraKind, "configdb1", "backups/cassandra/configdb1/20210101T000000Z")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No backup of pod configdb1-cassandra-statefulset-0")
+}
+
+func TestNewBackupJob(t *testing.T) {
+	manager := &v1alpha1.Manager{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "tf"}}
+	operatorPod := &corev1.Pod{Spec: corev1.PodSpec{
+		ServiceAccountName: "tf-operator",
+		HostNetwork:        true,
+		Containers:         []corev1.Container{{Name: "tf-operator", Image: "tf-operator:latest"}},
+	}}
+	cfg := &v1alpha1.BackupS3{Endpoint: "http://minio:9000", Bucket: "backups", CredentialsSecret: "s3-credentials"}
+	job := NewBackupJob("configdb1-cassandra-backup-20210102t000000z", manager, operatorPod,
+		CassandraKind, "configdb1", "cassandra/configdb1/20210102T000000Z", 7, cfg, 3600)
+
+	assert.Equal(t, JobLabels(BackupCommand, "cluster1"), job.Labels)
+	assert.Equal(t, "cassandra/configdb1", job.Annotations[ServiceAnnotation])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int64(3600), *job.Spec.ActiveDeadlineSeconds)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, "tf-operator", spec.ServiceAccountName)
+	assert.True(t, spec.HostNetwork)
+	c := spec.Containers[0]
+	assert.Equal(t, "tf-operator:latest", c.Image)
+	assert.Equal(t, BackupCommand, c.Args[0])
+	assert.True(t, IsJobCommand(c.Args[0]))
+	assert.Contains(t, c.Args, "--folder=cassandra/configdb1/20210102T000000Z")
+	assert.Contains(t, c.Args, "--retention=7")
+	for _, env := range c.Env {
+		if env.Name == secretKeyEnv {
+			assert.Equal(t, "s3-credentials", env.ValueFrom.SecretKeyRef.Name)
+			assert.Equal(t, SecretKey, env.ValueFrom.SecretKeyRef.Key)
+		}
+	}
+}
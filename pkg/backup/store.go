@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/s3"
+)
+
+// Keys of the credentials secret of the S3 store
+const (
+	AccessKey = "accessKey"
+	SecretKey = "secretKey"
+)
+
+// Store is the bucket of S3 compatible store with backups
+type Store struct {
+	*s3.Client
+	Bucket string
+}
+
+// NewStore creates store with the credentials from the secret of the configuration
+func NewStore(cfg *v1alpha1.BackupS3, namespace string, clnt client.Client) (*Store, error) {
+	secret := &corev1.Secret{}
+	if err := clnt.Get(context.TODO(), types.NamespacedName{Name: cfg.CredentialsSecret, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("Failed to get backup credentials secret %s: %v", cfg.CredentialsSecret, err)
+	}
+	accessKey, secretKey := string(secret.Data[AccessKey]), string(secret.Data[SecretKey])
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("Backup credentials secret %s must have %s and %s", cfg.CredentialsSecret, AccessKey, SecretKey)
+	}
+	return &Store{
+		Client: s3.NewClient(cfg.Endpoint, cfg.Region, accessKey, secretKey),
+		Bucket: cfg.Bucket,
+	}, nil
+}
+
+// Upload streams the object written by write into the store.
+// The upload is aborted if write fails, and write is interrupted if the upload fails.
+func (s *Store) Upload(key string, write func(w io.Writer) error) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := write(pw)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	err := s.PutObjectStream(s.Bucket, key, pr)
+	pr.CloseWithError(err)
+	writeErr := <-done
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// ApplyRetention deletes all but the newest keep backup folders under the prefix
+func (s *Store) ApplyRetention(prefix string, keep int) error {
+	objects, err := s.ListObjects(s.Bucket, prefix+"/")
+	if err != nil {
+		return err
+	}
+	folders := make(map[string][]string)
+	for _, o := range objects {
+		folder := strings.SplitN(strings.TrimPrefix(o.Key, prefix+"/"), "/", 2)[0]
+		folders[folder] = append(folders[folder], o.Key)
+	}
+	var stamps []string
+	for folder := range folders {
+		stamps = append(stamps, folder)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(stamps)))
+	for i := keep; i < len(stamps); i++ {
+		for _, key := range folders[stamps[i]] {
+			if err := s.DeleteObject(s.Bucket, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunningPods returns running pods of the service sorted by name
+func RunningPods(name, instanceType, namespace string, clnt client.Client) ([]corev1.Pod, error) {
+	podList, err := v1alpha1.SelectPods(name, instanceType, namespace, clnt)
+	if err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
+	"time"
 
 	"github.com/tungstenfabric/tf-operator/pkg/k8s"
 	corev1 "k8s.io/api/core/v1"
@@ -82,6 +83,7 @@ type Certificate struct {
 	sc                  *k8s.Secret
 	signer              CertificateSigner
 	certificateSubjects []CertificateSubject
+	issued              int
 }
 
 // NewCertificate creates new cert
@@ -100,9 +102,15 @@ func NewCertificate(signer CertificateSigner, cl client.Client, scheme *runtime.
 
 // EnsureExistsAndIsSigned ensures cert is signed
 func (r *Certificate) EnsureExistsAndIsSigned(force bool) error {
+	r.issued = 0
 	return r.sc.EnsureExists(r, force)
 }
 
+// Issued returns the number of certificates issued by the last EnsureExistsAndIsSigned
+func (r *Certificate) Issued() int {
+	return r.issued
+}
+
 type CertificateSigner interface {
 	SignCertificate(secret *corev1.Secret, certTemplate x509.Certificate, privateKey *rsa.PrivateKey) ([]byte, []byte, error)
 	ValidateCert(cert *x509.Certificate) ([]byte, error)
@@ -184,13 +192,16 @@ func (r *Certificate) createCertificateForPod(subject CertificateSubject, secret
 	if err != nil {
 		return err
 	}
+	lifetimes := LifetimesFromConfigMap(cm)
 	if ok, cert := r.certInSecret(secret, subject); !force && ok {
 		if secret.Annotations["ca-md5"] == cm.Annotations["ca-md5"] {
-			if _, err := ValidateCert(cert, []byte(cm.Data[CAFilename])); err == nil {
+			if _, err := ValidateCert(cert, []byte(cm.Data[CAFilename])); err != nil {
+				l.Info("Cert invalid", "reason", err)
+			} else if reason := lifetimes.needsRenewal(cert, r.maxLifetime(lifetimes)); reason != "" {
+				l.Info("Cert is to be renewed", "reason", reason)
+			} else {
 				l.Info("CA not changed and Cert is valid", "ca-md5", secret.Annotations["ca-md5"])
 				return nil
-			} else {
-				l.Info("Cert invalid", "reason", err)
 			}
 		} else {
 			l.Info("CA changed", "configmap", cm.Annotations["ca-md5"], "secret", secret.Annotations["ca-md5"])
@@ -203,7 +214,7 @@ func (r *Certificate) createCertificateForPod(subject CertificateSubject, secret
 			return fmt.Errorf("Failed to generate private key: %w", err)
 		}
 	}
-	certificateTemplate, err := subject.generateCertificateTemplate(privateKey)
+	certificateTemplate, err := subject.generateCertificateTemplate(privateKey, lifetimes.Cert)
 	if err != nil {
 		return fmt.Errorf("failed to generate certificate template for %s, %s: %w", subject.hostname, subject.name, err)
 	}
@@ -219,10 +230,19 @@ func (r *Certificate) createCertificateForPod(subject CertificateSubject, secret
 	}
 	secret.Annotations["ca-md5"] = cm.Annotations["ca-md5"]
 	delete(secret.Annotations, "changed-ca-md5")
+	r.issued++
 	l.Info("Secret updated", "ca md5", secret.Annotations["ca-md5"])
 	return nil
 }
 
+// maxLifetime is the validity period of issued certificates if it is defined by the operator
+func (r *Certificate) maxLifetime(lifetimes Lifetimes) time.Duration {
+	if _, ok := r.signer.(*signer); ok {
+		return lifetimes.Cert
+	}
+	return 0
+}
+
 func (r *Certificate) certInSecret(secret *corev1.Secret, subject CertificateSubject) (bool, *x509.Certificate) {
 	certPem, certOk := secret.Data[serverCertificateFileName(subject)]
 	_, pemOk := secret.Data[serverPrivateKeyFileName(subject)]
@@ -44,9 +44,8 @@ func contains(list []string, val string) bool {
 	return false
 }
 
-const (
-	certValidityPeriod = 10 * 365 * 24 * time.Hour // 10 years
-)
+// DefaultCertValidityPeriod is the default lifetime of certificates issued by SelfSignedCA
+const DefaultCertValidityPeriod = 10 * 365 * 24 * time.Hour // 10 years
 
 func GenerateSerialNumber() (*big.Int, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
@@ -92,9 +91,9 @@ func (c CertificateSubject) getPrivKeyFromSecret(secret *corev1.Secret) (*rsa.Pr
 	return privKey, nil
 }
 
-func (c CertificateSubject) generateCertificateTemplate(certPrivKey *rsa.PrivateKey) (x509.Certificate, error) {
+func (c CertificateSubject) generateCertificateTemplate(certPrivKey *rsa.PrivateKey, validityPeriod time.Duration) (x509.Certificate, error) {
 	notBefore := time.Now()
-	notAfter := notBefore.Add(certValidityPeriod)
+	notAfter := notBefore.Add(validityPeriod)
 
 	serialNumber, err := GenerateSerialNumber()
 	if err != nil {
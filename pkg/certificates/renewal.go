@@ -0,0 +1,140 @@
+package certificates
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultCertRenewalPercent is the default percentage of the lifetime after which certificates are renewed
+const DefaultCertRenewalPercent = 80
+
+const (
+	certValidityAnnotation   = "cert-validity-period"
+	caCertValidityAnnotation = "ca-cert-validity-period"
+	renewalPercentAnnotation = "cert-renewal-percent"
+)
+
+// Lifetimes are the validity periods of certificates and of the self-signed CA issued by the operator
+// and the percentage of the lifetime after which they are renewed
+type Lifetimes struct {
+	Cert           time.Duration
+	CACert         time.Duration
+	RenewalPercent int
+}
+
+// DefaultLifetimes returns lifetimes used when the manager does not define them
+func DefaultLifetimes() Lifetimes {
+	return Lifetimes{
+		Cert:           DefaultCertValidityPeriod,
+		CACert:         DefaultCACertValidityPeriod,
+		RenewalPercent: DefaultCertRenewalPercent,
+	}
+}
+
+// LifetimesFromConfigMap returns lifetimes stored in the CA configmap, defaults for values not stored
+func LifetimesFromConfigMap(cm *corev1.ConfigMap) Lifetimes {
+	l := DefaultLifetimes()
+	if d, err := time.ParseDuration(cm.Annotations[certValidityAnnotation]); err == nil {
+		l.Cert = d
+	}
+	if d, err := time.ParseDuration(cm.Annotations[caCertValidityAnnotation]); err == nil {
+		l.CACert = d
+	}
+	if p, err := strconv.Atoi(cm.Annotations[renewalPercentAnnotation]); err == nil {
+		l.RenewalPercent = p
+	}
+	return l
+}
+
+// GetLifetimes returns lifetimes stored in the CA configmap of the namespace,
+// defaults if there is no configmap yet
+func GetLifetimes(ns string, cl client.Client) Lifetimes {
+	cm, err := GetCAConfigMap(ns, cl)
+	if err != nil {
+		return DefaultLifetimes()
+	}
+	return LifetimesFromConfigMap(cm)
+}
+
+// UpdateLifetimes stores lifetimes in the CA configmap of the namespace,
+// certificates are issued and renewed by them
+func UpdateLifetimes(ns string, lifetimes Lifetimes, cl client.Client) error {
+	cm, err := GetCAConfigMap(ns, cl)
+	if err != nil {
+		return err
+	}
+	if LifetimesFromConfigMap(cm) == lifetimes {
+		return nil
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[certValidityAnnotation] = lifetimes.Cert.String()
+	cm.Annotations[caCertValidityAnnotation] = lifetimes.CACert.String()
+	cm.Annotations[renewalPercentAnnotation] = strconv.Itoa(lifetimes.RenewalPercent)
+	return cl.Update(context.TODO(), cm)
+}
+
+// RenewalClock is the current time for renewal of certificates
+var RenewalClock = time.Now
+
+// RenewalTime returns the time the certificate is to be renewed at
+func (l Lifetimes) RenewalTime(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(lifetime / 100 * time.Duration(l.RenewalPercent))
+}
+
+// needsRenewal returns the reason to renew the certificate or empty string if it is not needed.
+// Certificates living longer than maxLifetime (if set) are renewed to apply the shortened validity period.
+func (l Lifetimes) needsRenewal(cert *x509.Certificate, maxLifetime time.Duration) string {
+	if renewAt := l.RenewalTime(cert); RenewalClock().After(renewAt) {
+		return "renewal time " + renewAt.Format(time.RFC3339) + " passed"
+	}
+	// x509 keeps time in seconds, so small difference is allowed
+	if lifetime := cert.NotAfter.Sub(cert.NotBefore); maxLifetime > 0 && lifetime > maxLifetime+time.Minute {
+		return fmt.Sprintf("lifetime %s exceeds %s", lifetime, maxLifetime)
+	}
+	return ""
+}
+
+// CertificatesExpiry returns the earliest expiry and renewal time of certificates in the secret,
+// times are zero if there are no certificates
+func (l Lifetimes) CertificatesExpiry(secret *corev1.Secret) (notAfter, renewAt time.Time) {
+	for name, data := range secret.Data {
+		if !strings.HasSuffix(name, ".crt") {
+			continue
+		}
+		certs, err := certutil.ParseCertsPEM(data)
+		if err != nil || len(certs) == 0 {
+			continue
+		}
+		if notAfter.IsZero() || certs[0].NotAfter.Before(notAfter) {
+			notAfter = certs[0].NotAfter
+		}
+		if r := l.RenewalTime(certs[0]); renewAt.IsZero() || r.Before(renewAt) {
+			renewAt = r
+		}
+	}
+	return
+}
+
+// CAExpiry returns the expiry of the CA signing certificates, it is the first one of the CA bundle
+func CAExpiry(ns string, cl client.Client) (time.Time, error) {
+	caCert, err := GetCAFromConfigMap(ns, cl)
+	if err != nil {
+		return time.Time{}, err
+	}
+	certs, err := certutil.ParseCertsPEM([]byte(caCert))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return certs[0].NotAfter, nil
+}
@@ -16,6 +16,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -23,7 +24,7 @@ import (
 const (
 	CaSecretName                = "contrail-ca-certificate"
 	SignerCAPrivateKeyFilename  = "ca-priv-key.pem"
-	caCertValidityPeriod10Years = 10 * 365 * 24 * time.Hour // 10 years
+	DefaultCACertValidityPeriod = 10 * 365 * 24 * time.Hour // 10 years
 	caRootCommonName            = "tf_csr_singer"
 )
 
@@ -48,7 +49,7 @@ type signer struct {
 	owner  metav1.Object
 }
 
-func InitSelfCA(cl client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string) (CertificateSigner, error) {
+func InitSelfCA(cl client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string, lifetimes Lifetimes) (CertificateSigner, error) {
 	l := log.WithName("InitSelfCA")
 	l.Info("Init")
 	ns := owner.GetNamespace()
@@ -66,17 +67,37 @@ func InitSelfCA(cl client.Client, scheme *runtime.Scheme, owner metav1.Object, o
 		}
 	}
 	caCertPem, ok := caSecret.Data[CAFilename]
+	// only CA generated by the operator is renewed, CA provided by user is kept as is
+	var previousCA *x509.Certificate
+	if ok && metav1.GetControllerOf(caSecret) != nil {
+		if certs, err := certutil.ParseCertsPEM(caCertPem); err == nil && len(certs) > 0 {
+			if reason := lifetimes.needsRenewal(certs[0], lifetimes.CACert); reason != "" {
+				l.Info("Renew self CA", "reason", reason)
+				previousCA, ok = certs[0], false
+			}
+		}
+	}
 	if !ok {
 		l.Info("Generate new self CA and key")
 		var caPrivKeyPem []byte
-		if caCertPem, caPrivKeyPem, err = GenerateCaCertificate(caCertValidityPeriod10Years); err != nil {
+		if caCertPem, caPrivKeyPem, err = GenerateCaCertificate(lifetimes.CACert); err != nil {
 			l.Error(err, "Failed to generate self CA and key")
 			return nil, err
 		}
+		// the previous CA is trusted till its expiry, so certificates signed by it stay valid
+		// while they are re-issued by the new one
+		if previousCA != nil && RenewalClock().Before(previousCA.NotAfter) {
+			previousPem, err := certutil.EncodeCertificates(previousCA)
+			if err != nil {
+				return nil, err
+			}
+			caCertPem = append(caCertPem, previousPem...)
+		}
 		_, err = controllerutil.CreateOrUpdate(context.Background(), cl, caSecret, func() error {
 			caSecret.ObjectMeta = metav1.ObjectMeta{
-				Namespace: ns,
-				Name:      CaSecretName,
+				Namespace:       ns,
+				Name:            CaSecretName,
+				ResourceVersion: caSecret.ResourceVersion,
 			}
 			if caSecret.Data == nil {
 				caSecret.Data = make(map[string][]byte)
@@ -8,7 +8,6 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -104,7 +103,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 }
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New("analytics-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("analytics-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("analytics-controller"), &v1alpha1.Analytics{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -238,6 +239,10 @@ func (r *ReconcileAnalytics) Reconcile(request reconcile.Request) (reconcile.Res
 	redisActive := redisInstance.IsActive(v1alpha1.RedisInstance, request.Namespace, r.Client)
 	if !cassandraActive || !rabbitmqActive || !zookeeperActive || !redisActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "zk", zookeeperActive, "rmq", rabbitmqActive, "redis", redisActive)
+		dependencies := map[string]bool{"Cassandra": cassandraActive, "Rabbitmq": rabbitmqActive, "Zookeeper": zookeeperActive, "Redis": redisActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -292,21 +297,6 @@ func (r *ReconcileAnalytics) Reconcile(request reconcile.Request) (reconcile.Res
 	v1alpha1.AddCAVolumeToIntendedSTS(statefulSet)
 	v1alpha1.AddSecretVolumesToIntendedSTS(statefulSet, request.Name)
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
 
@@ -367,10 +357,9 @@ func (r *ReconcileAnalytics) Reconcile(request reconcile.Request) (reconcile.Res
 
 	if len(podIPMap) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -436,6 +425,18 @@ func (r *ReconcileAnalytics) Reconcile(request reconcile.Request) (reconcile.Res
 		return requeueReconcile, nil
 	}
 
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, nil, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
+	restarting, err := utils.RestartPods(instance, instanceType, instance.Spec.CommonConfiguration.MaxUnavailable, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to restart pods.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil {
@@ -451,6 +452,14 @@ func (r *ReconcileAnalytics) Reconcile(request reconcile.Request) (reconcile.Res
 		return requeueReconcile, nil
 	}
 
+	if restarting {
+		return requeueReconcile, nil
+	}
+
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
 	reqLogger.Info("Done")
 	return reconcile.Result{}, nil
 }
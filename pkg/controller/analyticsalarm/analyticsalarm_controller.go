@@ -12,7 +12,6 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -107,7 +106,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 }
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New(instanceType+"-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(instanceType+"-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor(instanceType+"-controller"), &v1alpha1.AnalyticsAlarm{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -250,6 +251,10 @@ func (r *ReconcileAnalyticsAlarm) Reconcile(request reconcile.Request) (reconcil
 	analyticsActive := analyticsInstance.IsActive(v1alpha1.AnalyticsInstance, request.Namespace, r.Client)
 	if !cassandraActive || !zookeeperActive || !rabbitmqActive || !redisActive || !configActive || !analyticsActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "zk", zookeeperActive, "rmq", rabbitmqActive, "redis", redisActive, "api", configActive, "analytics", analyticsActive)
+		dependencies := map[string]bool{"Cassandra": cassandraActive, "Zookeeper": zookeeperActive, "Rabbitmq": rabbitmqActive, "Redis": redisActive, "Config": configActive, "Analytics": analyticsActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -310,10 +315,9 @@ func (r *ReconcileAnalyticsAlarm) Reconcile(request reconcile.Request) (reconcil
 
 	if len(podIPMap) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -370,6 +374,12 @@ func (r *ReconcileAnalyticsAlarm) Reconcile(request reconcile.Request) (reconcil
 		return requeueReconcile, nil
 	}
 
+	restarting, err := utils.RestartPods(instance, instanceType, instance.Spec.CommonConfiguration.MaxUnavailable, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to restart pods.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil && !v1alpha1.IsOKForRequeque(err) {
@@ -385,6 +395,10 @@ func (r *ReconcileAnalyticsAlarm) Reconcile(request reconcile.Request) (reconcil
 		return requeueReconcile, nil
 	}
 
+	if restarting {
+		return requeueReconcile, nil
+	}
+
 	reqLogger.Info("Done")
 	return reconcile.Result{}, nil
 }
@@ -417,22 +431,6 @@ func (r *ReconcileAnalyticsAlarm) GetSTS(request reconcile.Request, instance *v1
 	v1alpha1.AddCAVolumeToIntendedSTS(statefulSet)
 	v1alpha1.AddSecretVolumesToIntendedSTS(statefulSet, request.Name)
 
-	// Don't know what is it
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	// Manual settings for containers
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
@@ -12,7 +12,6 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -107,7 +106,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 }
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New(instanceType+"-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(instanceType+"-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor(instanceType+"-controller"), &v1alpha1.AnalyticsSnmp{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -243,6 +244,10 @@ func (r *ReconcileAnalyticsSnmp) Reconcile(request reconcile.Request) (reconcile
 	analyticsActive := analyticsInstance.IsActive(v1alpha1.AnalyticsInstance, request.Namespace, r.Client)
 	if !cassandraActive || !zookeeperActive || !rabbitmqActive || !configActive || !analyticsActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "zk", zookeeperActive, "rmq", rabbitmqActive, "api", configActive, "analytics", configActive)
+		dependencies := map[string]bool{"Cassandra": cassandraActive, "Zookeeper": zookeeperActive, "Rabbitmq": rabbitmqActive, "Config": configActive, "Analytics": analyticsActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -301,13 +306,12 @@ func (r *ReconcileAnalyticsSnmp) Reconcile(request reconcile.Request) (reconcile
 
 	if len(podIPMap) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
 			if err != nil {
-				reqLogger.Error(err, "Cannot get controller nodes")
+				reqLogger.Error(err, "Cannot get intended replicas")
 			} else {
-				reqLogger.Info("Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+				reqLogger.Info("Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			}
 			return requeueReconcile, err
 		}
@@ -364,6 +368,12 @@ func (r *ReconcileAnalyticsSnmp) Reconcile(request reconcile.Request) (reconcile
 		return requeueReconcile, nil
 	}
 
+	restarting, err := utils.RestartPods(instance, instanceType, instance.Spec.CommonConfiguration.MaxUnavailable, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to restart pods.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil {
@@ -380,6 +390,10 @@ func (r *ReconcileAnalyticsSnmp) Reconcile(request reconcile.Request) (reconcile
 		return requeueReconcile, nil
 	}
 
+	if restarting {
+		return requeueReconcile, nil
+	}
+
 	reqLogger.Info("Done")
 	return reconcile.Result{}, nil
 }
@@ -412,22 +426,6 @@ func (r *ReconcileAnalyticsSnmp) GetSTS(request reconcile.Request, instance *v1a
 	v1alpha1.AddCAVolumeToIntendedSTS(statefulSet)
 	v1alpha1.AddSecretVolumesToIntendedSTS(statefulSet, request.Name)
 
-	// Don't know what is it
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	// Manual settings for containers
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
@@ -26,7 +26,6 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -106,7 +105,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
 
-	c, err := controller.New("cassandra-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("cassandra-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("cassandra-controller"), &v1alpha1.Cassandra{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -282,27 +283,35 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 					Value: jvmOpts,
 				})
 			}
+			// memory is derived from heap size unless it is set for the container
+			resources, err := instance.Spec.ServiceConfiguration.HeapAwareResources()
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			utils.ApplyContainerResources(container, resources)
+			utils.ApplyContainerResources(container, instanceContainer.Resources)
+			// the derived request must not exceed the limit set explicitly below it
+			// and the derived limit must not be below the request set explicitly above it
+			if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok && container.Resources.Requests.Memory().Cmp(limit) > 0 {
+				if instanceContainer.Resources != nil && instanceContainer.Resources.Limits.Memory().Cmp(limit) == 0 {
+					container.Resources.Requests[corev1.ResourceMemory] = limit.DeepCopy()
+				} else {
+					container.Resources.Limits[corev1.ResourceMemory] = container.Resources.Requests.Memory().DeepCopy()
+				}
+			}
 		}
 	}
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	v1alpha1.AddCommonVolumes(&statefulSet.Spec.Template.Spec, instance.Spec.CommonConfiguration)
 	v1alpha1.DefaultSecurityContext(&statefulSet.Spec.Template.Spec)
 
+	storageStatus, err := v1alpha1.ApplyStorageToSTS(instance, instanceType, statefulSet, databaseNodeType+"-cassandra-data", instance.Spec.ServiceConfiguration.Storage, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to apply storage to the stateful set.")
+		return reconcile.Result{}, err
+	}
+	instance.Status.Storage = storageStatus
+
 	// Create statefulset if it doesn't exist
 	if created, err := v1alpha1.CreateServiceSTS(instance, instanceType, statefulSet, r.Client); err != nil || created {
 		if err != nil {
@@ -334,18 +343,17 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 		return requeueReconcile, nil
 	}
 
-	var minPods int32
-	if replicas, err := v1alpha1.GetReplicas(r.Client, statefulSet.Spec.Template.Spec.NodeSelector); err != nil {
+	replicas, err := v1alpha1.GetSTSReplicas(r.Client, instance.Spec.CommonConfiguration.Replicas, statefulSet.Spec.Template.Spec.NodeSelector)
+	if err != nil {
 		return reconcile.Result{}, err
-	} else {
-		minPods = replicas/2 + 1
 	}
+	minPods := replicas/2 + 1
+	ringChanged := false
 	if len(podIPList) >= int(minPods) {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -353,6 +361,17 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 			return reconcile.Result{}, err
 		}
 
+		// ring is not available while cassandra is starting, so configs are updated anyway,
+		// nodes restored from backup are not removed while they rejoin the ring with new addresses
+		if mngr, err := v1alpha1.GetManagerObject(r.Client); err == nil && mngr.IsRestoreInProgress() {
+			reqLogger.Info("Restore is in progress, ring is not reconciled")
+			if err := instance.InitReplaceAddresses(podIPList, r.Client); err != nil {
+				return reconcile.Result{}, err
+			}
+		} else if ringChanged, err = instance.ReconcileRing(podIPList, replicas, r.Client); err != nil {
+			reqLogger.Info("Failed to reconcile ring", "err", err)
+		}
+
 		seedsIPList := []string{}
 		for _, pod := range podIPList {
 
@@ -390,12 +409,19 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 		}
 	}
 
+	wasScalingDown := instance.Status.ScaleDown != nil
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, instance.RemoveMember, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
 	currentSTS, err := instance.QuerySTS(statefulSet.Name, statefulSet.Namespace, r.Client)
 	if err != nil {
 		reqLogger.Error(err, "QuerySTS failed")
 		return reconcile.Result{}, err
 	}
-	if instance.UpdateStatus(cassandraConfig, nodesInfo, currentSTS) {
+	if instance.UpdateStatus(cassandraConfig, nodesInfo, currentSTS) || ringChanged || scalingDown || wasScalingDown {
 		reqLogger.Info("Update Status")
 		if err = r.Client.Status().Update(context.TODO(), instance); err != nil && !v1alpha1.IsOKForRequeque(err) {
 			reqLogger.Error(err, "Update Status failed")
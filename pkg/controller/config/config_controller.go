@@ -11,7 +11,6 @@ import (
 	core "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -107,7 +106,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 }
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New("config-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("config-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("config-controller"), &v1alpha1.Config{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -223,6 +224,10 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	zookeeperActive := zookeeperInstance.IsActive(v1alpha1.ZookeeperInstance, request.Namespace, r.Client)
 	if !cassandraActive || !rabbitmqActive || !zookeeperActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "zk", zookeeperActive, "rmq", rabbitmqActive)
+		dependencies := map[string]bool{"Cassandra": cassandraActive, "Rabbitmq": rabbitmqActive, "Zookeeper": zookeeperActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -268,21 +273,6 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	v1alpha1.AddCAVolumeToIntendedSTS(statefulSet)
 	v1alpha1.AddSecretVolumesToIntendedSTS(statefulSet, request.Name)
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
 
@@ -433,6 +423,13 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	v1alpha1.AddCommonVolumes(&statefulSet.Spec.Template.Spec, instance.Spec.CommonConfiguration)
 	v1alpha1.DefaultSecurityContext(&statefulSet.Spec.Template.Spec)
 
+	storageStatus, err := v1alpha1.ApplyStorageToSTS(instance, instanceType, statefulSet, "config-data", instance.Spec.ServiceConfiguration.Storage, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to apply storage to the stateful set.")
+		return reconcile.Result{}, err
+	}
+	instance.Status.Storage = storageStatus
+
 	if created, err := v1alpha1.CreateServiceSTS(instance, instanceType, statefulSet, r.Client); err != nil || created {
 		if err != nil {
 			reqLogger.Error(err, "Failed to create the stateful set.")
@@ -463,10 +460,9 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 
 	if len(podIPMap) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -523,6 +519,18 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 		return requeueReconcile, nil
 	}
 
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, instance.RemoveMember, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
+	restarting, err := utils.RestartPods(instance, instanceType, instance.Spec.CommonConfiguration.MaxUnavailable, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to restart pods.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	instance.Status.Endpoint = configService.ClusterIP()
@@ -541,6 +549,14 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 		return requeueReconcile, nil
 	}
 
+	if restarting {
+		return requeueReconcile, nil
+	}
+
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
 	reqLogger.Info("Done")
 	return reconcile.Result{}, nil
 }
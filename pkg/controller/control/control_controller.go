@@ -24,13 +24,13 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var log = logf.Log.WithName("controller_control")
 var restartTime, _ = time.ParseDuration("3s")
 var requeueReconcile = reconcile.Result{Requeue: true, RequeueAfter: restartTime}
+var introspectPollPeriod, _ = time.ParseDuration("60s")
 
 func resourceHandler(myclient client.Client) handler.Funcs {
 	appHandler := handler.Funcs{
@@ -104,7 +104,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("control-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("control-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("control-controller"), &v1alpha1.Control{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -225,6 +227,10 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 	configActive := configInstance.IsActive(v1alpha1.ConfigInstance, request.Namespace, r.Client)
 	if !configActive || !cassandraActive || !rabbitmqActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "rmq", rabbitmqActive, "api", configActive)
+		dependencies := map[string]bool{"Config": configActive, "Cassandra": cassandraActive, "Rabbitmq": rabbitmqActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -265,21 +271,6 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 			statefulSet.Spec.Template.ObjectMeta.Annotations["dataSubnet"] = instance.Spec.ServiceConfiguration.DataSubnet
 		}
 	}
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
 
@@ -356,10 +347,9 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 
 	if len(podIPMap) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -416,6 +406,13 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 		return requeueReconcile, nil
 	}
 
+	instance.UpdateServiceStatus(podIPList)
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, instance.RemoveMember, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil {
@@ -431,6 +428,11 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 		return requeueReconcile, nil
 	}
 
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
+	// introspect is polled periodically to keep state of peers in the status
 	reqLogger.Info("Done")
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: introspectPollPeriod}, nil
 }
@@ -0,0 +1,171 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/backup"
+)
+
+// backupTimeFormat is the format of backup folders, it is sortable
+const backupTimeFormat = "20060102T150405Z"
+
+// processBackup makes scheduled backups of cassandras and zookeeper into S3 compatible store.
+// Every backup runs in a job which streams archives of the pods into the store,
+// results of finished jobs are recorded in the status.
+// Returns the duration till the next backup or 0 if backups are not configured.
+func (r *ReconcileManager) processBackup(manager *v1alpha1.Manager) (time.Duration, error) {
+	cfg := manager.Spec.Backup
+	if cfg == nil {
+		return 0, nil
+	}
+	if err := r.collectBackupJobs(manager); err != nil {
+		return 0, err
+	}
+	if cfg.IsSuspended() {
+		return 0, nil
+	}
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	now := time.Now()
+	if manager.Status.LastBackupTime != nil {
+		if next := manager.Status.LastBackupTime.Add(interval); now.Before(next) {
+			return next.Sub(now), nil
+		}
+	}
+
+	operatorPod, err := k8sutil.GetPod(context.TODO(), r.Client, manager.Namespace)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get operator pod for backup jobs: %v", err)
+	}
+	stamp := now.UTC().Format(backupTimeFormat)
+
+	type backupTarget struct{ kind, name string }
+	var targets []backupTarget
+	for _, cassandraService := range manager.Spec.Services.Cassandras {
+		targets = append(targets, backupTarget{backup.CassandraKind, cassandraService.Metadata.Name})
+	}
+	if manager.Spec.Services.Zookeeper != nil {
+		targets = append(targets, backupTarget{backup.ZookeeperKind, manager.Spec.Services.Zookeeper.Metadata.Name})
+	}
+	for _, target := range targets {
+		kind, name := target.kind, target.name
+		service := kind + "/" + name
+		status := backupStatus(manager, service)
+		if status.Job != "" {
+			log.Info("Previous backup is not finished yet", "service", service, "job", status.Job)
+			continue
+		}
+		folder := path.Join(cfg.S3.Prefix, service, stamp)
+		job := backup.NewBackupJob(name+"-"+kind+"-backup-"+strings.ToLower(stamp), manager, operatorPod,
+			kind, name, folder, cfg.GetRetention(), &cfg.S3, int64(interval.Seconds()))
+		if err := controllerutil.SetControllerReference(manager, job, r.Scheme); err != nil {
+			return 0, err
+		}
+		if err := r.Client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+			setBackupResult(status, "", err)
+			continue
+		}
+		log.Info("Backup started", "service", service, "job", job.Name, "folder", folder)
+		status.Job = job.Name
+	}
+
+	// failed backups are not retried till the next interval, their errors are in the status
+	manager.Status.LastBackupTime = &v1.Time{Time: now}
+	return interval, nil
+}
+
+// collectBackupJobs records running and finished backup jobs in the status, finished jobs are deleted
+func (r *ReconcileManager) collectBackupJobs(manager *v1alpha1.Manager) error {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(context.TODO(), jobs, client.InNamespace(manager.Namespace), client.MatchingLabels(backup.JobLabels(backup.BackupCommand, manager.Name))); err != nil {
+		return err
+	}
+	found := make(map[string]bool)
+	for idx := range jobs.Items {
+		job := &jobs.Items[idx]
+		status := backupStatus(manager, job.Annotations[backup.ServiceAnnotation])
+		if job.Status.Succeeded > 0 {
+			setBackupResult(status, job.Annotations[backup.FolderAnnotation], nil)
+		} else if failed, reason := isJobFailed(job); failed {
+			setBackupResult(status, "", fmt.Errorf("Backup job %s failed: %s", job.Name, r.jobFailureMessage(job, reason)))
+		} else {
+			status.Job = job.Name
+			found[job.Name] = true
+			continue
+		}
+		log.Info("Backup finished", "service", status.Service, "job", job.Name, "message", status.Message)
+		if status.Job == job.Name {
+			status.Job = ""
+		}
+		policy := v1.DeletePropagationBackground
+		if err := r.Client.Delete(context.TODO(), job, &client.DeleteOptions{PropagationPolicy: &policy}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	for idx := range manager.Status.Backups {
+		status := &manager.Status.Backups[idx]
+		if status.Job != "" && !found[status.Job] {
+			setBackupResult(status, "", fmt.Errorf("Backup job %s is not found", status.Job))
+			status.Job = ""
+		}
+	}
+	return nil
+}
+
+func isJobFailed(job *batchv1.Job) (bool, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true, c.Message
+		}
+	}
+	return false, ""
+}
+
+// jobFailureMessage returns the termination message of the job pod or the reason of the job failure
+func (r *ReconcileManager) jobFailureMessage(job *batchv1.Job, reason string) string {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err == nil {
+		for _, pod := range pods.Items {
+			for _, cs := range pod.Status.ContainerStatuses {
+				if t := cs.State.Terminated; t != nil && t.Message != "" {
+					return strings.TrimSpace(t.Message)
+				}
+			}
+		}
+	}
+	return reason
+}
+
+func backupStatus(manager *v1alpha1.Manager, service string) *v1alpha1.BackupStatus {
+	for idx := range manager.Status.Backups {
+		if manager.Status.Backups[idx].Service == service {
+			return &manager.Status.Backups[idx]
+		}
+	}
+	manager.Status.Backups = append(manager.Status.Backups, v1alpha1.BackupStatus{Service: service})
+	return &manager.Status.Backups[len(manager.Status.Backups)-1]
+}
+
+func setBackupResult(status *v1alpha1.BackupStatus, folder string, err error) {
+	now := v1.Now()
+	if err != nil {
+		status.LastFailedTime = &now
+		status.Message = err.Error()
+		return
+	}
+	status.LastSuccessfulTime = &now
+	status.LastBackup = folder
+	status.Message = ""
+}
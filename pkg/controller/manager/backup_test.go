@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/backup"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, batchv1.SchemeBuilder.AddToScheme(scheme), "Failed to add BatchV1 into scheme")
+	require.NoError(t, appsv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AppsV1 into scheme")
+	return scheme
+}
+
+func newBackupManager() *v1alpha1.Manager {
+	return &v1alpha1.Manager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "tf"},
+		Spec: v1alpha1.ManagerSpec{
+			Backup: &v1alpha1.BackupConfiguration{
+				IntervalMinutes: 60,
+				S3:              v1alpha1.BackupS3{Endpoint: "http://minio:9000", Bucket: "backups", Prefix: "tf", CredentialsSecret: "s3"},
+			},
+			Services: v1alpha1.Services{
+				Cassandras: []*v1alpha1.CassandraInput{{Metadata: v1alpha1.Metadata{Name: "configdb1"}}},
+				Zookeeper:  &v1alpha1.ZookeeperInput{Metadata: v1alpha1.Metadata{Name: "zookeeper1"}},
+			},
+		},
+	}
+}
+
+func newOperatorPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "tf-operator-1", Namespace: "tf"},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "tf-operator",
+			Containers:         []corev1.Container{{Name: "tf-operator", Image: "tf-operator:latest"}},
+		},
+	}
+}
+
+func backupJobs(t *testing.T, cl client.Client) []batchv1.Job {
+	jobs := &batchv1.JobList{}
+	require.NoError(t, cl.List(context.TODO(), jobs, client.InNamespace("tf")))
+	return jobs.Items
+}
+
+func TestProcessBackupRunsJobs(t *testing.T) {
+	defer os.Unsetenv("POD_NAME")
+	require.NoError(t, os.Setenv("POD_NAME", "tf-operator-1"))
+
+	scheme := newTestScheme(t)
+	manager := newBackupManager()
+	cl := fake.NewFakeClientWithScheme(scheme, manager, newOperatorPod())
+	r := &ReconcileManager{Client: cl, Scheme: scheme}
+
+	next, err := r.processBackup(manager)
+	require.NoError(t, err)
+	assert.Equal(t, float64(60), next.Minutes())
+	require.NotNil(t, manager.Status.LastBackupTime)
+
+	jobs := backupJobs(t, cl)
+	require.Len(t, jobs, 2)
+	require.Len(t, manager.Status.Backups, 2)
+	for _, status := range manager.Status.Backups {
+		assert.NotEmpty(t, status.Job, status.Service)
+		assert.Nil(t, status.LastSuccessfulTime, "backup is not finished yet")
+	}
+	for _, job := range jobs {
+		assert.Equal(t, "tf-operator:latest", job.Spec.Template.Spec.Containers[0].Image)
+		assert.Equal(t, "cluster1", job.OwnerReferences[0].Name)
+	}
+
+	// the next reconcile doesn't start backups till the interval
+	_, err = r.processBackup(manager)
+	require.NoError(t, err)
+	assert.Len(t, backupJobs(t, cl), 2)
+	for _, status := range manager.Status.Backups {
+		assert.NotEmpty(t, status.Job, "running job is kept in the status")
+	}
+}
+
+func TestProcessBackupRecordsResults(t *testing.T) {
+	scheme := newTestScheme(t)
+	manager := newBackupManager()
+	now := metav1.Now()
+	manager.Status.LastBackupTime = &now
+	manager.Status.Backups = []v1alpha1.BackupStatus{
+		{Service: "cassandra/configdb1", Job: "configdb1-job"},
+		{Service: "zookeeper/zookeeper1", Job: "zookeeper1-job"},
+		{Service: "cassandra/analyticsdb1", Job: "lost-job"},
+	}
+	newJob := func(name, service string, status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "tf",
+				Labels:    backup.JobLabels(backup.BackupCommand, "cluster1"),
+				Annotations: map[string]string{
+					backup.ServiceAnnotation: service,
+					backup.FolderAnnotation:  "tf/" + service + "/20210102T000000Z",
+				},
+			},
+			Status: status,
+		}
+	}
+	failedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1-job-abcde", Namespace: "tf", Labels: map[string]string{"job-name": "zookeeper1-job"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "No running pods of zookeeper/zookeeper1\n"}},
+		}}},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, manager, failedPod,
+		newJob("configdb1-job", "cassandra/configdb1", batchv1.JobStatus{Succeeded: 1}),
+		newJob("zookeeper1-job", "zookeeper/zookeeper1", batchv1.JobStatus{Failed: 1, Conditions: []batchv1.JobCondition{{
+			Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit",
+		}}}),
+	)
+	r := &ReconcileManager{Client: cl, Scheme: scheme}
+
+	_, err := r.processBackup(manager)
+	require.NoError(t, err)
+
+	cassandra, zookeeper, lost := manager.Status.Backups[0], manager.Status.Backups[1], manager.Status.Backups[2]
+	assert.Empty(t, cassandra.Job)
+	require.NotNil(t, cassandra.LastSuccessfulTime)
+	assert.Equal(t, "tf/cassandra/configdb1/20210102T000000Z", cassandra.LastBackup)
+	assert.Empty(t, cassandra.Message)
+
+	assert.Empty(t, zookeeper.Job)
+	require.NotNil(t, zookeeper.LastFailedTime)
+	assert.Nil(t, zookeeper.LastSuccessfulTime)
+	assert.Equal(t, "Backup job zookeeper1-job failed: No running pods of zookeeper/zookeeper1", zookeeper.Message)
+
+	assert.Empty(t, lost.Job)
+	assert.Contains(t, lost.Message, "lost-job is not found")
+
+	assert.Empty(t, backupJobs(t, cl), "finished jobs are deleted")
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "configdb1-job", Namespace: "tf"}, &batchv1.Job{})
+	assert.True(t, errors.IsNotFound(err))
+}
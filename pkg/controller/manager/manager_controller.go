@@ -9,6 +9,7 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -28,7 +30,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/fatih/structs"
-	"github.com/go-logr/logr"
 	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
 	"github.com/tungstenfabric/tf-operator/pkg/certificates"
 	"github.com/tungstenfabric/tf-operator/pkg/controller/utils"
@@ -55,6 +56,7 @@ var resourcesList = []runtime.Object{
 	&v1alpha1.Vrouter{},
 	&v1alpha1.Kubemanager{},
 	&corev1.ConfigMap{},
+	&batchv1.Job{},
 }
 
 /**
@@ -69,10 +71,13 @@ func Add(mgr manager.Manager) error {
 		return err
 	}
 	reconcileManager := &ReconcileManager{Client: mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Manager: mgr,
+		Scheme:   mgr.GetScheme(),
+		Manager:  mgr,
+		Recorder: mgr.GetEventRecorderFor("manager-controller"),
 	}
-	c, err := controller.New("manager-controller", mgr, controller.Options{Reconciler: reconcileManager})
+	c, err := controller.New("manager-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(reconcileManager, mgr.GetClient(), reconcileManager.Recorder, &v1alpha1.Manager{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -106,9 +111,15 @@ var _ reconcile.Reconciler = &ReconcileManager{}
 type ReconcileManager struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver.
-	Client  client.Client
-	Scheme  *runtime.Scheme
-	Manager manager.Manager
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Manager  manager.Manager
+	Recorder record.EventRecorder
+}
+
+// recordProcessFailure records Warning event on the manager when services of the kind are not processed
+func (r *ReconcileManager) recordProcessFailure(manager *v1alpha1.Manager, process string, err error) {
+	v1alpha1.Eventf(r.Recorder, manager, corev1.EventTypeWarning, v1alpha1.EventReasonProcessFailed, "%s failed: %v", process, err)
 }
 
 // Got unstructured Services and Kind
@@ -144,9 +155,16 @@ func iterateOverKindInstances(kind string,
 		return nil, err
 	}
 
+	// deprecated single kubemanager is served as one of kubemanagers
+	mngr.Spec.Services.Kubemanagers = mngr.Spec.Services.KubemanagerInputs()
+	mngr.Spec.Services.Kubemanager = nil
+
 	var service reflect.Value
 	var _tried_services string
 	for serviceName := range structs.Map(mngr.Spec.Services) {
+		if serviceName == "Kubemanager" {
+			continue
+		}
 		if strings.EqualFold(serviceName, kind) || strings.EqualFold(serviceName, kind+"s") {
 			_srvs := reflect.Indirect(reflect.ValueOf(&mngr.Spec.Services))
 			service = _srvs.FieldByName(serviceName)
@@ -273,14 +291,18 @@ func getIfaceField(xPath string, iface interface{}) interface{} {
 // Get Kind based on ZIU Stage
 // Get manager unstructured spec for kind
 // For each instance of the service check if Instance is updated
-func isServiceUpdated(ziuStage v1alpha1.ZIUStatus, clnt client.Client) (bool, error) {
+func isServiceUpdated(ziuStage v1alpha1.ZIUStatus, checks []v1alpha1.ZiuReadinessCheck, clnt client.Client) (bool, error) {
 	kind := v1alpha1.ZiuKinds[ziuStage]
 	u, err := getManagerUnstructured(clnt)
 	if err != nil {
 		return false, err
 	}
+	if len(checks) == 0 {
+		checks = v1alpha1.ZiuReadinessChecksAll
+	}
 	params := map[string]interface{}{
 		"Manager": u.UnstructuredContent(),
+		"Checks":  checks,
 	}
 
 	resArr, err := iterateOverKindInstances(kind, isServiceInstanceUpdated, clnt, params)
@@ -297,7 +319,7 @@ func isServiceUpdated(ziuStage v1alpha1.ZIUStatus, clnt client.Client) (bool, er
 	return res, nil
 }
 
-// As params we got "Manager" spec as Unstructured
+// As params we got "Manager" spec as Unstructured and readiness "Checks" to run
 // And return boolean under "Updated" key in the map
 //
 // Find in the cluster STS related to the instance on service in manager spec
@@ -354,34 +376,36 @@ func isServiceInstanceUpdated(kind string, serviceName string, isSlice bool, cln
 	managerContainerImage := getIfaceField("image", managerContainerList[0]).(string)
 
 	// Find a container with the same name in STS template
-	if !checkContainersTag(managerContainerName, managerContainerImage, sts.Spec.Template.Spec.Containers) {
+	if hasZiuCheck(params, v1alpha1.ZiuCheckImage) && !checkContainersTag(managerContainerName, managerContainerImage, sts.Spec.Template.Spec.Containers) {
 		ll.Info(fmt.Sprintf("Container %s/%s is not updated, spec: %+v", managerContainerName, managerContainerImage, sts.Spec.Template.Spec.Containers))
 		return updatedFalse, nil
 	}
 
 	// check if STS Updated Replicas is the same with Replicas
-	if sts.Status.Replicas != sts.Status.UpdatedReplicas {
+	if hasZiuCheck(params, v1alpha1.ZiuCheckReplicas) && sts.Status.Replicas != sts.Status.UpdatedReplicas {
 		ll.Info(fmt.Sprintf("STS replicas not udpated: Replicas(%d) != UpdatedReplicas(%d)", sts.Status.Replicas, sts.Status.UpdatedReplicas))
 		return updatedFalse, nil
 	}
 	// Get service pods
-	var pods *corev1.PodList
-	if pods, err = v1alpha1.SelectPods(serviceName, strings.ToLower(kind), "tf", clnt); err != nil {
-		return updatedFalse, err
-	}
-	for _, podItem := range pods.Items {
-		if podItem.Status.Phase != corev1.PodPhase("Running") ||
-			!cmpContainers(sts.Spec.Template.Spec.Containers, podItem.Spec.Containers) {
-			if podItem.Status.Phase != corev1.PodPhase("Running") {
-				ll.Info(fmt.Sprintf("STS pod not ready: name=%s phase=%s ", podItem.Name, podItem.Status.Phase))
-			} else {
-				ll.Info("Pod containers are not ready, spec: %+v, pod: %+v", sts.Spec.Template.Spec.Containers, podItem.Spec.Containers)
+	if hasZiuCheck(params, v1alpha1.ZiuCheckPods) {
+		var pods *corev1.PodList
+		if pods, err = v1alpha1.SelectPods(serviceName, strings.ToLower(kind), "tf", clnt); err != nil {
+			return updatedFalse, err
+		}
+		for _, podItem := range pods.Items {
+			if podItem.Status.Phase != corev1.PodPhase("Running") ||
+				!cmpContainers(sts.Spec.Template.Spec.Containers, podItem.Spec.Containers) {
+				if podItem.Status.Phase != corev1.PodPhase("Running") {
+					ll.Info(fmt.Sprintf("STS pod not ready: name=%s phase=%s ", podItem.Name, podItem.Status.Phase))
+				} else {
+					ll.Info("Pod containers are not ready, spec: %+v, pod: %+v", sts.Spec.Template.Spec.Containers, podItem.Spec.Containers)
+				}
+				return updatedFalse, nil
 			}
-			return updatedFalse, nil
 		}
 	}
 	// Check if Service has Active status
-	if !v1alpha1.IsUnstructuredActive(kind, serviceName, "tf", clnt) {
+	if hasZiuCheck(params, v1alpha1.ZiuCheckActive) && !v1alpha1.IsUnstructuredActive(kind, serviceName, "tf", clnt) {
 		ll.Info("Service is not active")
 		return updatedFalse, nil
 	}
@@ -389,6 +413,20 @@ func isServiceInstanceUpdated(kind string, serviceName string, isSlice bool, cln
 	return updatedTrue, nil
 }
 
+// hasZiuCheck returns true if readiness check is requested in params
+func hasZiuCheck(params map[string]interface{}, check v1alpha1.ZiuReadinessCheck) bool {
+	checks, ok := params["Checks"].([]v1alpha1.ZiuReadinessCheck)
+	if !ok {
+		return true
+	}
+	for _, c := range checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
 func cmpContainers(stsContainers, podContainers []corev1.Container) bool {
 	for _, c := range stsContainers {
 		if !checkContainersTag(c.Name, c.Image, podContainers) {
@@ -464,63 +502,11 @@ func updateResource(kind string, serviceName string, isSlice bool, clnt client.C
 
 func updateZiuResource(kind string, serviceName string, isSlice bool, clnt client.Client, params map[string]interface{}) (map[string]interface{}, error) {
 	fake := make(map[string]interface{})
-	return fake, updateResource(kind, serviceName, isSlice, clnt)
-}
-
-func processZiuStage(ziuStage v1alpha1.ZIUStatus, clnt client.Client) error {
-	if _, err := iterateOverKindInstances(v1alpha1.ZiuKinds[ziuStage], updateZiuResource, clnt, nil); err != nil {
-		return err
-	}
-	return v1alpha1.SetZiuStage(int(ziuStage)+1, clnt)
-}
-
-func ReconcileZiu(namespace string, log logr.Logger, clnt client.Client, scheme *runtime.Scheme) (reconcile.Result, error) {
-	ziuLock.Lock()
-	defer ziuLock.Unlock()
-
-	reqLogger := log.WithName("ZIU")
-	restartTime, _ := time.ParseDuration("15s")
-	requeueResult := reconcile.Result{Requeue: true, RequeueAfter: restartTime}
-
-	ziuStage, err := v1alpha1.GetZiuStage(clnt)
-	if err != nil {
-		reqLogger.Error(err, "Error in ZIU")
-		return requeueResult, err
-	}
-	if ziuStage < 0 {
-		var f bool
-		f, err = v1alpha1.IsZiuRequired(clnt)
-		if err != nil {
-			reqLogger.Error(err, "Error in ZIU")
-			return requeueResult, err
-		}
-		if f {
-			log.Info("Start ZIU process")
-			if err = v1alpha1.InitZiu(clnt); err == nil {
-				err = EnableZiu2011(namespace, clnt, scheme, reqLogger)
-			}
-			return requeueResult, err
-		}
-		return reconcile.Result{}, err
+	// Save spec to be able to rollback ZIU
+	if err := snapshotZiuResource(kind, serviceName, clnt, params["Scheme"].(*runtime.Scheme)); err != nil {
+		return fake, err
 	}
-
-	// We have to wait previous stage updated and ready
-	if ziuStage > 0 {
-		if isUpdated, err := isServiceUpdated(ziuStage-1, clnt); err != nil || !isUpdated {
-			reqLogger.Info("Wait for updating services", "ziuStage", ziuStage-1, "err", err)
-			return requeueResult, err
-		}
-	}
-	if len(v1alpha1.ZiuKinds) == int(ziuStage) {
-		// ZIU have been finished - set stage to -1
-		reqLogger.Info("ZIU done")
-		return requeueResult, v1alpha1.SetZiuStage(-1, clnt)
-	}
-	if err := EnableZiu2011ForCR(v1alpha1.ZiuKinds[ziuStage], namespace, clnt, scheme, reqLogger); err != nil {
-		return requeueResult, err
-	}
-	reqLogger.Info("Process ZIU stage", "ziuStage", ziuStage)
-	return requeueResult, processZiuStage(ziuStage, clnt)
+	return fake, updateResource(kind, serviceName, isSlice, clnt)
 }
 
 // Reconcile reconciles the manager.
@@ -551,11 +537,92 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
+	// cluster parameters are resolved once here, services read them from the status
+	if cfg, sources, err := v1alpha1.ResolveClusterParameters(r.Client); err == nil {
+		instance.Status.ClusterConfig = cfg
+		instance.Status.ClusterConfigSources = sources
+	} else {
+		log.Error(err, "ResolveClusterParameters")
+	}
+
+	var requeueErr error = nil
+	if instance.IsZiuRolledBack() {
+		// services keep specs ZIU has been rolled back to
+		reqLogger.Info("ZIU has been rolled back, services are not updated till the Manager spec is changed",
+			"image", instance.Status.ZiuRolledBackImage)
+	} else {
+		requeueErr = r.processServices(instance)
+	}
+
+	restoring, err := r.processRestore(instance)
+	if err != nil {
+		log.Error(err, "processRestore")
+		r.recordProcessFailure(instance, "processRestore", err)
+	}
+
+	// data stores are not backed up till they are restored
+	var nextBackup time.Duration
+	if !restoring {
+		if nextBackup, err = r.processBackup(instance); err != nil {
+			log.Error(err, "processBackup")
+			r.recordProcessFailure(instance, "processBackup", err)
+		}
+	}
+
+	if err := k8s.UpdateNetworkStatus(r.Client); err != nil {
+		log.Error(err, "Update Network Status failed")
+		if v1alpha1.IsOKForRequeque(err) {
+			requeueErr = err
+		}
+	}
+
+	// certificates are renewed by owners of their secrets, the manager schedules it
+	nextRenewal, err := v1alpha1.CertificatesRenewal(instance.Namespace, r.Client)
+	if err != nil {
+		log.Error(err, "CertificatesRenewal")
+		r.recordProcessFailure(instance, "CertificatesRenewal", err)
+	}
+	if notAfter, err := certificates.CAExpiry(instance.Namespace, r.Client); err == nil {
+		instance.Status.CACertificateNotAfter = &v1.Time{Time: notAfter}
+	}
+
+	r.setConditions(instance)
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		if v1alpha1.IsOKForRequeque(err) {
+			log.Info("Failed to update status, and reconcile is restarting.")
+			return requeueReconcile, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if requeueErr != nil || restoring {
+		return requeueReconcile, nil
+	}
+
+	// the keystone password is read from its source again on the next reconcile
+	requeueAfter := instance.Spec.CommonConfiguration.AuthParameters.RefreshInterval()
+	for _, next := range []time.Duration{nextBackup, nextRenewal} {
+		if next > 0 && (requeueAfter == 0 || next < requeueAfter) {
+			requeueAfter = next
+		}
+	}
+	if requeueAfter > 0 {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// processServices creates or updates services of the Manager spec and reads their statuses.
+// Returns the error to requeue the reconcile with.
+func (r *ReconcileManager) processServices(instance *v1alpha1.Manager) error {
 	var requeueErr error = nil
 	if err := r.processVRouters(instance); err != nil {
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processVRouters, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processVRouters", err)
 		}
 		log.Error(err, "processVRouters")
 	}
@@ -564,6 +631,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processRabbitMQ, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processRabbitMQ", err)
 		}
 		log.Error(err, "processRabbitMQ")
 	}
@@ -572,6 +641,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processCassandras, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processCassandras", err)
 		}
 		log.Error(err, "processCassandras")
 	}
@@ -580,6 +651,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processRedis, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processRedis", err)
 		}
 		log.Error(err, "processRedis")
 	}
@@ -588,6 +661,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processZookeepers, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processZookeepers", err)
 		}
 		log.Error(err, "processZookeepers")
 	}
@@ -596,6 +671,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processControls, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processControls", err)
 		}
 		log.Error(err, "processControls")
 	}
@@ -604,6 +681,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processConfig, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processConfig", err)
 		}
 		log.Error(err, "processConfig")
 	}
@@ -612,6 +691,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processWebui, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processWebui", err)
 		}
 		log.Error(err, "processWebui")
 	}
@@ -620,6 +701,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processAnalytics, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processAnalytics", err)
 		}
 		log.Error(err, "processAnalytics")
 	}
@@ -628,6 +711,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processQueryEngine, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processQueryEngine", err)
 		}
 		log.Error(err, "processQueryEngine")
 	}
@@ -636,6 +721,8 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processAnalyticsSnmp, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processAnalyticsSnmp", err)
 		}
 		log.Error(err, "processAnalyticsSnmp")
 	}
@@ -644,50 +731,73 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		if v1alpha1.IsOKForRequeque(err) {
 			log.Info("Failed to processAnalyticsAlarm, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processAnalyticsAlarm", err)
 		}
 		log.Error(err, "processAnalyticsAlarm")
 	}
 
-	if err := r.processKubemanager(instance); err != nil {
+	if err := r.processKubemanagers(instance); err != nil {
 		if v1alpha1.IsOKForRequeque(err) {
-			log.Info("Failed to processKubemanager, future rereconcile")
+			log.Info("Failed to processKubemanagers, future rereconcile")
 			requeueErr = err
+		} else {
+			r.recordProcessFailure(instance, "processKubemanagers", err)
 		}
-		log.Error(err, "processKubemanager")
+		log.Error(err, "processKubemanagers")
 	}
+	return requeueErr
+}
 
-	if err := k8s.UpdateNetworkStatus(r.Client); err != nil {
-		log.Error(err, "Update Network Status failed")
-		if v1alpha1.IsOKForRequeque(err) {
-			requeueErr = err
-		}
+func (r *ReconcileManager) setConditions(manager *v1alpha1.Manager) {
+	generation := manager.Generation
+	manager.Status.ObservedGeneration = generation
+	conditions := &manager.Status.Conditions
+
+	notReady := manager.NotReadyServices()
+	if len(notReady) == 0 {
+		v1alpha1.SetCondition(conditions, generation, string(v1alpha1.ManagerReady), true, "ServicesReady", "All services are ready")
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionAvailable, true, "ServicesReady", "All services are ready")
+	} else {
+		message := "Services are not ready: " + strings.Join(notReady, ", ")
+		v1alpha1.SetCondition(conditions, generation, string(v1alpha1.ManagerReady), false, "ServicesNotReady", message)
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionAvailable, false, "ServicesNotReady", message)
 	}
 
-	r.setConditions(instance)
-	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
-		if v1alpha1.IsOKForRequeque(err) {
-			log.Info("Failed to update status, and reconcile is restarting.")
-			return requeueReconcile, nil
-		}
-		return reconcile.Result{}, err
+	degraded := manager.DegradedServices()
+	if len(degraded) > 0 {
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionDegraded, true, "ServicesDegraded",
+			"Services are degraded: "+strings.Join(degraded, ", "))
+	} else {
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionDegraded, false, "ServicesNotDegraded", "No services are degraded")
 	}
 
-	if requeueErr != nil {
-		return requeueReconcile, nil
+	upgrading := manager.IsZiuInProgress()
+	if upgrading {
+		reason, message := "ZiuInProgress", fmt.Sprintf("ZIU stage %d of %d", manager.Status.ZiuState, len(manager.Status.ZiuStages))
+		if int(manager.Status.ZiuState) < len(manager.Status.ZiuStages) {
+			message += ": " + manager.Status.ZiuStages[manager.Status.ZiuState].Kind
+		}
+		if manager.Status.ZiuRollback {
+			reason = "ZiuRollback"
+		}
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionUpgrading, true, reason, message)
+	} else if manager.IsZiuRolledBack() {
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionUpgrading, false, "ZiuRolledBack",
+			fmt.Sprintf("ZIU to image %s is rolled back, services are not updated till the Manager spec is changed", manager.Status.ZiuRolledBackImage))
+	} else {
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionUpgrading, false, "ZiuNotInProgress", "ZIU is not in progress")
 	}
 
-	return reconcile.Result{}, nil
-}
-
-func (r *ReconcileManager) setConditions(manager *v1alpha1.Manager) {
-	readyStatus := v1alpha1.ConditionFalse
-	if manager.IsClusterReady() {
-		readyStatus = v1alpha1.ConditionTrue
-	}
-	manager.Status.Conditions = []v1alpha1.ManagerCondition{{
-		Type:   v1alpha1.ManagerReady,
-		Status: readyStatus,
-	}}
+	switch {
+	case upgrading:
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionProgressing, true, "ZiuInProgress", "Services are upgraded by ZIU")
+	case len(notReady) > 0:
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionProgressing, true, "ServicesNotReady",
+			"Waiting for services: "+strings.Join(notReady, ", "))
+	default:
+		v1alpha1.SetCondition(conditions, generation, v1alpha1.ConditionProgressing, false, "ServicesReconciled", "All services are reconciled")
+	}
 }
 
 func ProcessAnalytics(manager *v1alpha1.Manager, clnt client.Client, scheme *runtime.Scheme) (*v1alpha1.Analytics, error) {
@@ -725,6 +835,7 @@ func ProcessAnalytics(manager *v1alpha1.Manager, clnt client.Client, scheme *run
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &analytics.Name
 	status.Active = analytics.Status.Active
+	status.Degraded = analytics.Status.Degraded
 	manager.Status.Analytics = status
 	return analytics, nil
 }
@@ -769,6 +880,7 @@ func ProcessQueryEngine(manager *v1alpha1.Manager, clnt client.Client, scheme *r
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &queryengine.Name
 	status.Active = queryengine.Status.Active
+	status.Degraded = queryengine.Status.Degraded
 	manager.Status.QueryEngine = status
 	return queryengine, nil
 }
@@ -813,6 +925,7 @@ func (r *ReconcileManager) processAnalyticsSnmp(manager *v1alpha1.Manager) error
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &analyticsSnmp.Name
 	status.Active = analyticsSnmp.Status.Active
+	status.Degraded = analyticsSnmp.Status.Degraded
 	manager.Status.AnalyticsSnmp = status
 	return err
 }
@@ -852,6 +965,7 @@ func (r *ReconcileManager) processAnalyticsAlarm(manager *v1alpha1.Manager) erro
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &analyticsAlarm.Name
 	status.Active = analyticsAlarm.Status.Active
+	status.Degraded = analyticsAlarm.Status.Degraded
 	manager.Status.AnalyticsAlarm = status
 	return err
 }
@@ -903,6 +1017,7 @@ func ProcessCassandras(manager *v1alpha1.Manager, clnt client.Client, scheme *ru
 		status := &v1alpha1.ServiceStatus{}
 		status.Name = &cassandra.Name
 		status.Active = cassandra.Status.Active
+		status.Degraded = cassandra.Status.Degraded
 		cassandraStatusList = append(cassandraStatusList, status)
 		result = append(result, cassandra)
 	}
@@ -946,7 +1061,7 @@ func (r *ReconcileManager) processZookeepers(manager *v1alpha1.Manager) error {
 	if err != nil {
 		return err
 	}
-	status := &v1alpha1.ServiceStatus{Name: &zookeeper.Name, Active: zookeeper.Status.Active}
+	status := &v1alpha1.ServiceStatus{Name: &zookeeper.Name, Active: zookeeper.Status.Active, Degraded: zookeeper.Status.Degraded}
 	manager.Status.Zookeeper = status
 	return nil
 }
@@ -1008,7 +1123,7 @@ func ProcessRedis(manager *v1alpha1.Manager, clnt client.Client, scheme *runtime
 		if err != nil {
 			return nil, err
 		}
-		status := &v1alpha1.ServiceStatus{Name: &redis.Name, Active: redis.Status.Active}
+		status := &v1alpha1.ServiceStatus{Name: &redis.Name, Active: redis.Status.Active, Degraded: redis.Status.Degraded}
 		redisStatusList = append(redisStatusList, status)
 		result = append(result, redis)
 	}
@@ -1052,7 +1167,7 @@ func (r *ReconcileManager) processWebui(manager *v1alpha1.Manager) error {
 	if err != nil {
 		return err
 	}
-	status := &v1alpha1.ServiceStatus{Name: &webui.Name, Active: webui.Status.Active}
+	status := &v1alpha1.ServiceStatus{Name: &webui.Name, Active: webui.Status.Active, Degraded: webui.Status.Degraded}
 	manager.Status.Webui = status
 	return err
 }
@@ -1096,27 +1211,45 @@ func (r *ReconcileManager) processConfig(manager *v1alpha1.Manager) error {
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &config.Name
 	status.Active = config.Status.Active
+	status.Degraded = config.Status.Degraded
 	manager.Status.Config = status
 	return nil
 }
 
-func (r *ReconcileManager) processKubemanager(manager *v1alpha1.Manager) error {
-	if manager.Spec.Services.Kubemanager == nil {
-		if manager.Status.Kubemanager != nil {
-			old := &v1alpha1.Kubemanager{}
-			old.ObjectMeta = v1.ObjectMeta{
+func (r *ReconcileManager) processKubemanagers(manager *v1alpha1.Manager) error {
+	intendedKubemanagers := manager.Spec.Services.KubemanagerInputs()
+	existingKubemanagers := manager.Status.Kubemanagers
+	if manager.Status.Kubemanager != nil && manager.Status.Kubemanager.Name != nil {
+		// status of the deprecated single kubemanager is migrated to the list
+		existingKubemanagers = append(existingKubemanagers, &v1alpha1.KubemanagerServiceStatus{ServiceStatus: *manager.Status.Kubemanager})
+	}
+	for _, existingKubemanager := range existingKubemanagers {
+		found := false
+		for _, intendedKubemanager := range intendedKubemanagers {
+			if *existingKubemanager.Name == intendedKubemanager.Metadata.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			oldKubemanager := &v1alpha1.Kubemanager{}
+			oldKubemanager.ObjectMeta = v1.ObjectMeta{
 				Namespace: manager.Namespace,
-				Name:      *manager.Status.Kubemanager.Name,
+				Name:      *existingKubemanager.Name,
 				Labels: map[string]string{
 					"tf_cluster": manager.Name,
 				},
 			}
-			err := r.Client.Delete(context.TODO(), old)
+			err := r.Client.Delete(context.TODO(), oldKubemanager)
 			if err != nil && !errors.IsNotFound(err) {
 				return err
 			}
-			manager.Status.Kubemanager = nil
 		}
+	}
+
+	if len(intendedKubemanagers) == 0 {
+		manager.Status.Kubemanager = nil
+		manager.Status.Kubemanagers = nil
 		return nil
 	}
 
@@ -1124,19 +1257,30 @@ func (r *ReconcileManager) processKubemanager(manager *v1alpha1.Manager) error {
 		return nil
 	}
 
-	kubemanager := &v1alpha1.Kubemanager{}
-	kubemanager.ObjectMeta.Name = manager.Spec.Services.Kubemanager.Metadata.Name
-	kubemanager.ObjectMeta.Labels = manager.Spec.Services.Kubemanager.Metadata.Labels
-	kubemanager.ObjectMeta.Namespace = manager.Namespace
-	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kubemanager, func() error {
-		kubemanager.Spec = manager.Spec.Services.Kubemanager.Spec
-		kubemanager.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, kubemanager.Spec.CommonConfiguration)
-		return controllerutil.SetControllerReference(manager, kubemanager, r.Scheme)
-	})
-	if err != nil {
-		return err
+	var kubemanagerServiceStatus []*v1alpha1.KubemanagerServiceStatus
+	for _, kubemanagerService := range intendedKubemanagers {
+		kubemanager := &v1alpha1.Kubemanager{}
+		kubemanager.ObjectMeta.Name = kubemanagerService.Metadata.Name
+		kubemanager.ObjectMeta.Labels = kubemanagerService.Metadata.Labels
+		kubemanager.ObjectMeta.Namespace = manager.Namespace
+		_, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kubemanager, func() error {
+			kubemanager.Spec = kubemanagerService.Spec
+			kubemanager.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, kubemanager.Spec.CommonConfiguration)
+			return controllerutil.SetControllerReference(manager, kubemanager, r.Scheme)
+		})
+		if err != nil {
+			return err
+		}
+		status := &v1alpha1.KubemanagerServiceStatus{}
+		status.Name = &kubemanager.Name
+		status.Active = kubemanager.Status.Active
+		status.Degraded = kubemanager.Status.Degraded
+		status.ClusterName = kubemanager.Status.ClusterName
+		kubemanagerServiceStatus = append(kubemanagerServiceStatus, status)
 	}
-	manager.Status.Kubemanager = &v1alpha1.ServiceStatus{Name: &kubemanager.Name, Active: kubemanager.Status.Active}
+
+	manager.Status.Kubemanager = nil
+	manager.Status.Kubemanagers = kubemanagerServiceStatus
 	return nil
 }
 
@@ -1186,6 +1330,7 @@ func (r *ReconcileManager) processControls(manager *v1alpha1.Manager) error {
 		status := &v1alpha1.ServiceStatus{}
 		status.Name = &control.Name
 		status.Active = control.Status.Active
+		status.Degraded = control.Status.Degraded
 		controlServiceStatus = append(controlServiceStatus, status)
 	}
 
@@ -1232,6 +1377,7 @@ func (r *ReconcileManager) processRabbitMQ(manager *v1alpha1.Manager) error {
 	status := &v1alpha1.ServiceStatus{}
 	status.Name = &rabbitMQ.Name
 	status.Active = rabbitMQ.Status.Active
+	status.Degraded = rabbitMQ.Status.Degraded
 	manager.Status.Rabbitmq = status
 	return err
 }
@@ -1294,5 +1440,5 @@ func (r *ReconcileManager) processCSRSignerCaConfigMap(manager *v1alpha1.Manager
 		certificates.ServerSignerName = *manager.Spec.CommonConfiguration.CertSigner
 		certificates.ClientSignerName = *manager.Spec.CommonConfiguration.CertSigner
 	}
-	return v1alpha1.InitCA(r.Client, r.Scheme, manager, "manager")
+	return v1alpha1.InitCA(r.Client, r.Scheme, manager, "manager", manager.Spec.CommonConfiguration.CertLifetimes())
 }
@@ -0,0 +1,251 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/backup"
+)
+
+// restoreTarget is a data store to be restored
+type restoreTarget struct {
+	kind         string
+	name         string
+	instanceType string
+}
+
+func (t restoreTarget) service() string {
+	return t.kind + "/" + t.name
+}
+
+// processRestore restores cassandras and zookeeper from the backup snapshot.
+// The archives are streamed into the running pods by restore jobs, then pods are restarted
+// and move the data into place on start. Other services are not reconciled
+// till all data stores are restored (see v1alpha1.CanReconcile).
+// Returns true if the restore is in progress.
+func (r *ReconcileManager) processRestore(manager *v1alpha1.Manager) (bool, error) {
+	cfg := manager.Spec.Restore
+	if cfg == nil {
+		return false, nil
+	}
+	status := manager.Status.Restore
+	if status == nil || status.Snapshot != cfg.Snapshot {
+		now := v1.Now()
+		status = &v1alpha1.RestoreStatus{Snapshot: cfg.Snapshot, Phase: v1alpha1.RestoreInProgress, StartTime: &now}
+		manager.Status.Restore = status
+	}
+	if status.Phase == v1alpha1.RestoreCompleted {
+		return false, nil
+	}
+
+	s3cfg := cfg.S3
+	if s3cfg == nil && manager.Spec.Backup != nil {
+		s3cfg = &manager.Spec.Backup.S3
+	}
+	if s3cfg == nil {
+		status.Message = "S3 store of the backup is not set"
+		return true, fmt.Errorf("%s", status.Message)
+	}
+
+	var targets []restoreTarget
+	for _, cassandraService := range manager.Spec.Services.Cassandras {
+		targets = append(targets, restoreTarget{
+			kind:         backup.CassandraKind,
+			name:         cassandraService.Metadata.Name,
+			instanceType: v1alpha1.CassandraInstanceType,
+		})
+	}
+	if manager.Spec.Services.Zookeeper != nil {
+		targets = append(targets, restoreTarget{
+			kind:         backup.ZookeeperKind,
+			name:         manager.Spec.Services.Zookeeper.Metadata.Name,
+			instanceType: "zookeeper",
+		})
+	}
+
+	completed := true
+	status.Message = ""
+	for _, target := range targets {
+		restored, err := r.restoreService(manager, target, s3cfg)
+		if err != nil {
+			// the status may be replaced by the staging update
+			manager.Status.Restore.Message = fmt.Sprintf("%s: %v", target.service(), err)
+			return true, err
+		}
+		completed = completed && restored
+	}
+	if completed {
+		now := v1.Now()
+		status = manager.Status.Restore
+		status.Phase = v1alpha1.RestoreCompleted
+		status.CompletionTime = &now
+		log.Info("Restore completed", "snapshot", cfg.Snapshot)
+	}
+	return !completed, nil
+}
+
+func restoreServiceStatus(manager *v1alpha1.Manager, service string) *v1alpha1.RestoreServiceStatus {
+	status := manager.Status.Restore
+	for idx := range status.Services {
+		if status.Services[idx].Service == service {
+			return &status.Services[idx]
+		}
+	}
+	status.Services = append(status.Services, v1alpha1.RestoreServiceStatus{Service: service})
+	return &status.Services[len(status.Services)-1]
+}
+
+// restoreService stages the backup into pods of the service by the restore job and restarts them.
+// The staged time is persisted before pods are restarted, so the backup is staged only once.
+// Returns true when the pods are ready after the restart.
+func (r *ReconcileManager) restoreService(manager *v1alpha1.Manager, target restoreTarget, s3cfg *v1alpha1.BackupS3) (bool, error) {
+	serviceStatus := restoreServiceStatus(manager, target.service())
+	if serviceStatus.Restored {
+		return true, nil
+	}
+	if serviceStatus.StagedTime == nil {
+		staged, err := r.stageRestore(manager, target, s3cfg)
+		if !staged || err != nil {
+			return false, err
+		}
+		serviceStatus = restoreServiceStatus(manager, target.service())
+	}
+
+	podList, err := v1alpha1.SelectPods(target.name, target.instanceType, manager.Namespace, r.Client)
+	if err != nil {
+		return false, err
+	}
+	restarted := true
+	for idx := range podList.Items {
+		pod := &podList.Items[idx]
+		if !pod.CreationTimestamp.Before(serviceStatus.StagedTime) {
+			continue
+		}
+		restarted = false
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		log.Info("Restart pod to restore the backup", "service", target.service(), "pod", pod.Name)
+		if err := r.Client.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	if !restarted {
+		return false, nil
+	}
+	ready, err := r.allPodsReady(target, manager.Namespace, podList.Items)
+	if err != nil || !ready {
+		return false, err
+	}
+	log.Info("Service restored", "service", target.service())
+	serviceStatus.Restored = true
+	return true, nil
+}
+
+// stageRestore runs the restore job of the service once all its pods are running.
+// Returns true when the job has succeeded and the staged time is persisted in the status.
+func (r *ReconcileManager) stageRestore(manager *v1alpha1.Manager, target restoreTarget, s3cfg *v1alpha1.BackupS3) (bool, error) {
+	serviceStatus := restoreServiceStatus(manager, target.service())
+	snapshot := manager.Spec.Restore.Snapshot
+	job := &batchv1.Job{}
+	jobName := types.NamespacedName{
+		Name:      target.name + "-" + target.kind + "-restore-" + strings.ToLower(snapshot),
+		Namespace: manager.Namespace,
+	}
+	err := r.Client.Get(context.TODO(), jobName, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if errors.IsNotFound(err) {
+		pods, err := backup.RunningPods(target.name, target.instanceType, manager.Namespace, r.Client)
+		if err != nil {
+			return false, err
+		}
+		if ready, err := r.allPodsReady(target, manager.Namespace, pods); err != nil || !ready {
+			// wait for all pods
+			return false, err
+		}
+		operatorPod, err := k8sutil.GetPod(context.TODO(), r.Client, manager.Namespace)
+		if err != nil {
+			return false, fmt.Errorf("Failed to get operator pod for restore jobs: %v", err)
+		}
+		folder := path.Join(s3cfg.Prefix, target.service(), snapshot)
+		job = backup.NewRestoreJob(jobName.Name, manager, operatorPod, target.kind, target.name, folder, s3cfg)
+		if err := controllerutil.SetControllerReference(manager, job, r.Scheme); err != nil {
+			return false, err
+		}
+		if err := r.Client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+			return false, err
+		}
+		log.Info("Restore started", "service", target.service(), "job", job.Name, "folder", folder)
+		serviceStatus.Job = job.Name
+		return false, nil
+	}
+
+	failed, reason := isJobFailed(job)
+	if job.Status.Succeeded == 0 && !failed {
+		serviceStatus.Job = job.Name
+		return false, nil
+	}
+	if failed {
+		err = fmt.Errorf("Restore job %s failed: %s", job.Name, r.jobFailureMessage(job, reason))
+		serviceStatus.Job = ""
+	} else {
+		log.Info("Backup staged, restart pods", "service", target.service(), "job", job.Name)
+		now := v1.Now()
+		serviceStatus.StagedTime = &now
+		serviceStatus.Job = ""
+		// pods must not be restarted again if the status is lost, so it is persisted right away
+		if err := r.Client.Status().Update(context.TODO(), manager); err != nil {
+			return false, err
+		}
+	}
+	policy := v1.DeletePropagationBackground
+	if delErr := r.Client.Delete(context.TODO(), job, &client.DeleteOptions{PropagationPolicy: &policy}); delErr != nil && !errors.IsNotFound(delErr) {
+		return false, delErr
+	}
+	return err == nil, err
+}
+
+// allPodsReady returns true if all replicas of the service are running and ready
+func (r *ReconcileManager) allPodsReady(target restoreTarget, namespace string, pods []corev1.Pod) (bool, error) {
+	sts := &appsv1.StatefulSet{}
+	stsName := types.NamespacedName{Name: target.name + "-" + target.instanceType + "-statefulset", Namespace: namespace}
+	if err := r.Client.Get(context.TODO(), stsName, sts); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if sts.Spec.Replicas == nil || len(pods) != int(*sts.Spec.Replicas) {
+		return false, nil
+	}
+	for idx := range pods {
+		if pods[idx].Status.Phase != corev1.PodRunning || !isPodReady(&pods[idx]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
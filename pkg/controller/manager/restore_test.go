@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+const restoreJobName = "zookeeper1-zookeeper-restore-20210102t000000z"
+
+func newRestoreManager() *v1alpha1.Manager {
+	manager := newBackupManager()
+	manager.Spec.Services.Cassandras = nil
+	manager.Spec.Restore = &v1alpha1.RestoreConfiguration{Snapshot: "20210102T000000Z"}
+	return manager
+}
+
+func newZookeeperPod(name string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "tf",
+			Labels:            map[string]string{"tf_manager": "zookeeper", "zookeeper": "zookeeper1"},
+			CreationTimestamp: metav1.Time{Time: created},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      "10.0.0.1",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func newZookeeperSts() *appsv1.StatefulSet {
+	replicas := int32(1)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "zookeeper1-zookeeper-statefulset", Namespace: "tf"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}
+}
+
+func setJobStatus(t *testing.T, cl client.Client, status batchv1.JobStatus) {
+	job := &batchv1.Job{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: restoreJobName, Namespace: "tf"}, job))
+	job.Status = status
+	require.NoError(t, cl.Update(context.TODO(), job))
+}
+
+func TestProcessRestoreStages(t *testing.T) {
+	defer os.Unsetenv("POD_NAME")
+	require.NoError(t, os.Setenv("POD_NAME", "tf-operator-1"))
+
+	scheme := newTestScheme(t)
+	manager := newRestoreManager()
+	oldPod := newZookeeperPod("zookeeper1-zookeeper-statefulset-0", time.Now().Add(-time.Hour))
+	cl := fake.NewFakeClientWithScheme(scheme, manager, newOperatorPod(), newZookeeperSts(), oldPod)
+	r := &ReconcileManager{Client: cl, Scheme: scheme}
+
+	// the restore job is started
+	restoring, err := r.processRestore(manager)
+	require.NoError(t, err)
+	assert.True(t, restoring)
+	require.Len(t, manager.Status.Restore.Services, 1)
+	assert.Equal(t, restoreJobName, manager.Status.Restore.Services[0].Job)
+	job := &batchv1.Job{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: restoreJobName, Namespace: "tf"}, job))
+	assert.Equal(t, "restore", job.Spec.Template.Spec.Containers[0].Args[0])
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--folder=tf/zookeeper/zookeeper1/20210102T000000Z")
+
+	// the job is running
+	restoring, err = r.processRestore(manager)
+	require.NoError(t, err)
+	assert.True(t, restoring)
+	assert.Nil(t, manager.Status.Restore.Services[0].StagedTime)
+
+	// the job has succeeded, the staged time is persisted before pods are restarted
+	setJobStatus(t, cl, batchv1.JobStatus{Succeeded: 1})
+	restoring, err = r.processRestore(manager)
+	require.NoError(t, err)
+	assert.True(t, restoring)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: restoreJobName, Namespace: "tf"}, &batchv1.Job{})
+	assert.True(t, errors.IsNotFound(err), "finished job is deleted")
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: oldPod.Name, Namespace: "tf"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "pod is restarted")
+
+	// the in-memory status is lost, e.g. by an update conflict, the persisted one is used
+	persisted := &v1alpha1.Manager{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "cluster1", Namespace: "tf"}, persisted))
+	require.NotNil(t, persisted.Status.Restore)
+	serviceStatus := persisted.Status.Restore.Services[0]
+	require.NotNil(t, serviceStatus.StagedTime)
+	assert.Empty(t, serviceStatus.Job)
+	assert.False(t, serviceStatus.Restored)
+
+	// the pod is started again, the backup is not staged twice
+	require.NoError(t, cl.Create(context.TODO(), newZookeeperPod(oldPod.Name, time.Now().Add(time.Hour))))
+	restoring, err = r.processRestore(persisted)
+	require.NoError(t, err)
+	assert.False(t, restoring)
+	assert.True(t, persisted.Status.Restore.Services[0].Restored)
+	assert.Equal(t, v1alpha1.RestoreCompleted, persisted.Status.Restore.Phase)
+	assert.Empty(t, backupJobs(t, cl), "no new restore job")
+}
+
+func TestProcessRestoreRestartsStalePods(t *testing.T) {
+	scheme := newTestScheme(t)
+	manager := newRestoreManager()
+	staged := metav1.NewTime(time.Now().Add(-time.Minute))
+	manager.Status.Restore = &v1alpha1.RestoreStatus{
+		Snapshot: "20210102T000000Z",
+		Phase:    v1alpha1.RestoreInProgress,
+		Services: []v1alpha1.RestoreServiceStatus{{Service: "zookeeper/zookeeper1", StagedTime: &staged}},
+	}
+	oldPod := newZookeeperPod("zookeeper1-zookeeper-statefulset-0", time.Now().Add(-time.Hour))
+	cl := fake.NewFakeClientWithScheme(scheme, manager, newZookeeperSts(), oldPod)
+	r := &ReconcileManager{Client: cl, Scheme: scheme}
+
+	// pods are not restarted yet when the staged time was persisted
+	restoring, err := r.processRestore(manager)
+	require.NoError(t, err)
+	assert.True(t, restoring)
+	assert.False(t, manager.Status.Restore.Services[0].Restored)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: oldPod.Name, Namespace: "tf"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "stale pod is restarted")
+	assert.Empty(t, backupJobs(t, cl), "the backup is not staged again")
+}
+
+func TestProcessRestoreJobFailure(t *testing.T) {
+	defer os.Unsetenv("POD_NAME")
+	require.NoError(t, os.Setenv("POD_NAME", "tf-operator-1"))
+
+	scheme := newTestScheme(t)
+	manager := newRestoreManager()
+	pod := newZookeeperPod("zookeeper1-zookeeper-statefulset-0", time.Now().Add(-time.Hour))
+	cl := fake.NewFakeClientWithScheme(scheme, manager, newOperatorPod(), newZookeeperSts(), pod)
+	r := &ReconcileManager{Client: cl, Scheme: scheme}
+
+	_, err := r.processRestore(manager)
+	require.NoError(t, err)
+	setJobStatus(t, cl, batchv1.JobStatus{Failed: 1, Conditions: []batchv1.JobCondition{{
+		Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit",
+	}}})
+
+	restoring, err := r.processRestore(manager)
+	require.Error(t, err)
+	assert.True(t, restoring)
+	status := manager.Status.Restore
+	assert.Contains(t, status.Message, "Restore job "+restoreJobName+" failed: Job has reached the specified backoff limit")
+	assert.Empty(t, status.Services[0].Job)
+	assert.Nil(t, status.Services[0].StagedTime)
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "tf"}, &corev1.Pod{}), "pods are not restarted")
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: restoreJobName, Namespace: "tf"}, &batchv1.Job{})
+	assert.True(t, errors.IsNotFound(err), "failed job is deleted to be retried")
+}
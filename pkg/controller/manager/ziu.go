@@ -0,0 +1,407 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+// ZiuHookFn is a hook of ZIU plan, kind is empty for hooks of the whole plan
+type ZiuHookFn func(kind, namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error
+
+var ziuHooks = map[string]ZiuHookFn{}
+
+// readiness checks of reverted services, images differ from the Manager spec
+var ziuRollbackChecks = []v1alpha1.ZiuReadinessCheck{v1alpha1.ZiuCheckReplicas, v1alpha1.ZiuCheckPods, v1alpha1.ZiuCheckActive}
+
+// RegisterZiuHook registers hook to be referenced by name in ZIU plans
+func RegisterZiuHook(name string, hook ZiuHookFn) {
+	ziuHooks[name] = hook
+}
+
+func runZiuHooks(hooks []string, kind, namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error {
+	for _, name := range hooks {
+		hook, ok := ziuHooks[name]
+		if !ok {
+			return fmt.Errorf("Unknown ZIU hook %s", name)
+		}
+		log.Info("Run ZIU hook", "hook", name, "kind", kind)
+		if err := hook(kind, namespace, clnt, scheme, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ziuPlanStage returns plan stage for the ZIU stage,
+// stage kinds are fixed at ZIU start, so plan changes in the middle of ZIU are ignored
+func ziuPlanStage(plan *v1alpha1.ZiuPlan, ziuStage v1alpha1.ZIUStatus) v1alpha1.ZiuPlanStage {
+	kind := v1alpha1.ZiuKinds[ziuStage]
+	if int(ziuStage) < len(plan.Stages) && plan.Stages[ziuStage].Kind == kind {
+		return plan.Stages[ziuStage]
+	}
+	return v1alpha1.ZiuPlanStage{Kind: kind}
+}
+
+func ziuStageStatus(mngr *v1alpha1.Manager, ziuStage v1alpha1.ZIUStatus) *v1alpha1.ZiuStageStatus {
+	if int(ziuStage) < len(mngr.Status.ZiuStages) {
+		return &mngr.Status.ZiuStages[ziuStage]
+	}
+	return nil
+}
+
+func isZiuStageTimedOut(stage v1alpha1.ZiuPlanStage, status *v1alpha1.ZiuStageStatus) bool {
+	if stage.Timeout == nil || *stage.Timeout <= 0 || status == nil || status.StartTime == nil {
+		return false
+	}
+	return time.Since(status.StartTime.Time) > time.Duration(*stage.Timeout)*time.Second
+}
+
+// updateZiuStatus updates ZIU status fields of freshly read manager
+func updateZiuStatus(clnt client.Client, update func(status *v1alpha1.ManagerStatus)) error {
+	var err error
+	for i := 0; i < 3; i++ {
+		var mngr *v1alpha1.Manager
+		if mngr, err = v1alpha1.GetManagerObject(clnt); err != nil {
+			return err
+		}
+		update(&mngr.Status)
+		if err = clnt.Status().Update(context.Background(), mngr); !errors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func setZiuStagePhase(ziuStage v1alpha1.ZIUStatus, phase v1alpha1.ZiuStagePhase, message string, clnt client.Client) error {
+	return updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+		if int(ziuStage) >= len(status.ZiuStages) {
+			return
+		}
+		now := v1.Now()
+		s := &status.ZiuStages[ziuStage]
+		s.Phase = phase
+		s.Message = message
+		if phase != v1alpha1.ZiuStageInProgress {
+			s.CompletionTime = &now
+		}
+	})
+}
+
+func ziuSnapshotName(mngr *v1alpha1.Manager) string {
+	return mngr.Name + "-ziu-snapshot"
+}
+
+func ziuSnapshotKey(kind, serviceName string) string {
+	return kind + "." + serviceName
+}
+
+// snapshotZiuResource saves spec of the resource into the snapshot configmap before ZIU updates it,
+// spec saved at the first attempt is kept
+func snapshotZiuResource(kind, serviceName string, clnt client.Client, scheme *runtime.Scheme) error {
+	res := getUnstructured(kind)
+	if err := clnt.Get(context.Background(), getObjectKeyTF(serviceName), res); err != nil {
+		if errors.IsNotFound(err) {
+			// Nothing to save - resource is created by ZIU
+			return nil
+		}
+		return err
+	}
+	spec, err := json.Marshal(res.Object["spec"])
+	if err != nil {
+		return err
+	}
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	cm.ObjectMeta = v1.ObjectMeta{Name: ziuSnapshotName(mngr), Namespace: mngr.Namespace}
+	key := ziuSnapshotKey(kind, serviceName)
+	_, err = controllerutil.CreateOrUpdate(context.Background(), clnt, cm, func() error {
+		if _, ok := cm.Data[key]; !ok {
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[key] = string(spec)
+		}
+		return controllerutil.SetControllerReference(mngr, cm, scheme)
+	})
+	return err
+}
+
+// restoreZiuResource sets spec of the resource from the snapshot configmap passed in params
+func restoreZiuResource(kind string, serviceName string, isSlice bool, clnt client.Client, params map[string]interface{}) (map[string]interface{}, error) {
+	fake := make(map[string]interface{})
+	cm := params["Snapshot"].(*corev1.ConfigMap)
+	data, ok := cm.Data[ziuSnapshotKey(kind, serviceName)]
+	if !ok {
+		log.Info("No ZIU snapshot, skip rollback", "kind", kind, "name", serviceName)
+		return fake, nil
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &spec); err != nil {
+		return fake, err
+	}
+	res := getUnstructured(kind)
+	if err := clnt.Get(context.Background(), getObjectKeyTF(serviceName), res); err != nil {
+		if errors.IsNotFound(err) {
+			return fake, nil
+		}
+		return fake, err
+	}
+	res.Object["spec"] = spec
+	log.Info(fmt.Sprintf("Rollback service %s/%s spec: %+v", kind, serviceName, spec))
+	return fake, clnt.Update(context.Background(), res)
+}
+
+// StartZiu initializes ZIU stages and runs pre hooks of the plan
+func StartZiu(namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error {
+	if err := dropZiuSnapshot(clnt); err != nil {
+		return err
+	}
+	if err := v1alpha1.InitZiu(clnt); err != nil {
+		return err
+	}
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	if err != nil {
+		return err
+	}
+	v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuStarted, "ZIU is started, target image is %s", mngr.ZiuTargetImage())
+	plan, err := mngr.ZiuPlan(clnt)
+	if err != nil {
+		return err
+	}
+	return runZiuHooks(plan.PreHooks, "", namespace, clnt, scheme, log)
+}
+
+// dropZiuSnapshot removes specs saved by the previous ZIU
+func dropZiuSnapshot(clnt client.Client) error {
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	cm.ObjectMeta = v1.ObjectMeta{Name: ziuSnapshotName(mngr), Namespace: mngr.Namespace}
+	if err = clnt.Delete(context.Background(), cm); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func isZiuRollbackRequested(mngr *v1alpha1.Manager) bool {
+	return mngr.Status.ZiuRollback || mngr.Annotations[v1alpha1.ZiuRollbackAnnotation] == "true"
+}
+
+// rollbackZiu reverts ZIU stages in reverse order, the stage is reverted
+// when services of the next one are ready
+func rollbackZiu(ziuStage v1alpha1.ZIUStatus, mngr *v1alpha1.Manager, clnt client.Client, log logr.Logger) error {
+	if ziuStage == 0 {
+		log.Info("ZIU rollback done")
+		image := mngr.ZiuTargetImage()
+		if err := updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+			status.ZiuState = -1
+			status.ZiuRollback = false
+			status.ZiuRolledBackImage = image
+			status.ZiuRolledBackGeneration = mngr.Generation
+		}); err != nil {
+			return err
+		}
+		v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuRollback,
+			"ZIU is rolled back, services are not updated to image %s till the Manager spec is changed", image)
+		if _, ok := mngr.Annotations[v1alpha1.ZiuRollbackAnnotation]; !ok {
+			return nil
+		}
+		if mngr, err := v1alpha1.GetManagerObject(clnt); err != nil {
+			return err
+		} else {
+			delete(mngr.Annotations, v1alpha1.ZiuRollbackAnnotation)
+			return clnt.Update(context.Background(), mngr)
+		}
+	}
+
+	stage := ziuStage - 1
+	status := ziuStageStatus(mngr, stage)
+	if status == nil || status.Phase != v1alpha1.ZiuStageRolledBack {
+		log.Info("Rollback ZIU stage", "ziuStage", stage, "kind", v1alpha1.ZiuKinds[stage])
+		cm := &corev1.ConfigMap{}
+		if err := clnt.Get(context.Background(), getObjectKey(mngr.Namespace, ziuSnapshotName(mngr)), cm); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		params := map[string]interface{}{"Snapshot": cm}
+		if _, err := iterateOverKindInstances(v1alpha1.ZiuKinds[stage], restoreZiuResource, clnt, params); err != nil {
+			return err
+		}
+		if err := updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+			status.ZiuRollback = true
+			if int(stage) < len(status.ZiuStages) {
+				now := v1.Now()
+				status.ZiuStages[stage].Phase = v1alpha1.ZiuStageRolledBack
+				status.ZiuStages[stage].CompletionTime = &now
+			}
+		}); err != nil {
+			return err
+		}
+		v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuStageRolledBack,
+			"ZIU stage %d (%s) is rolled back", stage, v1alpha1.ZiuKinds[stage])
+		return nil
+	}
+
+	if isUpdated, err := isServiceUpdated(stage, ziuRollbackChecks, clnt); err != nil || !isUpdated {
+		log.Info("Wait for reverting services", "ziuStage", stage, "err", err)
+		return err
+	}
+	return v1alpha1.SetZiuStage(int(stage), clnt)
+}
+
+func processZiuStage(ziuStage v1alpha1.ZIUStatus, clnt client.Client, scheme *runtime.Scheme) error {
+	params := map[string]interface{}{"Scheme": scheme}
+	if _, err := iterateOverKindInstances(v1alpha1.ZiuKinds[ziuStage], updateZiuResource, clnt, params); err != nil {
+		return err
+	}
+	return updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+		status.ZiuState = ziuStage + 1
+		if int(ziuStage) < len(status.ZiuStages) {
+			now := v1.Now()
+			s := &status.ZiuStages[ziuStage]
+			s.Phase = v1alpha1.ZiuStageInProgress
+			s.StartTime = &now
+			s.CompletionTime = nil
+			s.Message = ""
+		}
+	})
+}
+
+func ReconcileZiu(namespace string, log logr.Logger, clnt client.Client, scheme *runtime.Scheme) (reconcile.Result, error) {
+	ziuLock.Lock()
+	defer ziuLock.Unlock()
+
+	reqLogger := log.WithName("ZIU")
+	restartTime, _ := time.ParseDuration("15s")
+	requeueResult := reconcile.Result{Requeue: true, RequeueAfter: restartTime}
+
+	ziuStage, err := v1alpha1.GetZiuStage(clnt)
+	if err != nil {
+		reqLogger.Error(err, "Error in ZIU")
+		return requeueResult, err
+	}
+	if ziuStage < 0 {
+		if mngr, err := v1alpha1.GetManagerObject(clnt); err != nil {
+			return requeueResult, err
+		} else if mngr.IsZiuRolledBack() {
+			// services are not updated by the Manager till its spec is changed
+			return reconcile.Result{}, nil
+		} else if mngr.Status.ZiuRolledBackImage != "" {
+			reqLogger.Info("Manager spec is changed after ZIU rollback", "image", mngr.Status.ZiuRolledBackImage)
+			if err = updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+				status.ZiuRolledBackImage = ""
+				status.ZiuRolledBackGeneration = 0
+			}); err != nil {
+				return requeueResult, err
+			}
+		}
+		var f bool
+		f, err = v1alpha1.IsZiuRequired(clnt)
+		if err != nil {
+			reqLogger.Error(err, "Error in ZIU")
+			return requeueResult, err
+		}
+		if f {
+			log.Info("Start ZIU process")
+			return requeueResult, StartZiu(namespace, clnt, scheme, reqLogger)
+		}
+		return reconcile.Result{}, err
+	}
+	if len(v1alpha1.ZiuKinds) == 0 {
+		// operator has been restarted in the middle of ZIU
+		if _, err := v1alpha1.ResumeZiu(clnt); err != nil {
+			return requeueResult, err
+		}
+	}
+
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	if err != nil {
+		return requeueResult, err
+	}
+	plan, err := mngr.ZiuPlan(clnt)
+	if err != nil {
+		return requeueResult, err
+	}
+
+	if isZiuRollbackRequested(mngr) {
+		return requeueResult, rollbackZiu(ziuStage, mngr, clnt, reqLogger)
+	}
+
+	// We have to wait previous stage updated and ready
+	if ziuStage > 0 {
+		prevStage := ziuPlanStage(plan, ziuStage-1)
+		prevStatus := ziuStageStatus(mngr, ziuStage-1)
+		if prevStatus != nil && prevStatus.Phase == v1alpha1.ZiuStageFailed {
+			reqLogger.Info("ZIU stage failed", "ziuStage", ziuStage-1, "kind", prevStage.Kind, "message", prevStatus.Message)
+			return requeueResult, nil
+		}
+		if isUpdated, err := isServiceUpdated(ziuStage-1, prevStage.ReadinessChecks, clnt); err != nil || !isUpdated {
+			if err == nil && isZiuStageTimedOut(prevStage, prevStatus) {
+				reqLogger.Info("ZIU stage timed out", "ziuStage", ziuStage-1, "kind", prevStage.Kind)
+				message := fmt.Sprintf("Services are not ready in %d seconds", *prevStage.Timeout)
+				if err = setZiuStagePhase(ziuStage-1, v1alpha1.ZiuStageFailed, message, clnt); err != nil {
+					return requeueResult, err
+				}
+				v1alpha1.RecordEvent(mngr, corev1.EventTypeWarning, v1alpha1.EventReasonZiuStageFailed,
+					"ZIU stage %d (%s) failed: %s", ziuStage-1, prevStage.Kind, message)
+				if plan.AutoRollback != nil && *plan.AutoRollback {
+					reqLogger.Info("Start ZIU rollback")
+					if err = updateZiuStatus(clnt, func(status *v1alpha1.ManagerStatus) {
+						status.ZiuRollback = true
+					}); err == nil {
+						v1alpha1.RecordEvent(mngr, corev1.EventTypeWarning, v1alpha1.EventReasonZiuRollback, "ZIU rollback is started automatically")
+					}
+				}
+				return requeueResult, err
+			}
+			reqLogger.Info("Wait for updating services", "ziuStage", ziuStage-1, "err", err)
+			return requeueResult, err
+		}
+		if prevStatus == nil || prevStatus.Phase != v1alpha1.ZiuStageCompleted {
+			if err := runZiuHooks(prevStage.PostHooks, prevStage.Kind, namespace, clnt, scheme, reqLogger); err != nil {
+				return requeueResult, err
+			}
+			if err := setZiuStagePhase(ziuStage-1, v1alpha1.ZiuStageCompleted, "", clnt); err != nil {
+				return requeueResult, err
+			}
+			v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuStageCompleted,
+				"ZIU stage %d (%s) is completed", ziuStage-1, prevStage.Kind)
+		}
+	}
+	if len(v1alpha1.ZiuKinds) == int(ziuStage) {
+		// ZIU have been finished - set stage to -1
+		reqLogger.Info("ZIU done")
+		if err := v1alpha1.SetZiuStage(-1, clnt); err != nil {
+			return requeueResult, err
+		}
+		v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuCompleted, "ZIU is completed")
+		return requeueResult, nil
+	}
+	stage := ziuPlanStage(plan, ziuStage)
+	if err := runZiuHooks(stage.PreHooks, stage.Kind, namespace, clnt, scheme, reqLogger); err != nil {
+		return requeueResult, err
+	}
+	reqLogger.Info("Process ZIU stage", "ziuStage", ziuStage)
+	if err := processZiuStage(ziuStage, clnt, scheme); err != nil {
+		return requeueResult, err
+	}
+	v1alpha1.RecordEvent(mngr, corev1.EventTypeNormal, v1alpha1.EventReasonZiuStageStarted, "ZIU stage %d (%s) is started", ziuStage, stage.Kind)
+	return requeueResult, nil
+}
@@ -211,15 +211,12 @@ func enableZiu2011ForCR(kind, namespace string, clnt client.Client, scheme *runt
 	return nil
 }
 
-func EnableZiu2011ForCR(kind, namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error {
-	if err := enableZiu2011ForCR(kind, namespace, clnt, scheme, log); err != nil {
-		return err
-	}
-	if kind == "QueryEngine" || kind == "Analytics" || kind == "AnalyticsAlarm" {
-		// Kinds depend on redis, so patch it one more times to ensure it is active
-		if err := enableZiu2011ForCR("Redis", namespace, clnt, scheme, log); err != nil {
-			return err
-		}
-	}
-	return nil
+func init() {
+	RegisterZiuHook(v1alpha1.ZiuHookPrepare2011, func(kind, namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error {
+		return EnableZiu2011(namespace, clnt, scheme, log)
+	})
+	RegisterZiuHook(v1alpha1.ZiuHookResetStatusNodes2011, enableZiu2011ForCR)
+	RegisterZiuHook(v1alpha1.ZiuHookResetRedisStatusNodes2011, func(kind, namespace string, clnt client.Client, scheme *runtime.Scheme, log logr.Logger) error {
+		return enableZiu2011ForCR("Redis", namespace, clnt, scheme, log)
+	})
 }
@@ -23,7 +23,6 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -101,7 +100,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New("rabbitmq-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("rabbitmq-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("rabbitmq-controller"), &v1alpha1.Rabbitmq{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -222,21 +223,6 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 	v1alpha1.AddCAVolumeToIntendedSTS(statefulSet)
 	v1alpha1.AddSecretVolumesToIntendedSTS(statefulSet, request.Name)
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	utils.CleanupContainers(&statefulSet.Spec.Template.Spec, instance.Spec.ServiceConfiguration.Containers)
 	for idx := range statefulSet.Spec.Template.Spec.Containers {
 
@@ -278,6 +264,7 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 		}
 		return requeueReconcile, nil
 	}
+	instance.Status.CredentialsVersion = v1alpha1.CredentialsVersion(secret.Data, "user", "password", "vhost")
 
 	if created, err := v1alpha1.CreateServiceSTS(instance, instanceType, statefulSet, r.Client); err != nil || created {
 		if err != nil {
@@ -308,11 +295,10 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 	}
 
 	if len(podIPList) > 0 {
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
 		// TODO: Services can be run on masters only, ensure that pods number is
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -341,6 +327,12 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 		}
 	}
 
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, instance.RemoveMember, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil {
@@ -356,5 +348,23 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 		return requeueReconcile, nil
 	}
 
-	return reconcile.Result{}, nil
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
+	rotationInterval, err := instance.ReconcileCredentialsRotation(podIPList, secret, r.Client)
+	if err != nil {
+		if v1alpha1.IsOKForRequeque(err) {
+			return requeueReconcile, nil
+		}
+		reqLogger.Error(err, "Failed to rotate credentials.")
+		return reconcile.Result{}, err
+	}
+
+	// credentials are read from the source again on the next reconcile
+	requeueAfter := instance.Spec.ServiceConfiguration.CredentialsSource.RefreshInterval()
+	if rotationInterval > 0 && (requeueAfter == 0 || rotationInterval < requeueAfter) {
+		requeueAfter = rotationInterval
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
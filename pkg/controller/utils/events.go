@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+// ReconcilerWithEvents wraps the reconciler to record Warning event for the instance of the request
+// when reconcile fails, errors expected to be resolved by requeue (e.g. conflicts) are not recorded.
+// The instance is an empty object of the reconciled kind.
+func ReconcilerWithEvents(r reconcile.Reconciler, clnt client.Client, recorder record.EventRecorder, instance runtime.Object) reconcile.Reconciler {
+	return reconcile.Func(func(request reconcile.Request) (reconcile.Result, error) {
+		result, err := r.Reconcile(request)
+		if err != nil && !v1alpha1.IsOKForRequeque(err) {
+			obj := instance.DeepCopyObject()
+			if getErr := clnt.Get(context.TODO(), request.NamespacedName, obj); getErr == nil {
+				v1alpha1.Eventf(recorder, obj, corev1.EventTypeWarning, v1alpha1.EventReasonReconcileFailed, "Reconcile failed: %v", err)
+			}
+		}
+		return result, err
+	})
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcilerWithEvents(t *testing.T) {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	control := &v1alpha1.Control{ObjectMeta: metav1.ObjectMeta{Name: "control1", Namespace: "tf"}}
+	cl := fake.NewFakeClientWithScheme(scheme, control)
+	recorder := record.NewFakeRecorder(10)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "control1", Namespace: "tf"}}
+
+	var reconcileErr error
+	r := ReconcilerWithEvents(reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, reconcileErr
+	}), cl, recorder, &v1alpha1.Control{})
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	reconcileErr = k8serrors.NewConflict(schema.GroupResource{Resource: "controls"}, "control1",
+		fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	_, err = r.Reconcile(request)
+	require.Error(t, err)
+	assert.Empty(t, recorder.Events, "conflicts are resolved by requeue")
+
+	reconcileErr = fmt.Errorf("config is not valid")
+	_, err = r.Reconcile(request)
+	require.Error(t, err)
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning ReconcileFailed Reconcile failed: config is not valid", <-recorder.Events)
+
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "control2", Namespace: "tf"}})
+	require.Error(t, err)
+	assert.Empty(t, recorder.Events, "no event for deleted instance")
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+// HealthCheckedObject is an instance which pods are checked to be healthy after restart
+type HealthCheckedObject interface {
+	v1alpha1.StatusObject
+	PodHealthy(pod *corev1.Pod) (bool, error)
+}
+
+// RestartPods restarts outdated pods of the OnDelete statefulset of the instance one by one,
+// waiting for the restarted pod to be ready and healthy before restarting the next one.
+// Returns true if the restart is in progress or was interrupted by a conflict,
+// so that reconcile is to be requeued.
+func RestartPods(instance HealthCheckedObject, instanceType string, maxUnavailable *int, clnt client.Client) (bool, error) {
+	restarting, err := v1alpha1.RestartOnDeletePods(instance, instanceType, maxUnavailable, instance.PodHealthy, clnt)
+	if err != nil {
+		if v1alpha1.IsOKForRequeque(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if restarting {
+		log.Info("Pods are restarting", "instance", instance.GetName(), "type", instanceType)
+	}
+	return restarting, nil
+}
+
+// ScaleDown reduces replicas of the statefulset of the instance to the configured replicas or the number of nodes,
+// members of leaving pods are removed from the cluster of the service by removeMember,
+// nil if the service has no cluster. Returns true if the scale-down is in progress
+// or was interrupted by a conflict, so that reconcile is to be requeued.
+func ScaleDown(instance v1alpha1.StatusObject, instanceType string, configuration *v1alpha1.PodConfiguration, removeMember v1alpha1.MemberRemoval, clnt client.Client) (bool, error) {
+	scalingDown, err := v1alpha1.ScaleDownSTS(instance, instanceType, configuration, removeMember, clnt)
+	if err != nil {
+		if v1alpha1.IsOKForRequeque(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if scalingDown {
+		log.Info("Scale-down is in progress", "instance", instance.GetName(), "type", instanceType, "status", instance.GetCommonStatus().ScaleDown)
+	}
+	return scalingDown, nil
+}
@@ -325,7 +325,7 @@ func ControlActiveChange() predicate.Funcs {
 	}
 }
 
-// RabbitmqActiveChange returns predicate function based on group kind.
+// RabbitmqActiveChange returns predicate function triggered by change of active status or credentials.
 func RabbitmqActiveChange() predicate.Funcs {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
@@ -337,7 +337,8 @@ func RabbitmqActiveChange() predicate.Funcs {
 			if !ok {
 				reqLogger.Info("type conversion mismatch")
 			}
-			return statusChange(oldRabbitmq.Status.Active, newRabbitmq.Status.Active)
+			return statusChange(oldRabbitmq.Status.Active, newRabbitmq.Status.Active) ||
+				oldRabbitmq.Status.CredentialsVersion != newRabbitmq.Status.CredentialsVersion
 		},
 	}
 }
@@ -453,19 +454,47 @@ func PodPhaseChanges(podLabels map[string]string) predicate.Funcs {
 	}
 }
 
+// CleanupContainers leaves containers and init containers of the pod which are in the spec
+// and applies resources of the spec to them
 func CleanupContainers(pod *corev1.PodSpec, spec []*v1alpha1.Container) {
 	var list []corev1.Container
 	for idx := range pod.Containers {
-		if GetContainerFromList(pod.Containers[idx].Name, spec) != nil {
+		if c := GetContainerFromList(pod.Containers[idx].Name, spec); c != nil {
+			ApplyContainerResources(&pod.Containers[idx], c.Resources)
 			list = append(list, pod.Containers[idx])
 		}
 	}
 	pod.Containers = list
 	list = []corev1.Container{}
 	for idx := range pod.InitContainers {
-		if GetContainerFromList(pod.InitContainers[idx].Name, spec) != nil {
+		if c := GetContainerFromList(pod.InitContainers[idx].Name, spec); c != nil {
+			ApplyContainerResources(&pod.InitContainers[idx], c.Resources)
 			list = append(list, pod.InitContainers[idx])
 		}
 	}
 	pod.InitContainers = list
 }
+
+// ApplyContainerResources sets requests and limits of the container,
+// resources which are not set keep values of the container (e.g. hugepages of vrouter agent)
+func ApplyContainerResources(container *corev1.Container, resources *corev1.ResourceRequirements) {
+	if resources == nil {
+		return
+	}
+	container.Resources.Requests = mergeResourceList(container.Resources.Requests, resources.Requests)
+	container.Resources.Limits = mergeResourceList(container.Resources.Limits, resources.Limits)
+}
+
+func mergeResourceList(current, intended corev1.ResourceList) corev1.ResourceList {
+	if len(intended) == 0 {
+		return current
+	}
+	result := corev1.ResourceList{}
+	for name, quantity := range current {
+		result[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range intended {
+		result[name] = quantity.DeepCopy()
+	}
+	return result
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestCleanupContainers(t *testing.T) {
@@ -44,6 +45,43 @@ func TestCleanupContainers(t *testing.T) {
 	require.Equal(t, "image2", statefulSet.Spec.Template.Spec.Containers[0].Image)
 }
 
+func TestCleanupContainersResources(t *testing.T) {
+	hugepages := resource.MustParse("2Gi")
+	pod := &corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name: "container1",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceHugePagesPrefix + "1Gi": hugepages},
+			},
+		}},
+		InitContainers: []corev1.Container{{Name: "init1"}},
+	}
+	filter := []*v1alpha1.Container{
+		{
+			Name: "container1",
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+		},
+		{
+			Name: "init1",
+			Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+		},
+	}
+	CleanupContainers(pod, filter)
+	require.Equal(t, corev1.ResourceList{
+		corev1.ResourceHugePagesPrefix + "1Gi": hugepages,
+		corev1.ResourceCPU:                     resource.MustParse("2"),
+	}, pod.Containers[0].Resources.Limits, "resources which are not set are kept")
+	require.Nil(t, pod.Containers[0].Resources.Requests)
+	require.Equal(t, "64Mi", pod.InitContainers[0].Resources.Requests.Memory().String())
+
+	filter[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("4")
+	require.Equal(t, "2", pod.Containers[0].Resources.Limits.Cpu().String(), "spec is copied")
+}
+
 func TestCleanupContainersSame(t *testing.T) {
 	statefulSet := &appsv1.StatefulSet{
 		Spec: appsv1.StatefulSetSpec{
@@ -110,7 +110,9 @@ func NewReconciler(client client.Client, scheme *runtime.Scheme, cfg *rest.Confi
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller.
-	c, err := controller.New("vrouter-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("vrouter-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("vrouter-controller"), &v1alpha1.Vrouter{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -303,6 +305,7 @@ func (r *ReconcileVrouter) Reconcile(request reconcile.Request) (reconcile.Resul
 		v1alpha1.SetLogLevelEnv(instance.Spec.CommonConfiguration.LogLevel, container)
 
 		container.Image = instanceContainer.Image
+		utils.ApplyContainerResources(container, instanceContainer.Resources)
 
 		if container.Command == nil {
 			command := []string{"bash", fmt.Sprintf("/etc/contrailconfigmaps/run-%s.sh", container.Name)}
@@ -335,6 +338,7 @@ func (r *ReconcileVrouter) Reconcile(request reconcile.Request) (reconcile.Resul
 				container.Command = instanceContainer.Command
 			}
 			container.Image = instanceContainer.Image
+			utils.ApplyContainerResources(container, instanceContainer.Resources)
 		}
 
 		container.VolumeMounts = append(container.VolumeMounts,
@@ -450,16 +454,26 @@ func (r *ReconcileVrouter) Reconcile(request reconcile.Request) (reconcile.Resul
 			// check configs and update params if needed
 			again, _ = instance.UpdateAgent(node.Name, agentStatus, vrouterPod, configMapAgent, r.Client)
 		} else {
-			// Upgrade case - wait till an user delete pod to let DaemonSet to create new with new images
+			// Upgrade case - wait till an user (or rolling upgrade) delete pod
+			// to let DaemonSet to create new with new images
 			agentStatus.Status = "Upgrading"
 			// Reset config sha to let UpdateAgent recreate it explicitly after upgraded pod be created
 			agentStatus.EncryptedParams = ""
+			if instance.IsRollingUpgradeEnabled() && agentStatus.UpgradeState == "" {
+				agentStatus.UpgradeState = v1alpha1.AgentUpgradePending
+			}
 			again = true
 		}
 
 		reconcileAgain = reconcileAgain || again
 	}
 
+	upgradeInProgress, err := instance.RollingUpgradeAgents(daemonSet, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to process rolling upgrade of agents")
+	}
+	reconcileAgain = reconcileAgain || upgradeInProgress || err != nil
+
 	falseVal := false
 	instance.Status.ActiveOnControllers = &falseVal
 	isControllerActive, err := instance.IsActiveOnControllers(r.Client)
@@ -10,7 +10,6 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -111,7 +110,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("webui-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("webui-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("webui-controller"), &v1alpha1.Webui{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -248,6 +249,10 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 	controlActive := controlInstance.IsActive(instance.Spec.ServiceConfiguration.ControlInstance, request.Namespace, r.Client)
 	if !configActive || !cassandraActive || !redisActive || !controlActive {
 		reqLogger.Info("Dependencies not ready", "db", cassandraActive, "redis", redisActive, "api", configActive, "control", controlActive)
+		dependencies := map[string]bool{"Config": configActive, "Cassandra": cassandraActive, "Redis": redisActive, "Control": controlActive}
+		if err := v1alpha1.UpdateDependenciesConditions(instance, dependencies, r.Client); err != nil && !v1alpha1.IsOKForRequeque(err) {
+			return reconcile.Result{}, err
+		}
 		return reconcile.Result{}, nil
 	}
 
@@ -359,21 +364,6 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 	v1alpha1.AddCommonVolumes(&statefulSet.Spec.Template.Spec, instance.Spec.CommonConfiguration)
 	v1alpha1.DefaultSecurityContext(&statefulSet.Spec.Template.Spec)
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	if created, err := v1alpha1.CreateServiceSTS(instance, instanceType, statefulSet, r.Client); err != nil || created {
 		if err != nil {
 			reqLogger.Error(err, "Failed to create the stateful set.")
@@ -405,10 +395,9 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 
 	if len(podIPList) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -437,6 +426,18 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 		}
 	}
 
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, nil, r.Client)
+	if err != nil {
+		log.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
+	restarting, err := utils.RestartPods(instance, instanceType, instance.Spec.CommonConfiguration.MaxUnavailable, r.Client)
+	if err != nil {
+		log.Error(err, "Failed to restart pods.")
+		return reconcile.Result{}, err
+	}
+
 	if err = r.updateStatus(instance, statefulSet, webuiService.ClusterIP()); err != nil {
 		if v1alpha1.IsOKForRequeque(err) {
 			return requeueReconcile, nil
@@ -450,6 +451,14 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 		return requeueReconcile, nil
 	}
 
+	if restarting {
+		return requeueReconcile, nil
+	}
+
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -462,6 +471,7 @@ func (r *ReconcileWebui) updateStatus(cr *v1alpha1.Webui, sts *appsv1.StatefulSe
 	degraded := sts.Status.ReadyReplicas < *sts.Spec.Replicas
 	cr.Status.Active = &active
 	cr.Status.Degraded = &degraded
+	cr.Status.SetStatefulSetConditions(cr.Generation, sts)
 	r.updatePorts(cr)
 	if err := r.updateServiceStatus(cr); err != nil {
 		return err
@@ -105,7 +105,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
 
-	c, err := controller.New("zookeeper-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("zookeeper-controller", mgr, controller.Options{
+		Reconciler: utils.ReconcilerWithEvents(r, mgr.GetClient(), mgr.GetEventRecorderFor("zookeeper-controller"), &v1alpha1.Zookeeper{}),
+	})
 	if err != nil {
 		return err
 	}
@@ -245,24 +247,16 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 		}
 	}
 
-	statefulSet.Spec.Template.Spec.Affinity = &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
-				LabelSelector: &metav1.LabelSelector{
-					MatchExpressions: []metav1.LabelSelectorRequirement{{
-						Key:      instanceType,
-						Operator: "In",
-						Values:   []string{request.Name},
-					}},
-				},
-				TopologyKey: "kubernetes.io/hostname",
-			}},
-		},
-	}
-
 	v1alpha1.AddCommonVolumes(&statefulSet.Spec.Template.Spec, instance.Spec.CommonConfiguration)
 	v1alpha1.DefaultSecurityContext(&statefulSet.Spec.Template.Spec)
 
+	storageStatus, err := v1alpha1.ApplyStorageToSTS(instance, instanceType, statefulSet, "zookeeper-data", instance.Spec.ServiceConfiguration.Storage, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to apply storage to the stateful set.")
+		return reconcile.Result{}, err
+	}
+	instance.Status.Storage = storageStatus
+
 	if created, err := v1alpha1.CreateServiceSTS(instance, instanceType, statefulSet, r.Client); err != nil || created {
 		if err != nil {
 			reqLogger.Error(err, "Failed to create the stateful set.")
@@ -294,10 +288,9 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 
 	if len(podIPList) > 0 {
 		// TODO: Services can be run on masters only, ensure that pods number is
-		nodeselector := instance.Spec.CommonConfiguration.NodeSelector
-		if nodes, err := v1alpha1.GetNodes(nodeselector, r.Client); err != nil || len(podIPList) < len(nodes) {
+		if intended, err := instance.Spec.CommonConfiguration.IntendedReplicas(r.Client); err != nil || len(podIPList) < int(intended) {
 			// to avoid redundand sts-es reloading configure only as STS pods are ready
-			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < nodes)", "pods", len(podIPList), "nodes", len(nodes))
+			reqLogger.Error(err, "Not enough pods are ready to generate configs (pods < replicas)", "pods", len(podIPList), "replicas", intended)
 			return requeueReconcile, err
 		}
 
@@ -317,6 +310,17 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 			return reconcile.Result{}, err
 		}
 
+		replicas, err := v1alpha1.GetSTSReplicas(r.Client, instance.Spec.CommonConfiguration.Replicas, statefulSet.Spec.Template.Spec.NodeSelector)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		var ensembleUpdated bool
+		var failedEnsemble bool = false
+		if ensembleUpdated, err = instance.ReconcileEnsemble(podIPList, replicas, r.Client); err != nil {
+			reqLogger.Error(err, "Failed to reconcile zookeeper ensemble.")
+			failedEnsemble = true
+		}
+
 		var nodes map[string]v1alpha1.NodeInfo
 		var failedAddZKNode bool = false
 		if nodes, err = instance.AddZKNode(podIPList); err != nil {
@@ -324,7 +328,7 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 			failedAddZKNode = true
 		}
 
-		if requeueNeeded, err := instance.ManageNodeStatus(nodes, r.Client); err != nil || requeueNeeded || failedAddZKNode {
+		if requeueNeeded, err := instance.ManageNodeStatus(nodes, r.Client); err != nil || requeueNeeded || failedAddZKNode || ensembleUpdated || failedEnsemble {
 			if err != nil {
 				reqLogger.Error(err, "Failed to manage node status.")
 				return reconcile.Result{}, err
@@ -338,6 +342,12 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 		return reconcile.Result{}, err
 	}
 
+	scalingDown, err := utils.ScaleDown(instance, instanceType, &instance.Spec.CommonConfiguration, instance.RemoveMember, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to scale down.")
+		return reconcile.Result{}, err
+	}
+
 	instance.Status.Active = new(bool)
 	instance.Status.Degraded = new(bool)
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, instance.Status.Degraded, statefulSet, request); err != nil {
@@ -353,6 +363,10 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 		return requeueReconcile, nil
 	}
 
+	if scalingDown {
+		return requeueReconcile, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 func (r *ReconcileZookeeper) ensurePodDisruptionBudgetExists(zookeeper *v1alpha1.Zookeeper) error {
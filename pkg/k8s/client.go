@@ -87,6 +87,18 @@ func GetV1Csr() v1cert.CertificateSigningRequestInterface {
 //          string: Errors. (STDERR)
 //           error: If any error has occurred otherwise `nil`
 func ExecToPodThroughAPI(command []string, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
+	var stdout bytes.Buffer
+	stderr, err := StreamFromPodThroughAPI(command, containerName, podName, namespace, stdin, &stdout)
+	if err != nil {
+		return "", "", err
+	}
+	return stdout.String(), stderr, nil
+}
+
+// StreamFromPodThroughAPI uninterractively exec to the pod with the command specified
+// and writes output of the command into stdout as it comes, so large outputs are not kept in memory.
+// Returns errors of the command (STDERR).
+func StreamFromPodThroughAPI(command []string, containerName, podName, namespace string, stdin io.Reader, stdout io.Writer) (string, error) {
 	req := GetCoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(podName).
@@ -94,7 +106,7 @@ func ExecToPodThroughAPI(command []string, containerName, podName, namespace str
 		SubResource("exec")
 	scheme := runtime.NewScheme()
 	if err := corev1.AddToScheme(scheme); err != nil {
-		return "", "", fmt.Errorf("error adding to scheme: %v", err)
+		return "", fmt.Errorf("error adding to scheme: %v", err)
 	}
 
 	parameterCodec := runtime.NewParameterCodec(scheme)
@@ -110,19 +122,19 @@ func ExecToPodThroughAPI(command []string, containerName, podName, namespace str
 
 	exec, err := remotecommand.NewSPDYExecutor(getClientConfig(), "POST", req.URL())
 	if err != nil {
-		return "", "", fmt.Errorf("error while creating Executor: %v", err)
+		return "", fmt.Errorf("error while creating Executor: %v", err)
 	}
 
-	var stdout, stderr bytes.Buffer
+	var stderr bytes.Buffer
 	err = exec.Stream(remotecommand.StreamOptions{
 		Stdin:  stdin,
-		Stdout: &stdout,
+		Stdout: stdout,
 		Stderr: &stderr,
 		Tty:    false,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("error in Stream: %v", err)
+		return stderr.String(), fmt.Errorf("error in Stream: %v", err)
 	}
 
-	return stdout.String(), stderr.String(), nil
+	return stderr.String(), nil
 }
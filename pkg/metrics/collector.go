@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+var log = logf.Log.WithName("metrics")
+
+var (
+	ziuStageDesc = prometheus.NewDesc(
+		"tf_operator_ziu_stage",
+		"Current stage of zero impact upgrade, -1 if upgrade is not in progress",
+		nil, nil)
+	resourceActiveDesc = prometheus.NewDesc(
+		"tf_operator_resource_active",
+		"1 if the resource is active",
+		[]string{"kind", "name"}, nil)
+	resourceDegradedDesc = prometheus.NewDesc(
+		"tf_operator_resource_degraded",
+		"1 if the resource is degraded",
+		[]string{"kind", "name"}, nil)
+	vrouterAgentDesc = prometheus.NewDesc(
+		"tf_operator_vrouter_agent_status",
+		"Status of vrouter agents, the value is always 1",
+		[]string{"vrouter", "agent", "status"}, nil)
+	certificateExpiryDesc = prometheus.NewDesc(
+		"tf_operator_certificate_expiry_timestamp_seconds",
+		"Expiry time of certificates issued for pods",
+		[]string{"secret", "certificate"}, nil)
+)
+
+// Collector exposes state of TF resources: ZIU stage, Active and Degraded of
+// resources, states of vrouter agents and expiry of certificates.
+// The state is read on every scrape, so the cached client of the manager is to be used.
+type Collector struct {
+	client    client.Client
+	scheme    *runtime.Scheme
+	namespace string
+}
+
+// NewCollector creates collector of the resources in the namespace
+func NewCollector(clnt client.Client, scheme *runtime.Scheme, namespace string) *Collector {
+	return &Collector{client: clnt, scheme: scheme, namespace: namespace}
+}
+
+// registered is the collector registered by Register
+var registered *Collector
+
+// Register registers collector in the registry of controller-runtime,
+// metrics of the registry are served by metrics server of the manager
+// together with reconcile counts and latencies of controllers.
+// The collector registered before is replaced as the operator is restarted with new manager.
+func Register(clnt client.Client, scheme *runtime.Scheme, namespace string) error {
+	if registered != nil {
+		ctrlmetrics.Registry.Unregister(registered)
+	}
+	registered = NewCollector(clnt, scheme, namespace)
+	return ctrlmetrics.Registry.Register(registered)
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ziuStageDesc
+	ch <- resourceActiveDesc
+	ch <- resourceDegradedDesc
+	ch <- vrouterAgentDesc
+	ch <- certificateExpiryDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if stage, err := v1alpha1.GetZiuStage(c.client); err == nil {
+		ch <- prometheus.MustNewConstMetric(ziuStageDesc, prometheus.GaugeValue, float64(stage))
+	} else {
+		log.Info("Failed to get ZIU stage", "err", err)
+	}
+	c.collectResources(ch)
+	c.collectVrouterAgents(ch)
+	c.collectCertificates(ch)
+}
+
+// resourceKinds returns kinds of TF resources except Manager, sorted
+func (c *Collector) resourceKinds() []string {
+	var kinds []string
+	for gvk := range c.scheme.AllKnownTypes() {
+		if gvk.GroupVersion() != v1alpha1.SchemeGroupVersion || !strings.HasSuffix(gvk.Kind, "List") {
+			continue
+		}
+		if kind := strings.TrimSuffix(gvk.Kind, "List"); kind != "Manager" {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (c *Collector) collectResources(ch chan<- prometheus.Metric) {
+	for _, kind := range c.resourceKinds() {
+		obj, err := c.scheme.New(v1alpha1.SchemeGroupVersion.WithKind(kind + "List"))
+		if err != nil {
+			continue
+		}
+		if err := c.client.List(context.TODO(), obj, client.InNamespace(c.namespace)); err != nil {
+			log.Info("Failed to list resources", "kind", kind, "err", err)
+			continue
+		}
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+			if err != nil {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(u, "metadata", "name")
+			if active, found, _ := unstructured.NestedBool(u, "status", "active"); found {
+				ch <- prometheus.MustNewConstMetric(resourceActiveDesc, prometheus.GaugeValue, boolValue(active), kind, name)
+			}
+			if degraded, found, _ := unstructured.NestedBool(u, "status", "degraded"); found {
+				ch <- prometheus.MustNewConstMetric(resourceDegradedDesc, prometheus.GaugeValue, boolValue(degraded), kind, name)
+			}
+		}
+	}
+}
+
+func (c *Collector) collectVrouterAgents(ch chan<- prometheus.Metric) {
+	vrouters := &v1alpha1.VrouterList{}
+	if err := c.client.List(context.TODO(), vrouters, client.InNamespace(c.namespace)); err != nil {
+		log.Info("Failed to list vrouters", "err", err)
+		return
+	}
+	for _, vrouter := range vrouters.Items {
+		for _, agent := range vrouter.Status.Agents {
+			if agent == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(vrouterAgentDesc, prometheus.GaugeValue, 1,
+				vrouter.Name, agent.Name, string(agent.Status))
+		}
+	}
+}
+
+func (c *Collector) collectCertificates(ch chan<- prometheus.Metric) {
+	secrets := &corev1.SecretList{}
+	if err := c.client.List(context.TODO(), secrets, client.InNamespace(c.namespace)); err != nil {
+		log.Info("Failed to list secrets", "err", err)
+		return
+	}
+	for _, secret := range secrets.Items {
+		if !strings.HasSuffix(secret.Name, "-secret-certificates") {
+			continue
+		}
+		for key, data := range secret.Data {
+			if !strings.HasSuffix(key, ".crt") {
+				continue
+			}
+			block, _ := pem.Decode(data)
+			if block == nil {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(certificateExpiryDesc, prometheus.GaugeValue,
+				float64(cert.NotAfter.Unix()), secret.Name, key)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+func testCert(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCollector(t *testing.T) {
+	require.NoError(t, os.Setenv(k8sutil.WatchNamespaceEnvVar, "tf"))
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+
+	trueVal, falseVal := true, false
+	cl := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.Manager{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "tf"},
+			Status:     v1alpha1.ManagerStatus{ZiuState: 2},
+		},
+		&v1alpha1.Control{
+			ObjectMeta: metav1.ObjectMeta{Name: "control1", Namespace: "tf"},
+			Status: v1alpha1.ControlStatus{CommonStatus: v1alpha1.CommonStatus{
+				Active: &trueVal, Degraded: &falseVal,
+			}},
+		},
+		&v1alpha1.Vrouter{
+			ObjectMeta: metav1.ObjectMeta{Name: "vrouter1", Namespace: "tf"},
+			Status: v1alpha1.VrouterStatus{
+				Active: &falseVal,
+				Agents: []*v1alpha1.AgentStatus{{Name: "node1", Status: "Ready"}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "control1-secret-certificates", Namespace: "tf"},
+			Data:       map[string][]byte{"server-10.0.0.1.crt": testCert(t, time.Unix(1924992000, 0)), "server-key-10.0.0.1.pem": []byte("key")},
+		},
+	)
+
+	expected := `
+# HELP tf_operator_certificate_expiry_timestamp_seconds Expiry time of certificates issued for pods
+# TYPE tf_operator_certificate_expiry_timestamp_seconds gauge
+tf_operator_certificate_expiry_timestamp_seconds{certificate="server-10.0.0.1.crt",secret="control1-secret-certificates"} 1.924992e+09
+# HELP tf_operator_resource_active 1 if the resource is active
+# TYPE tf_operator_resource_active gauge
+tf_operator_resource_active{kind="Control",name="control1"} 1
+tf_operator_resource_active{kind="Vrouter",name="vrouter1"} 0
+# HELP tf_operator_resource_degraded 1 if the resource is degraded
+# TYPE tf_operator_resource_degraded gauge
+tf_operator_resource_degraded{kind="Control",name="control1"} 0
+# HELP tf_operator_vrouter_agent_status Status of vrouter agents, the value is always 1
+# TYPE tf_operator_vrouter_agent_status gauge
+tf_operator_vrouter_agent_status{agent="node1",status="Ready",vrouter="vrouter1"} 1
+# HELP tf_operator_ziu_stage Current stage of zero impact upgrade, -1 if upgrade is not in progress
+# TYPE tf_operator_ziu_stage gauge
+tf_operator_ziu_stage 2
+`
+	require.NoError(t, testutil.CollectAndCompare(NewCollector(cl, scheme, "tf"), strings.NewReader(expected)))
+}
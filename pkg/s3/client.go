@@ -0,0 +1,322 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRegion = "us-east-1"
+
+	amzDateFormat  = "20060102T150405Z"
+	emptySHA256    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signingAlgName = "AWS4-HMAC-SHA256"
+)
+
+// Client is a minimal client of S3 compatible object store (AWS S3, MinIO, Ceph RGW).
+// Path-style addressing is used: <endpoint>/<bucket>/<key>
+type Client struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	HTTP      *http.Client
+	// now is used by tests
+	now func() time.Time
+}
+
+// Object is an object of the bucket listing
+type Object struct {
+	Key          string
+	LastModified time.Time
+	Size         int64
+}
+
+// NewClient creates client for the endpoint, e.g. https://minio.local:9000
+func NewClient(endpoint, region, accessKey, secretKey string) *Client {
+	if region == "" {
+		region = DefaultRegion
+	}
+	return &Client{
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		Region:    region,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		HTTP:      http.DefaultClient,
+		now:       time.Now,
+	}
+}
+
+// PutObject uploads the object
+func (c *Client) PutObject(bucket, key string, data []byte) error {
+	resp, err := c.do("PUT", bucket, key, nil, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PartSize is the size of parts of multipart uploads,
+// objects smaller than the part are uploaded by single request
+var PartSize = 16 << 20
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// PutObjectStream uploads the object read from r by parts, so only one part is kept in memory.
+// The upload is aborted if r returns an error.
+func (c *Client) PutObjectStream(bucket, key string, r io.Reader) error {
+	buf := make([]byte, PartSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return c.PutObject(bucket, key, buf[:n])
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do("POST", bucket, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	var upload initiateMultipartUploadResult
+	err = xml.NewDecoder(resp.Body).Decode(&upload)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	var parts []completedPart
+	for number := 1; err == nil; number++ {
+		query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {upload.UploadID}}
+		if resp, err = c.do("PUT", bucket, key, query, buf[:n]); err != nil {
+			break
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		if n, err = io.ReadFull(r, buf); err == io.ErrUnexpectedEOF {
+			// the last part is uploaded on the next iteration
+			err = nil
+		}
+	}
+	if err == io.EOF {
+		var body []byte
+		if body, err = xml.Marshal(completeMultipartUpload{Parts: parts}); err == nil {
+			if resp, err = c.do("POST", bucket, key, url.Values{"uploadId": {upload.UploadID}}, body); err == nil {
+				resp.Body.Close()
+				return nil
+			}
+		}
+	}
+	if resp, abortErr := c.do("DELETE", bucket, key, url.Values{"uploadId": {upload.UploadID}}, nil); abortErr == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+// GetObject downloads the object
+func (c *Client) GetObject(bucket, key string) ([]byte, error) {
+	body, err := c.GetObjectStream(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// GetObjectStream returns reader of the object content, the reader must be closed
+func (c *Client) GetObjectStream(bucket, key string) (io.ReadCloser, error) {
+	resp, err := c.do("GET", bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteObject deletes the object
+func (c *Client) DeleteObject(bucket, key string) error {
+	resp, err := c.do("DELETE", bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ListObjects lists all objects with the prefix
+func (c *Client) ListObjects(bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do("GET", bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range res.Contents {
+			objects = append(objects, Object{Key: o.Key, LastModified: o.LastModified, Size: o.Size})
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+func (c *Client) do(method, bucket, key string, query url.Values, body []byte) (*http.Response, error) {
+	u, err := url.Parse(c.Endpoint + "/" + bucket + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	c.sign(req, body)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s failed: %s %s", method, u.Path, resp.Status, string(msg))
+	}
+	return resp, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// uriEncode encodes string as required by AWS Signature Version 4
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (c *Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	date := now.Format("20060102")
+
+	payloadHash := emptySHA256
+	if len(body) > 0 {
+		payloadHash = sha256Hex(body)
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	var params []string
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(v, true))
+		}
+	}
+	sort.Strings(params)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		signingAlgName,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgName, c.AccessKey, scope, signedHeaders, signature))
+}
@@ -0,0 +1,332 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+	"github.com/tungstenfabric/tf-operator/pkg/certificates"
+)
+
+var log = logf.Log.WithName("webhooks")
+
+// certValidity is the validity of the serving certificate and of its CA
+var certValidity = 365 * 24 * time.Hour
+
+// certRenewBefore is the time before expiry when the serving certificate is issued again
+var certRenewBefore = 30 * 24 * time.Hour
+
+// certCheckInterval is the period of the check of the serving certificate, it must be much less than certRenewBefore
+var certCheckInterval = time.Hour
+
+// Setup registers admission webhooks of TF kinds in the webhook server of the manager
+// and writes the serving certificate into certDir. The certificate is kept in a Secret
+// to be reused by restarts and replicas of the operator, the webhooks are registered
+// in the API server with the Service pointing to the operator pod by the leader only.
+// The certificate is checked every certCheckInterval and is issued again before it expires,
+// every replica writes the renewed certificate into its certDir to be reloaded by the webhook server
+// and the leader updates the CA bundle of the webhooks.
+func Setup(mgr manager.Manager, clnt client.Client, namespace string, port int, certDir string) error {
+	if err := v1alpha1.AddWebhooksToManager(mgr); err != nil {
+		return err
+	}
+	operatorName, err := k8sutil.GetOperatorName()
+	if err != nil {
+		return err
+	}
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: operatorName + "-webhook", Namespace: namespace}}
+	caBundle, err := ensureServingCertificate(clnt, certDir, service)
+	if err != nil {
+		return err
+	}
+	if err = mgr.Add(renewalRunnable(func(stop <-chan struct{}) error {
+		wait.Until(func() {
+			if _, err := ensureServingCertificate(clnt, certDir, service); err != nil {
+				log.Error(err, "Failed to renew serving certificate of admission webhooks")
+			}
+		}, certCheckInterval, stop)
+		return nil
+	})); err != nil {
+		return err
+	}
+	// runnables of the manager are run under leader election unless they opt out
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		if err := register(clnt, mgr.GetScheme(), mgr.GetRESTMapper(), operatorName, service, port, caBundle); err != nil {
+			log.Error(err, "Failed to register admission webhooks")
+			return err
+		}
+		wait.Until(func() {
+			bundle, err := ensureServingCertificate(clnt, certDir, service)
+			if err == nil && !bytes.Equal(bundle, caBundle) {
+				if err = register(clnt, mgr.GetScheme(), mgr.GetRESTMapper(), operatorName, service, port, bundle); err == nil {
+					caBundle = bundle
+				}
+			}
+			if err != nil {
+				log.Error(err, "Failed to update CA bundle of admission webhooks")
+			}
+		}, certCheckInterval, stop)
+		return nil
+	}))
+}
+
+// renewalRunnable is run by every replica of the operator regardless of leader election
+type renewalRunnable func(<-chan struct{}) error
+
+func (r renewalRunnable) Start(stop <-chan struct{}) error {
+	return r(stop)
+}
+
+func (r renewalRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+// register creates the Service of the webhook server in the operator pod and the webhook configurations
+func register(clnt client.Client, scheme *runtime.Scheme, mapper meta.RESTMapper, operatorName string, service *corev1.Service, port int, caBundle []byte) error {
+	if _, err := controllerutil.CreateOrUpdate(context.TODO(), clnt, service, func() error {
+		service.Spec.Selector = map[string]string{"name": operatorName}
+		service.Spec.Ports = []corev1.ServicePort{{
+			Name:       "webhook",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       443,
+			TargetPort: intstr.FromInt(port),
+		}}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	mutating, validating, err := newWebhooks(v1alpha1.WebhookObjects, scheme, mapper, service, caBundle)
+	if err != nil {
+		return err
+	}
+	name := operatorName + "-" + service.Namespace
+	mutatingConfig := &admissionregistrationv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err = controllerutil.CreateOrUpdate(context.TODO(), clnt, mutatingConfig, func() error {
+		mutatingConfig.Webhooks = mutating
+		return nil
+	}); err != nil {
+		return err
+	}
+	validatingConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err = controllerutil.CreateOrUpdate(context.TODO(), clnt, validatingConfig, func() error {
+		validatingConfig.Webhooks = validating
+		return nil
+	}); err != nil {
+		return err
+	}
+	log.Info("Admission webhooks are registered", "service", service.Name, "port", port)
+	return nil
+}
+
+// newWebhooks makes webhooks of objects with paths of controller-runtime webhook builder
+func newWebhooks(objects []runtime.Object, scheme *runtime.Scheme, mapper meta.RESTMapper, service *corev1.Service, caBundle []byte,
+) ([]admissionregistrationv1.MutatingWebhook, []admissionregistrationv1.ValidatingWebhook, error) {
+	var mutating []admissionregistrationv1.MutatingWebhook
+	var validating []admissionregistrationv1.ValidatingWebhook
+	failurePolicy := admissionregistrationv1.Fail
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, nil, err
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules := []admissionregistrationv1.RuleWithOperations{{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{gvk.Group},
+				APIVersions: []string{gvk.Version},
+				Resources:   []string{mapping.Resource.Resource},
+			},
+		}}
+		suffix := strings.Replace(gvk.Group, ".", "-", -1) + "-" + gvk.Version + "-" + strings.ToLower(gvk.Kind)
+		clientConfig := func(prefix string) admissionregistrationv1.WebhookClientConfig {
+			path := "/" + prefix + "-" + suffix
+			return admissionregistrationv1.WebhookClientConfig{
+				Service:  &admissionregistrationv1.ServiceReference{Namespace: service.Namespace, Name: service.Name, Path: &path},
+				CABundle: caBundle,
+			}
+		}
+		name := strings.ToLower(gvk.Kind) + "." + gvk.Group
+		if _, ok := obj.(admission.Defaulter); ok {
+			mutating = append(mutating, admissionregistrationv1.MutatingWebhook{
+				Name:                    "m" + name,
+				ClientConfig:            clientConfig("mutate"),
+				Rules:                   rules,
+				FailurePolicy:           &failurePolicy,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+			})
+		}
+		if _, ok := obj.(admission.Validator); ok {
+			validating = append(validating, admissionregistrationv1.ValidatingWebhook{
+				Name:                    "v" + name,
+				ClientConfig:            clientConfig("validate"),
+				Rules:                   rules,
+				FailurePolicy:           &failurePolicy,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+			})
+		}
+	}
+	return mutating, validating, nil
+}
+
+// ensureServingCertificate reads the serving certificate of service from its Secret,
+// the certificate is issued again if it is absent, is issued for other service or expires soon.
+// The certificate is written into certDir as expected by the webhook server and the CA bundle is returned.
+// The bundle keeps the CA of the previous certificate, so replicas which have not reloaded
+// the renewed certificate yet are trusted as well.
+func ensureServingCertificate(clnt client.Client, certDir string, service *corev1.Service) ([]byte, error) {
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Name: service.Name + "-cert", Namespace: service.Namespace}
+	err := clnt.Get(context.TODO(), name, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+	if !exists || !isServingCertificateValid(secret.Data, service) {
+		data, err := issueServingCertificate(service)
+		if err != nil {
+			return nil, err
+		}
+		if exists && len(secret.Data[caCertKey]) > 0 {
+			data[previousCACertKey] = secret.Data[caCertKey]
+		}
+		secret.ObjectMeta.Name = name.Name
+		secret.ObjectMeta.Namespace = name.Namespace
+		secret.Data = data
+		if exists {
+			err = clnt.Update(context.TODO(), secret)
+		} else {
+			err = clnt.Create(context.TODO(), secret)
+		}
+		if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+			// other replica of the operator has issued the certificate at the same time
+			err = clnt.Get(context.TODO(), name, secret)
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Serving certificate of admission webhooks is issued", "secret", name.Name)
+	}
+
+	if err = os.MkdirAll(certDir, 0700); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if err = ioutil.WriteFile(filepath.Join(certDir, key), secret.Data[key], 0600); err != nil {
+			return nil, err
+		}
+	}
+	return append(append([]byte{}, secret.Data[caCertKey]...), secret.Data[previousCACertKey]...), nil
+}
+
+const (
+	caCertKey         = "ca.crt"
+	previousCACertKey = "previous-ca.crt"
+)
+
+// isServingCertificateValid checks that the certificate is signed by the CA for the service
+// and is not going to expire in certRenewBefore
+func isServingCertificateValid(data map[string][]byte, service *corev1.Service) bool {
+	if len(data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false
+	}
+	block, err := certificates.GetAndDecodePem(data, corev1.TLSCertKey)
+	if err != nil || block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data[caCertKey]) {
+		return false
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:     service.Name + "." + service.Namespace + ".svc",
+		Roots:       roots,
+		CurrentTime: time.Now().Add(certRenewBefore),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err == nil
+}
+
+// issueServingCertificate issues the certificate of service by a new self-signed CA
+// and returns the data of the Secret with the certificate, its key and the CA certificate
+func issueServingCertificate(service *corev1.Service) (map[string][]byte, error) {
+	caTemplate, caKey, err := certificates.GenerateCaCertificateTemplateEx(service.Name+"-ca", certValidity)
+	if err != nil {
+		return nil, err
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, &caTemplate, &caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ca certificate: %w", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, certificates.CertKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	serialNumber, err := certificates.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	host := service.Name + "." + service.Namespace + ".svc"
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{service.Name, service.Name + "." + service.Namespace, host, host + ".cluster.local"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certPem, caPem, err := certificates.SignCertificateSelfCA(caDer, x509.MarshalPKCS1PrivateKey(caKey), template, key.Public())
+	if err != nil {
+		return nil, err
+	}
+	keyPem, err := certificates.EncodeInPemFormat(x509.MarshalPKCS1PrivateKey(key), certificates.PrivateKeyPemType)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		caCertKey:               caPem,
+		corev1.TLSCertKey:       certPem,
+		corev1.TLSPrivateKeyKey: keyPem,
+	}, nil
+}
@@ -0,0 +1,194 @@
+package webhooks
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/tungstenfabric/tf-operator/pkg/apis/tf/v1alpha1"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	require.NoError(t, err, "Failed to build scheme")
+	require.NoError(t, corev1.SchemeBuilder.AddToScheme(scheme), "Failed to add CoreV1 into scheme")
+	require.NoError(t, admissionregistrationv1.SchemeBuilder.AddToScheme(scheme), "Failed to add AdmissionregistrationV1 into scheme")
+	return scheme
+}
+
+func newMapper(t *testing.T, scheme *runtime.Scheme) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, obj := range v1alpha1.WebhookObjects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		require.NoError(t, err)
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return mapper
+}
+
+func newService() *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "tf-operator-webhook", Namespace: "tf"}}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "webhook-certs")
+	require.NoError(t, err)
+	return dir
+}
+
+func servingSecret(t *testing.T, cl client.Client) *corev1.Secret {
+	secret := &corev1.Secret{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "tf-operator-webhook-cert", Namespace: "tf"}, secret))
+	return secret
+}
+
+func TestServingCertificateIsReused(t *testing.T) {
+	cl := fake.NewFakeClientWithScheme(newScheme(t))
+	service := newService()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	dir1 := filepath.Join(dir, "1")
+	caBundle, err := ensureServingCertificate(cl, dir1, service)
+	require.NoError(t, err)
+	assert.NotEmpty(t, caBundle)
+	secret := servingSecret(t, cl)
+	assert.Equal(t, caBundle, secret.Data[caCertKey])
+	assert.True(t, isServingCertificateValid(secret.Data, service))
+	cert, err := ioutil.ReadFile(filepath.Join(dir1, corev1.TLSCertKey))
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data[corev1.TLSCertKey], cert)
+
+	// restart or other replica of the operator
+	dir2 := filepath.Join(dir, "2")
+	caBundle2, err := ensureServingCertificate(cl, dir2, service)
+	require.NoError(t, err)
+	assert.Equal(t, caBundle, caBundle2, "CA must not be changed on restart")
+	key, err := ioutil.ReadFile(filepath.Join(dir2, corev1.TLSPrivateKeyKey))
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data[corev1.TLSPrivateKeyKey], key)
+}
+
+func TestServingCertificateIsRenewed(t *testing.T) {
+	cl := fake.NewFakeClientWithScheme(newScheme(t))
+	service := newService()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	caBundle, err := ensureServingCertificate(cl, dir, service)
+	require.NoError(t, err)
+
+	defer func(d time.Duration) { certRenewBefore = d }(certRenewBefore)
+	certRenewBefore = certValidity + time.Hour
+	caBundle2, err := ensureServingCertificate(cl, dir, service)
+	require.NoError(t, err)
+	assert.NotEqual(t, caBundle, caBundle2, "certificate expiring soon must be issued again")
+	data := servingSecret(t, cl).Data
+	assert.Equal(t, caBundle, data[previousCACertKey])
+	assert.Equal(t, append(append([]byte{}, data[caCertKey]...), caBundle...), caBundle2,
+		"pods serving the previous certificate are trusted until they reload the renewed one")
+	cert, err := ioutil.ReadFile(filepath.Join(dir, corev1.TLSCertKey))
+	require.NoError(t, err)
+	assert.Equal(t, data[corev1.TLSCertKey], cert)
+
+	// other replica writes the renewed certificate into its directory on the next check
+	dir2 := filepath.Join(dir, "2")
+	caBundle3, err := ensureServingCertificate(cl, dir2, service)
+	require.NoError(t, err)
+	assert.NotEqual(t, caBundle2, caBundle3, "certificate is renewed once more as it always expires soon in the test")
+	certRenewBefore = time.Hour
+	caBundle4, err := ensureServingCertificate(cl, dir2, service)
+	require.NoError(t, err)
+	assert.Equal(t, caBundle3, caBundle4, "renewed certificate is reused")
+}
+
+func TestRenewalRunnable(t *testing.T) {
+	var r interface{} = renewalRunnable(func(<-chan struct{}) error { return nil })
+	runnable, ok := r.(manager.LeaderElectionRunnable)
+	require.True(t, ok)
+	assert.False(t, runnable.NeedLeaderElection(), "certificate is renewed by every replica")
+}
+
+func TestServingCertificateOfOtherService(t *testing.T) {
+	cl := fake.NewFakeClientWithScheme(newScheme(t))
+	service := newService()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := ensureServingCertificate(cl, dir, service)
+	require.NoError(t, err)
+	data := servingSecret(t, cl).Data
+
+	other := newService()
+	other.Namespace = "other"
+	assert.False(t, isServingCertificateValid(data, other))
+	delete(data, corev1.TLSPrivateKeyKey)
+	assert.False(t, isServingCertificateValid(data, service))
+}
+
+func TestNewWebhooks(t *testing.T) {
+	scheme := newScheme(t)
+	caBundle := []byte("ca")
+	mutating, validating, err := newWebhooks(v1alpha1.WebhookObjects, scheme, newMapper(t, scheme), newService(), caBundle)
+	require.NoError(t, err)
+
+	defaulters := 0
+	for _, obj := range v1alpha1.WebhookObjects {
+		if _, ok := obj.(admission.Defaulter); ok {
+			defaulters++
+		}
+	}
+	assert.Len(t, mutating, defaulters)
+	assert.Len(t, validating, len(v1alpha1.WebhookObjects))
+
+	m := validating[0]
+	assert.Equal(t, "vmanager.tf.tungsten.io", m.Name)
+	assert.Equal(t, "/validate-tf-tungsten-io-v1alpha1-manager", *m.ClientConfig.Service.Path)
+	assert.Equal(t, "tf-operator-webhook", m.ClientConfig.Service.Name)
+	assert.Equal(t, caBundle, m.ClientConfig.CABundle)
+	assert.Equal(t, []string{"managers"}, m.Rules[0].Resources)
+	assert.Equal(t, admissionregistrationv1.Fail, *m.FailurePolicy)
+	assert.Equal(t, "/mutate-tf-tungsten-io-v1alpha1-manager", *mutating[0].ClientConfig.Service.Path)
+}
+
+func TestRegister(t *testing.T) {
+	scheme := newScheme(t)
+	cl := fake.NewFakeClientWithScheme(scheme)
+	caBundle := []byte("ca")
+
+	require.NoError(t, register(cl, scheme, newMapper(t, scheme), "tf-operator", newService(), 9443, caBundle))
+
+	service := &corev1.Service{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "tf-operator-webhook", Namespace: "tf"}, service))
+	assert.Equal(t, map[string]string{"name": "tf-operator"}, service.Spec.Selector)
+	assert.Equal(t, 9443, service.Spec.Ports[0].TargetPort.IntValue())
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "tf-operator-tf"}, validating))
+	assert.Len(t, validating.Webhooks, len(v1alpha1.WebhookObjects))
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "tf-operator-tf"}, mutating))
+	assert.Equal(t, caBundle, mutating.Webhooks[0].ClientConfig.CABundle)
+
+	// registration by the next leader updates the configurations
+	require.NoError(t, register(cl, scheme, newMapper(t, scheme), "tf-operator", newService(), 9443, []byte("ca2")))
+	require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "tf-operator-tf"}, validating))
+	assert.Equal(t, []byte("ca2"), validating.Webhooks[0].ClientConfig.CABundle)
+}
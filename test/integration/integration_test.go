@@ -39,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	fakeclient "k8s.io/client-go/kubernetes/fake"
@@ -70,6 +71,15 @@ func requireZiuStage(t *testing.T, stage int, cl client.Client) {
 	require.Equal(t, v1alpha1.ZIUStatus(stage), ziuStage)
 }
 
+func requireZiuStagePhase(t *testing.T, stage int, phase v1alpha1.ZiuStagePhase, cl client.Client) {
+	mngr, err := v1alpha1.GetManagerObject(cl)
+	require.NoError(t, err)
+	require.Less(t, stage, len(mngr.Status.ZiuStages))
+	require.Equal(t, v1alpha1.ZiuKinds[stage], mngr.Status.ZiuStages[stage].Kind)
+	require.Equal(t, phase, mngr.Status.ZiuStages[stage].Phase)
+	require.NotNil(t, mngr.Status.ZiuStages[stage].StartTime)
+}
+
 func ziuReconcileObjects(m *manager.ReconcileManager) []reconcile.Reconciler {
 	cl := m.Client
 	scheme := m.Scheme
@@ -428,6 +438,7 @@ func testZIUUpdate(t *testing.T, initialVersion, targetVersion string) {
 		require.Equal(t, ziuReconcielResult, result)
 		t.Logf("Check ziu stage changed to %v", i+1)
 		requireZiuStage(t, i+1, clnt)
+		requireZiuStagePhase(t, i, v1alpha1.ZiuStageInProgress, clnt)
 
 		// 2nd run manager - ziu stage should not be changed till STS-es updated byt controllers
 		t.Logf("Reconcile manager 2")
@@ -483,6 +494,9 @@ func testZIUUpdate(t *testing.T, initialVersion, targetVersion string) {
 	require.NoError(t, err)
 	require.Equal(t, ziuReconcielResult, result)
 	requireZiuStage(t, -1, clnt)
+	for i := 0; i < end; i++ {
+		requireZiuStagePhase(t, i, v1alpha1.ZiuStageCompleted, clnt)
+	}
 	isZiuRequired, err = v1alpha1.IsZiuRequired(clnt)
 	require.NoError(t, err)
 	require.Equal(t, false, isZiuRequired)
@@ -490,3 +504,138 @@ func testZIUUpdate(t *testing.T, initialVersion, targetVersion string) {
 	// Check STSes target containers tag
 	requireAllStsTag(t, targetVersion, reconcileManager)
 }
+
+func TestZIU_Rollback(t *testing.T) {
+	initialVersion, targetVersion := "master", "new"
+	initialData := GetAllTestData(initialVersion)
+	initialData["ManagerList"] = GetTestData(targetVersion, "ManagerList")
+	runtimeScheme := runtimeScheme(t)
+	clnt := fake.NewFakeClientWithScheme(runtimeScheme, getObjectsList(initialData)...)
+	reconcileManager := &manager.ReconcileManager{
+		Client:  clnt,
+		Scheme:  runtimeScheme,
+		Manager: nil}
+	reconcileRequest := reconcile.Request{NamespacedName: types.NamespacedName{Name: "cluster1", Namespace: "tf"}}
+
+	require.NoError(t, manager.StartZiu("tf", clnt, runtimeScheme, logf.Log))
+
+	// Upgrade 1st stage
+	require.NoError(t, setUpdatedReplicas(0, 0, reconcileManager))
+	result, err := reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.Equal(t, ziuReconcielResult, result)
+	requireZiuStage(t, 1, clnt)
+	_, err = runReconcileStage(t, 0, reconcileManager)
+	require.NoError(t, err)
+	requireServiceStsTag(t, ziuObjectNames(0), targetVersion, reconcileManager)
+
+	// Request rollback
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	mngr.Annotations = map[string]string{v1alpha1.ZiuRollbackAnnotation: "true"}
+	require.NoError(t, clnt.Update(context.Background(), mngr))
+
+	// 1st stage spec is reverted, ZIU state is kept till services are ready
+	result, err = reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.Equal(t, ziuReconcielResult, result)
+	requireZiuStage(t, 1, clnt)
+	requireZiuStagePhase(t, 0, v1alpha1.ZiuStageRolledBack, clnt)
+	_, err = runReconcileStage(t, 0, reconcileManager)
+	require.NoError(t, err)
+	requireServiceStsTag(t, ziuObjectNames(0), initialVersion, reconcileManager)
+	require.NoError(t, updateReplicas(0, reconcileManager))
+	updateStatus(t, 0, reconcileManager)
+
+	result, err = reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.Equal(t, ziuReconcielResult, result)
+	requireZiuStage(t, 0, clnt)
+
+	// Rollback is done, ZIU is not restarted for the same images
+	result, err = reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.Equal(t, ziuReconcielResult, result)
+	requireZiuStage(t, -1, clnt)
+	mngr, err = v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	require.NotEmpty(t, mngr.Status.ZiuRolledBackImage)
+	require.NotContains(t, mngr.Annotations, v1alpha1.ZiuRollbackAnnotation)
+	isZiuRequired, err := v1alpha1.IsZiuRequired(clnt)
+	require.NoError(t, err)
+	require.Equal(t, false, isZiuRequired)
+	requireAllStsTag(t, initialVersion, reconcileManager)
+
+	// Manager is reconciled without requeue, services keep reverted specs
+	result, err = reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.NotEqual(t, ziuReconcielResult, result)
+	requireZiuStage(t, -1, clnt)
+	requireAllStsTag(t, initialVersion, reconcileManager)
+	mngr, err = v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	require.True(t, mngr.IsZiuRolledBack())
+
+	// The next change of the spec clears the rollback and ZIU is started again
+	mngr.Generation++
+	require.NoError(t, clnt.Update(context.Background(), mngr))
+	isZiuRequired, err = v1alpha1.IsZiuRequired(clnt)
+	require.NoError(t, err)
+	require.Equal(t, true, isZiuRequired)
+	result, err = reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.Equal(t, ziuReconcielResult, result)
+	mngr, err = v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	require.Empty(t, mngr.Status.ZiuRolledBackImage)
+	require.False(t, mngr.IsZiuRolledBack())
+}
+
+func TestRestoreGating(t *testing.T) {
+	runtimeScheme := runtimeScheme(t)
+	clnt := fake.NewFakeClientWithScheme(
+		runtimeScheme,
+		GetTestData("master", "ManagerList"),
+		GetTestData("master", "SecretList"),
+	)
+	reconcileManager := &manager.ReconcileManager{
+		Client:  clnt,
+		Scheme:  runtimeScheme,
+		Manager: nil}
+	reconcileRequest := reconcile.Request{NamespacedName: types.NamespacedName{Name: "cluster1", Namespace: "tf"}}
+	require.NoError(t, v1alpha1.SetZiuStage(-1, clnt))
+	configAllowed, err := v1alpha1.CanReconcile("Config", clnt)
+	require.NoError(t, err)
+
+	mngr, err := v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	mngr.Spec.Restore = &v1alpha1.RestoreConfiguration{Snapshot: "20210101T000000Z"}
+	require.NoError(t, clnt.Update(context.Background(), mngr))
+
+	// store of the backup is not set, restore waits for it
+	result, err := reconcileManager.Reconcile(reconcileRequest)
+	require.NoError(t, err)
+	require.True(t, result.Requeue)
+	mngr, err = v1alpha1.GetManagerObject(clnt)
+	require.NoError(t, err)
+	require.NotNil(t, mngr.Status.Restore)
+	require.Equal(t, v1alpha1.RestoreInProgress, mngr.Status.Restore.Phase)
+	require.NotEmpty(t, mngr.Status.Restore.Message)
+
+	for _, kind := range []string{"Config", "Control", "Vrouter"} {
+		f, err := v1alpha1.CanReconcile(kind, clnt)
+		require.NoError(t, err)
+		require.False(t, f, kind)
+	}
+	for _, kind := range []string{"Cassandra", "Zookeeper"} {
+		f, err := v1alpha1.CanReconcile(kind, clnt)
+		require.NoError(t, err)
+		require.True(t, f, kind)
+	}
+
+	mngr.Status.Restore.Phase = v1alpha1.RestoreCompleted
+	require.NoError(t, clnt.Status().Update(context.Background(), mngr))
+	f, err := v1alpha1.CanReconcile("Config", clnt)
+	require.NoError(t, err)
+	require.Equal(t, configAllowed, f)
+}